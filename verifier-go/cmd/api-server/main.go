@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	_ "github.com/mattn/go-sqlite3"
+
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/credential"
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/oidvp"
 	verifierModels "github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/vp"
@@ -21,7 +34,6 @@ import (
 const (
 	DefaultPort       = "8080"
 	DefaultIssuerDID  = "did:example:issuer"
-	DefaultIssuerKey  = "issuer-key-placeholder"
 	DefaultVPVerifyURI = "http://localhost:8080/api/vp/validate"
 )
 
@@ -31,16 +43,264 @@ type Server struct {
 	oidvpService      *oidvp.VerifierService
 	credentialService *credential.Service
 
+	// DID resolver shared by the VP service, for its cache counters
+	didResolver *crypto.DIDResolver
+
 	// HTTP server
 	httpServer *http.Server
 }
 
 func NewServer() *Server {
+	verifierGroups := loadVerifierGroups()
+	policies := loadCredentialPolicies()
+	options := append(loadIssuerStorage(policies),
+		credential.WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(policies...)),
+		credential.WithVerifierGroups(verifierGroups))
+	credentialService := credential.NewService(DefaultIssuerDID, loadIssuerKey(), options...)
+
+	// Register the issuer's public key so credentials issued by this server
+	// can be verified by its own VP validation endpoint
+	resolver := crypto.NewDIDResolver()
+	configureUniversalResolver(resolver)
+	configureDIDCache(resolver)
+	if publicKey := credentialService.PublicKey(); publicKey != nil {
+		resolver.RegisterLocalKey(DefaultIssuerDID, publicKey)
+	}
+
+	vpService := vp.NewServiceWithResolver(resolver)
+	vpService.SetVerifierGroups(verifierGroups)
+	configureKeyBindingMaxAge(vpService)
+
+	oidvpService := oidvp.NewVerifierService(DefaultVPVerifyURI)
+	oidvpService.SetPresentationValidator(vpService)
+
 	return &Server{
-		vpService:         vp.NewService(),
-		oidvpService:      oidvp.NewVerifierService(DefaultVPVerifyURI),
-		credentialService: credential.NewService(DefaultIssuerDID, DefaultIssuerKey),
+		vpService:         vpService,
+		oidvpService:      oidvpService,
+		credentialService: credentialService,
+		didResolver:       resolver,
+	}
+}
+
+// loadIssuerKey loads the issuer signing key (PEM or JWK) from ISSUER_KEY or
+// ISSUER_KEY_FILE. If neither is set, an ephemeral P-256 key is generated.
+func loadIssuerKey() string {
+	if key := os.Getenv("ISSUER_KEY"); key != "" {
+		return key
+	}
+
+	if path := os.Getenv("ISSUER_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read issuer key file: %v", err)
+		}
+		return string(data)
+	}
+
+	log.Println("ISSUER_KEY not set, generating an ephemeral issuer key (development only)")
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("Failed to generate issuer key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		log.Fatalf("Failed to encode issuer key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// loadSecret reads a secret from the environment variable name, or from the
+// file at name_FILE; empty if neither is set
+func loadSecret(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read %s_FILE: %v", name, err)
+		}
+		return strings.TrimSpace(string(data))
 	}
+	return ""
+}
+
+// loadIssuerStorage configures where issued credentials, status lists, tickets
+// and opaque ID seeds are stored. ISSUER_DB_DSN is a SQLite database
+// (mattn/go-sqlite3, ex: "issuer.db?_busy_timeout=5000"); without it everything
+// is kept in memory and lost on restart. Seeds are encrypted with the
+// key-encryption key VC_KEY_ENC and keyed by the pepper SEED_HOLDER_PEPPER
+// (or VC_KEY_ENC_FILE / SEED_HOLDER_PEPPER_FILE); both are required when a
+// policy enables pairwise seeds, as ephemeral keys would lose every seed.
+func loadIssuerStorage(policies []models.CredentialPolicyEntity) []credential.Option {
+	kek, pepper := loadSecret("VC_KEY_ENC"), loadSecret("SEED_HOLDER_PEPPER")
+
+	pairwise := false
+	for _, policy := range policies {
+		pairwise = pairwise || policy.PairwiseEnabled
+	}
+
+	if pairwise && kek == "" {
+		log.Fatal("VC_KEY_ENC is required for pairwise credential types")
+	}
+
+	dsn := os.Getenv("ISSUER_DB_DSN")
+	if dsn == "" {
+		log.Println("ISSUER_DB_DSN not set, storing credentials and seeds in memory (development only)")
+		if !pairwise {
+			return nil
+		}
+		registry := credential.NewOpaqueIDSeedRegistryWithRepository(repository.NewMemoryOpaqueIDSeedRepository(), kek, pepper)
+		if vcErr := registry.CheckKeys(); vcErr != nil {
+			log.Fatalf("Invalid opaque ID seed keys: %s", vcErr.Message)
+		}
+		return []credential.Option{credential.WithOpaqueIDSeedRegistry(registry)}
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		log.Fatalf("Failed to open issuer database: %v", err)
+	}
+
+	// The seed registry migrates stored seeds with its keys, so it opens the database first
+	registry, err := credential.NewSQLOpaqueIDSeedRegistry(ctx, db, kek, pepper)
+	if err != nil {
+		log.Fatalf("Failed to open opaque ID seed repository: %v", err)
+	}
+	if vcErr := registry.CheckKeys(); pairwise && vcErr != nil {
+		log.Fatalf("Invalid opaque ID seed keys: %s", vcErr.Message)
+	}
+	credentials, err := repository.NewSQLCredentialRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to open credential repository: %v", err)
+	}
+	statusLists, err := repository.NewSQLStatusListRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to open status list repository: %v", err)
+	}
+	tickets, err := repository.NewSQLTicketRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to open ticket repository: %v", err)
+	}
+
+	return []credential.Option{
+		credential.WithOpaqueIDSeedRegistry(registry),
+		credential.WithRepository(credentials),
+		credential.WithStatusListRepository(statusLists),
+		credential.WithTicketRepository(tickets),
+	}
+}
+
+// loadCredentialPolicies loads the credential policies (a JSON array of
+// models.CredentialPolicyEntity) from ISSUER_POLICY_FILE. If it is not set, a
+// one-year pairwise IdentityCredential policy is used.
+func loadCredentialPolicies() []models.CredentialPolicyEntity {
+	if path := os.Getenv("ISSUER_POLICY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read credential policy file: %v", err)
+		}
+		var policies []models.CredentialPolicyEntity
+		if err := json.Unmarshal(data, &policies); err != nil {
+			log.Fatalf("Failed to parse credential policy file: %v", err)
+		}
+		return policies
+	}
+
+	log.Println("ISSUER_POLICY_FILE not set, using a one-year IdentityCredential policy (development only)")
+	return []models.CredentialPolicyEntity{{
+		CredentialType:     "IdentityCredential",
+		ExpirationDuration: 1,
+		ExpirationTimeUnit: models.TimeUnitYear,
+		PairwiseEnabled:    true,
+	}}
+}
+
+// loadVerifierGroups loads the verifier groups sharing a pairwise_id from
+// PAIRWISE_GROUPS_FILE (a JSON array of {"group_id", "name", "domains"}).
+// If it is not set, no groups are published.
+func loadVerifierGroups() *pairwiseid.GroupRegistry {
+	registry, err := pairwiseid.NewGroupRegistry()
+	if err != nil {
+		log.Fatalf("Failed to load the Public Suffix List: %v", err)
+	}
+
+	path := os.Getenv("PAIRWISE_GROUPS_FILE")
+	if path == "" {
+		return registry
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read verifier group file: %v", err)
+	}
+	var groups []pairwiseid.Group
+	if err := json.Unmarshal(data, &groups); err != nil {
+		log.Fatalf("Failed to parse verifier group file: %v", err)
+	}
+	for _, group := range groups {
+		if _, err := registry.Register(group); err != nil {
+			log.Fatalf("Invalid verifier group %s: %v", group.ID, err)
+		}
+	}
+	return registry
+}
+
+// configureUniversalResolver resolves DIDs through the Universal Resolver at
+// UNIVERSAL_RESOLVER_URL, ex: a TWDIW DID registry gateway. It serves the
+// comma separated methods of UNIVERSAL_RESOLVER_METHODS (ex: "twdiw,ebsi"), or
+// every method without a built-in resolver if that is not set.
+func configureUniversalResolver(resolver *crypto.DIDResolver) {
+	baseURL := os.Getenv("UNIVERSAL_RESOLVER_URL")
+	if baseURL == "" {
+		return
+	}
+
+	universal := crypto.NewUniversalResolver(baseURL)
+	methods := os.Getenv("UNIVERSAL_RESOLVER_METHODS")
+	if methods == "" {
+		resolver.SetDefaultMethodResolver(universal)
+		return
+	}
+	for _, method := range strings.Split(methods, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			resolver.RegisterMethod(method, universal)
+		}
+	}
+}
+
+// configureDIDCache bounds the DID resolution cache to DID_CACHE_MAX_ENTRIES
+// DIDs (1000 by default)
+func configureDIDCache(resolver *crypto.DIDResolver) {
+	value := os.Getenv("DID_CACHE_MAX_ENTRIES")
+	if value == "" {
+		return
+	}
+
+	maxEntries, err := strconv.Atoi(value)
+	if err != nil || maxEntries <= 0 {
+		log.Fatalf("Invalid DID_CACHE_MAX_ENTRIES: %q", value)
+	}
+	config := crypto.DefaultDIDCacheConfig()
+	config.MaxEntries = maxEntries
+	resolver.SetCacheConfig(config)
+}
+
+// configureKeyBindingMaxAge sets how old a Key Binding JWT may be from
+// KB_JWT_MAX_AGE (a Go duration, e.g. "2m"); the default is vp.DefaultKeyBindingMaxAge
+func configureKeyBindingMaxAge(vpService *vp.Service) {
+	value := os.Getenv("KB_JWT_MAX_AGE")
+	if value == "" {
+		return
+	}
+
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge <= 0 {
+		log.Fatalf("Invalid KB_JWT_MAX_AGE: %q", value)
+	}
+	vpService.SetKeyBindingMaxAge(maxAge)
 }
 
 func (s *Server) Start(port string) error {
@@ -55,6 +315,15 @@ func (s *Server) Start(port string) error {
 	mux.HandleFunc("/api/credential/revoke", s.handleCredentialRevoke)      // PUT
 	mux.HandleFunc("/api/credential/suspend", s.handleCredentialSuspend)    // PUT
 	mux.HandleFunc("/api/credential/recover", s.handleCredentialRecover)    // PUT
+	mux.HandleFunc("/api/credential/status-history", s.handleCredentialStatusHistory) // GET
+	mux.HandleFunc("/api/credential/seed/rotate", s.handleSeedRotate)       // POST
+	mux.HandleFunc("/api/credential/seed/history", s.handleSeedHistory)     // POST
+
+	// Status list endpoints
+	mux.HandleFunc("/api/status-list/{credentialType}/{groupName}", s.handleStatusList) // GET
+
+	// Pairwise pseudonym endpoints
+	mux.HandleFunc("/api/pairwise/verifier-groups", s.handleVerifierGroups) // GET
 
 	// VP validation endpoints
 	mux.HandleFunc("/api/presentation/validation", s.handleVPValidation)    // POST
@@ -83,6 +352,8 @@ func (s *Server) Start(port string) error {
 	log.Printf("  POST   /api/credential               - Generate credential")
 	log.Printf("  GET    /api/credential/query?cid=... - Query credential")
 	log.Printf("  PUT    /api/credential/revoke?cid=.. - Revoke credential")
+	log.Printf("  GET    /api/credential/status-history?cid=.. - Credential status history")
+	log.Printf("  GET    /api/status-list/{type}/{group} - Status list credential")
 	log.Printf("  POST   /api/presentation/validation  - Validate VP")
 	log.Printf("  POST   /api/oidvp/verify             - Verify OID4VP")
 	log.Printf("  GET    /api/health                   - Health check")
@@ -108,6 +379,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 			"oidvp":      "ready",
 			"credential": "ready",
 		},
+		"did_cache": s.didResolver.CacheStats(),
 	})
 }
 
@@ -131,16 +403,20 @@ func (s *Server) handleCredentialGenerate(w http.ResponseWriter, r *http.Request
 	// Extract fields (simplified for demonstration)
 	issuerDID, _ := request["issuer_did"].(string)
 	credType, _ := request["credential_type"].(string)
+	credSubjectID, _ := request["credential_subject_id"].(string)
 	credSubject, _ := request["credential_subject"].(map[string]interface{})
+	nonce, _ := request["nonce"].(string)
 
 	if issuerDID == "" {
 		issuerDID = DefaultIssuerDID
 	}
 
 	req := &models.CredentialRequestDTO{
-		IssuerDID:         issuerDID,
-		CredentialType:    credType,
-		CredentialSubject: credSubject,
+		IssuerDID:           issuerDID,
+		CredentialType:      credType,
+		CredentialSubjectID: credSubjectID,
+		CredentialSubject:   credSubject,
+		Nonce:               nonce,
 	}
 
 	result, status, err := s.credentialService.Generate(ctx, req)
@@ -182,7 +458,7 @@ func (s *Server) handleCredentialRevoke(w http.ResponseWriter, r *http.Request)
 	cid := r.URL.Query().Get("cid")
 	ctx := r.Context()
 
-	result, status, _ := s.credentialService.Revoke(ctx, cid)
+	result, status, _ := s.credentialService.Revoke(ctx, cid, statusChangeFromQuery(r))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -199,7 +475,7 @@ func (s *Server) handleCredentialSuspend(w http.ResponseWriter, r *http.Request)
 	cid := r.URL.Query().Get("cid")
 	ctx := r.Context()
 
-	result, status, _ := s.credentialService.Suspend(ctx, cid)
+	result, status, _ := s.credentialService.Suspend(ctx, cid, statusChangeFromQuery(r))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -216,13 +492,153 @@ func (s *Server) handleCredentialRecover(w http.ResponseWriter, r *http.Request)
 	cid := r.URL.Query().Get("cid")
 	ctx := r.Context()
 
-	result, status, _ := s.credentialService.Recover(ctx, cid)
+	result, status, _ := s.credentialService.Recover(ctx, cid, statusChangeFromQuery(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(result))
+}
+
+// Credential status history endpoint
+func (s *Server) handleCredentialStatusHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cid := r.URL.Query().Get("cid")
+	ctx := r.Context()
+
+	result, status, _ := s.credentialService.StatusHistory(ctx, cid)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(result))
+}
+
+// Opaque ID seed rotation endpoint
+func (s *Server) handleSeedRotate(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeSeedRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	change := &models.StatusChange{ReasonCode: request.ReasonCode, Actor: request.Actor}
+	result, status, _ := s.credentialService.RotateSeed(ctx, request.HolderUID, request.CredentialType, change)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(result))
+}
+
+// Opaque ID seed history endpoint
+func (s *Server) handleSeedHistory(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeSeedRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	result, status, _ := s.credentialService.SeedHistory(ctx, request.HolderUID, request.CredentialType)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	w.Write([]byte(result))
 }
 
+// seedRequest is the body of the opaque ID seed endpoints
+type seedRequest struct {
+	HolderUID      string `json:"holder_uid"`
+	CredentialType string `json:"credential_type"`
+	ReasonCode     string `json:"reason_code,omitempty"`
+	Actor          string `json:"actor,omitempty"`
+}
+
+// decodeSeedRequest decodes the POST body of an opaque ID seed endpoint. The
+// holder UID is only accepted in the body, so it never reaches a URL recorded
+// by access logs, proxies or traces.
+func decodeSeedRequest(w http.ResponseWriter, r *http.Request) (*seedRequest, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if r.URL.Query().Has("holder_uid") {
+		http.Error(w, "holder_uid must be sent in the request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var request seedRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	return &request, true
+}
+
+// Status list endpoint
+// Serves the signed status list credential as a raw JWT when the client
+// accepts application/jwt or application/vc+jwt, and as JSON otherwise.
+func (s *Server) handleStatusList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	result, status, err := s.credentialService.GetStatusList(ctx, r.PathValue("credentialType"), r.PathValue("groupName"))
+
+	accept := r.Header.Get("Accept")
+	if err == nil && (strings.Contains(accept, "application/jwt") || strings.Contains(accept, "application/vc+jwt")) {
+		var statusList models.StatusListResponse
+		if json.Unmarshal([]byte(result), &statusList) == nil {
+			w.Header().Set("Content-Type", statusList.ContentType)
+			w.WriteHeader(status)
+			w.Write([]byte(statusList.StatusList))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(result))
+}
+
+// Verifier group metadata endpoint
+// Serves the signed verifier groups as a raw JWT when the client accepts
+// application/jwt, and as JSON otherwise.
+func (s *Server) handleVerifierGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	result, status, err := s.credentialService.GetVerifierGroups(ctx)
+
+	if err == nil && strings.Contains(r.Header.Get("Accept"), "application/jwt") {
+		var groups models.VerifierGroupsResponse
+		if json.Unmarshal([]byte(result), &groups) == nil {
+			w.Header().Set("Content-Type", groups.ContentType)
+			w.WriteHeader(status)
+			w.Write([]byte(groups.VerifierGroups))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(result))
+}
+
+// statusChangeFromQuery reads the optional reason_code and actor query parameters
+func statusChangeFromQuery(r *http.Request) *models.StatusChange {
+	return &models.StatusChange{
+		ReasonCode: r.URL.Query().Get("reason_code"),
+		Actor:      r.URL.Query().Get("actor"),
+	}
+}
+
 // VP validation endpoint
 func (s *Server) handleVPValidation(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -230,14 +646,37 @@ func (s *Server) handleVPValidation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var presentations []string
-	if err := json.NewDecoder(r.Body).Decode(&presentations); err != nil {
+	// The body is either {"presentations": [...], "nonce": ..., "client_id": ...},
+	// binding the presentations to the verifier's nonce and client_id, or a
+	// bare array of presentations (W3C VPs only; SD-JWT requires the binding)
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	ctx := r.Context()
-	result, status, _ := s.vpService.Validate(ctx, presentations)
+	var result string
+	var status int
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		var presentations []string
+		if err := json.Unmarshal(body, &presentations); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		result, status, _ = s.vpService.Validate(ctx, presentations)
+	} else {
+		var request struct {
+			Presentations []string `json:"presentations"`
+			Nonce         string   `json:"nonce"`
+			ClientID      string   `json:"client_id"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		result, status, _ = s.vpService.ValidateWithBinding(ctx, request.Presentations, request.Nonce, request.ClientID)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -343,8 +782,8 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		// Call next handler
 		next.ServeHTTP(w, r)
 
-		// Log request
-		log.Printf("%s %s %s", r.Method, r.RequestURI, time.Since(start))
+		// Log request, without the query string: it may carry identifiers
+		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
 	})
 }
 
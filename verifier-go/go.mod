@@ -6,10 +6,17 @@ replace github.com/moda-gov-tw/twdiw-issuer-go => ./issuer-go
 
 require github.com/moda-gov-tw/twdiw-issuer-go v0.0.0-00010101000000-000000000000
 
-require github.com/golang-jwt/jwt/v5 v5.3.0
+require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
+	github.com/fxamacker/cbor/v2 v2.5.0
+	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/veraison/go-cose v1.1.0
+)
 
 require (
-	github.com/fxamacker/cbor/v2 v2.5.0 // indirect
-	github.com/veraison/go-cose v1.1.0 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
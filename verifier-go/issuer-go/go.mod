@@ -1,3 +1,12 @@
 module github.com/moda-gov-tw/twdiw-issuer-go
 
 go 1.22.3
+
+require github.com/golang-jwt/jwt/v5 v5.3.0
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+	golang.org/x/net v0.21.0
+	golang.org/x/text v0.14.0
+)
@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// KeyEncryptionKey encrypts data at rest with AES-GCM.
+// Equivalent to Java's KeyUtils.GCMEncrypt / GCMDecrypt with VC_KEY_ENC, except
+// that every encryption uses a fresh random nonce, stored in front of the ciphertext.
+type KeyEncryptionKey struct {
+	aead cipher.AEAD
+}
+
+// ParseKeyEncryptionKey parses a base64 (standard or URL alphabet) encoded
+// AES-128, AES-192 or AES-256 key
+func ParseKeyEncryptionKey(encoded string) (*KeyEncryptionKey, error) {
+	key, err := decodeKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("key encryption key %w", err)
+	}
+
+	return NewKeyEncryptionKey(key)
+}
+
+// decodeKey decodes base64 key material in the standard or URL alphabet, with
+// or without padding
+func decodeKey(encoded string) ([]byte, error) {
+	trimmed := strings.TrimRight(strings.TrimSpace(encoded), "=")
+	if trimmed == "" {
+		return nil, fmt.Errorf("is empty")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(trimmed)
+	if err != nil {
+		if key, err = base64.RawURLEncoding.DecodeString(trimmed); err != nil {
+			return nil, fmt.Errorf("is not base64: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// NewKeyEncryptionKey creates a key encryption key from a 16, 24 or 32 byte AES key
+func NewKeyEncryptionKey(key []byte) (*KeyEncryptionKey, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key: %w", err)
+	}
+
+	return &KeyEncryptionKey{aead: aead}, nil
+}
+
+// Encrypt returns base64(nonce || ciphertext || tag). The additional data is
+// authenticated but not encrypted; the same value must be passed to Decrypt.
+func (k *KeyEncryptionKey) Encrypt(plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := k.aead.Seal(nonce, nonce, plaintext, additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. It fails if the ciphertext or the additional data
+// was modified, or if it was encrypted with another key.
+func (k *KeyEncryptionKey) Decrypt(ciphertext string, additionalData []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext is not base64: %w", err)
+	}
+	if len(sealed) < k.aead.NonceSize()+k.aead.Overhead() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, sealed := sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestKeyEncryptionKey_RoundTrip(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		key := bytes.Repeat([]byte{byte(size)}, size)
+
+		kek, err := ParseKeyEncryptionKey(base64.StdEncoding.EncodeToString(key))
+		if err != nil {
+			t.Fatalf("Failed to parse %d byte key: %v", size, err)
+		}
+
+		ciphertext, err := kek.Encrypt([]byte("secret"), []byte("aad"))
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		plaintext, err := kek.Decrypt(ciphertext, []byte("aad"))
+		if err != nil || string(plaintext) != "secret" {
+			t.Errorf("Expected secret, got %q (%v)", plaintext, err)
+		}
+	}
+}
+
+func TestKeyEncryptionKey_FreshNonce(t *testing.T) {
+	kek, err := NewKeyEncryptionKey(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+
+	first, _ := kek.Encrypt([]byte("secret"), nil)
+	second, _ := kek.Encrypt([]byte("secret"), nil)
+	if first == second {
+		t.Error("Expected different ciphertexts for the same plaintext")
+	}
+}
+
+func TestKeyEncryptionKey_DecryptFailures(t *testing.T) {
+	kek, _ := NewKeyEncryptionKey(make([]byte, 32))
+	otherKEK, _ := NewKeyEncryptionKey(bytes.Repeat([]byte{1}, 32))
+
+	ciphertext, err := kek.Encrypt([]byte("secret"), []byte("holder-1"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(ciphertext)
+	sealed[len(sealed)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(sealed)
+
+	tests := []struct {
+		name       string
+		kek        *KeyEncryptionKey
+		ciphertext string
+		aad        string
+	}{
+		{"Other key", otherKEK, ciphertext, "holder-1"},
+		{"Other additional data", kek, ciphertext, "holder-2"},
+		{"Tampered", kek, tampered, "holder-1"},
+		{"Too short", kek, base64.StdEncoding.EncodeToString(make([]byte, 8)), "holder-1"},
+		{"Not base64", kek, "not base64!", "holder-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.kek.Decrypt(tt.ciphertext, []byte(tt.aad)); err == nil {
+				t.Error("Expected decryption to fail")
+			}
+		})
+	}
+}
+
+func TestParseKeyEncryptionKey_Invalid(t *testing.T) {
+	for _, encoded := range []string{"", "   ", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 20))} {
+		if _, err := ParseKeyEncryptionKey(encoded); err == nil {
+			t.Errorf("Expected an error for %q", encoded)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// PrivateJWK represents a private JSON Web Key (RFC 7517 / RFC 7518)
+type PrivateJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// ParsePrivateKey parses an issuer signing key encoded either as PEM
+// (PKCS#8, SEC 1 or PKCS#1) or as a private JWK (EC, OKP or RSA)
+func ParsePrivateKey(data string) (stdcrypto.Signer, error) {
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" {
+		return nil, fmt.Errorf("private key is empty")
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		return ParsePrivateJWK([]byte(trimmed))
+	}
+
+	return ParsePrivateKeyPEM(trimmed)
+}
+
+// ParsePrivateKeyPEM parses a PEM-encoded private key
+func ParsePrivateKeyPEM(pemData string) (stdcrypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	// Try parsing as PKCS8
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(stdcrypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type: %T", key)
+		}
+		return signer, nil
+	}
+
+	// Try EC private key
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	// Try RSA private key
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse private key")
+}
+
+// ParsePrivateJWK parses a JSON-encoded private JWK
+func ParsePrivateJWK(data []byte) (stdcrypto.Signer, error) {
+	var jwk PrivateJWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("failed to parse JWK: %w", err)
+	}
+
+	if jwk.D == "" {
+		return nil, fmt.Errorf("JWK does not contain a private key")
+	}
+
+	switch jwk.Kty {
+	case "EC":
+		return ecPrivateKeyFromJWK(&jwk)
+	case "OKP":
+		return okpPrivateKeyFromJWK(&jwk)
+	case "RSA":
+		return rsaPrivateKeyFromJWK(&jwk)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// ecPrivateKeyFromJWK converts an EC JWK to an ECDSA private key
+func ecPrivateKeyFromJWK(jwk *PrivateJWK) (*ecdsa.PrivateKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+	}
+
+	d, err := decodeBigInt(jwk.D)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode d: %w", err)
+	}
+
+	if d.Sign() <= 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid private scalar for curve %s", jwk.Crv)
+	}
+
+	// Derive the public point from the private scalar and check it against
+	// the published coordinates, so a mismatched JWK is rejected up front
+	x, y := curve.ScalarBaseMult(d.FillBytes(make([]byte, (curve.Params().BitSize+7)/8)))
+	if jwk.X != "" || jwk.Y != "" {
+		jx, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x: %w", err)
+		}
+		jy, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode y: %w", err)
+		}
+		if jx.Cmp(x) != 0 || jy.Cmp(y) != 0 {
+			return nil, fmt.Errorf("public coordinates do not match private key")
+		}
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         d,
+	}, nil
+}
+
+// okpPrivateKeyFromJWK converts an OKP JWK to an Ed25519 private key
+func okpPrivateKeyFromJWK(jwk *PrivateJWK) (ed25519.PrivateKey, error) {
+	if jwk.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+	}
+
+	seed, err := base64.RawURLEncoding.DecodeString(jwk.D)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode d: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid Ed25519 seed length: %d", len(seed))
+	}
+
+	key := ed25519.NewKeyFromSeed(seed)
+	if jwk.X != "" {
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x: %w", err)
+		}
+		if !key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+			return nil, fmt.Errorf("public key does not match private key")
+		}
+	}
+
+	return key, nil
+}
+
+// rsaPrivateKeyFromJWK converts an RSA JWK to an RSA private key
+func rsaPrivateKeyFromJWK(jwk *PrivateJWK) (*rsa.PrivateKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode n: %w", err)
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode e: %w", err)
+	}
+	d, err := decodeBigInt(jwk.D)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode d: %w", err)
+	}
+	p, err := decodeBigInt(jwk.P)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode p: %w", err)
+	}
+	q, err := decodeBigInt(jwk.Q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode q: %w", err)
+	}
+
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid RSA public exponent")
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+		D:         d,
+		Primes:    []*big.Int{p, q},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid RSA key: %w", err)
+	}
+	key.Precompute()
+
+	return key, nil
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("value is empty")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
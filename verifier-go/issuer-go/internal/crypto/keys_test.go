@@ -0,0 +1,182 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParsePrivateKey_PEM(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	// SEC 1 encoding
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	pemData := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+	signer, err := ParsePrivateKey(pemData)
+	if err != nil {
+		t.Fatalf("Failed to parse PEM key: %v", err)
+	}
+
+	ecKey, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		t.Fatalf("Expected ECDSA key, got %T", signer)
+	}
+
+	if !ecKey.Equal(privateKey) {
+		t.Error("Parsed key does not match original key")
+	}
+}
+
+func TestParsePrivateKey_ECJWK(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	jwk := PrivateJWK{
+		Kty: "EC",
+		Crv: "P-384",
+		X:   base64.RawURLEncoding.EncodeToString(privateKey.X.FillBytes(make([]byte, 48))),
+		Y:   base64.RawURLEncoding.EncodeToString(privateKey.Y.FillBytes(make([]byte, 48))),
+		D:   base64.RawURLEncoding.EncodeToString(privateKey.D.FillBytes(make([]byte, 48))),
+	}
+	data, _ := json.Marshal(jwk)
+
+	signer, err := ParsePrivateKey(string(data))
+	if err != nil {
+		t.Fatalf("Failed to parse JWK: %v", err)
+	}
+
+	if !signer.(*ecdsa.PrivateKey).Equal(privateKey) {
+		t.Error("Parsed key does not match original key")
+	}
+
+	method, err := SigningMethod(signer)
+	if err != nil || method != jwt.SigningMethodES384 {
+		t.Errorf("Expected ES384, got %v (%v)", method, err)
+	}
+}
+
+func TestParsePrivateKey_ECJWKMismatchedPublicKey(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	jwk := PrivateJWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(otherKey.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(otherKey.Y.FillBytes(make([]byte, 32))),
+		D:   base64.RawURLEncoding.EncodeToString(privateKey.D.FillBytes(make([]byte, 32))),
+	}
+	data, _ := json.Marshal(jwk)
+
+	if _, err := ParsePrivateKey(string(data)); err == nil {
+		t.Error("Expected error for JWK with mismatched public coordinates")
+	}
+}
+
+func TestParsePrivateKey_OKPJWK(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	jwk := PrivateJWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		D:   base64.RawURLEncoding.EncodeToString(privateKey.Seed()),
+	}
+	data, _ := json.Marshal(jwk)
+
+	signer, err := ParsePrivateKey(string(data))
+	if err != nil {
+		t.Fatalf("Failed to parse JWK: %v", err)
+	}
+
+	if !signer.(ed25519.PrivateKey).Equal(privateKey) {
+		t.Error("Parsed key does not match original key")
+	}
+}
+
+func TestParsePrivateKey_RSAJWK(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	jwk := PrivateJWK{
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+		D:   base64.RawURLEncoding.EncodeToString(privateKey.D.Bytes()),
+		P:   base64.RawURLEncoding.EncodeToString(privateKey.Primes[0].Bytes()),
+		Q:   base64.RawURLEncoding.EncodeToString(privateKey.Primes[1].Bytes()),
+	}
+	data, _ := json.Marshal(jwk)
+
+	signer, err := ParsePrivateKey(string(data))
+	if err != nil {
+		t.Fatalf("Failed to parse JWK: %v", err)
+	}
+
+	if !signer.(*rsa.PrivateKey).PublicKey.Equal(&privateKey.PublicKey) {
+		t.Error("Parsed key does not match original key")
+	}
+}
+
+func TestParsePrivateKey_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Placeholder", "issuer-key"},
+		{"Public JWK", `{"kty":"EC","crv":"P-256","x":"AA","y":"AA"}`},
+		{"Unsupported kty", `{"kty":"oct","k":"AA","d":"AA"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePrivateKey(tt.data); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestSignJWT(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	signed, err := SignJWT(jwt.MapClaims{"iss": "did:example:issuer"}, privateKey, "did:example:issuer#key-1", "JWT")
+	if err != nil {
+		t.Fatalf("Failed to sign JWT: %v", err)
+	}
+
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return &privateKey.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify JWT: %v", err)
+	}
+
+	if token.Header["kid"] != "did:example:issuer#key-1" || token.Header["typ"] != "JWT" {
+		t.Errorf("Unexpected header: %v", token.Header)
+	}
+}
@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// MinPepperSize is the minimum size of a pepper in bytes
+const MinPepperSize = 32
+
+// Pepper is a secret HMAC-SHA256 key that turns identifiers into stable
+// pseudonymous lookup keys. Unlike a key-encryption key it is never rotated:
+// a new pepper makes every stored lookup key unreachable.
+type Pepper struct {
+	key []byte
+}
+
+// ParsePepper parses a base64 (standard or URL alphabet) encoded pepper of at
+// least MinPepperSize bytes
+func ParsePepper(encoded string) (*Pepper, error) {
+	key, err := decodeKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("pepper %w", err)
+	}
+
+	return NewPepper(key)
+}
+
+// NewPepper creates a pepper from at least MinPepperSize bytes of secret key material
+func NewPepper(key []byte) (*Pepper, error) {
+	if len(key) < MinPepperSize {
+		return nil, fmt.Errorf("pepper must be at least %d bytes, got %d", MinPepperSize, len(key))
+	}
+
+	return &Pepper{key: append([]byte(nil), key...)}, nil
+}
+
+// Key returns base64url(HMAC-SHA256(pepper, value)) without padding
+func (p *Pepper) Key(value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
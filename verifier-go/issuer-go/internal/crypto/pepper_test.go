@@ -0,0 +1,31 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestPepper_Key(t *testing.T) {
+	pepper, err := ParsePepper(base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		t.Fatalf("Failed to parse pepper: %v", err)
+	}
+	otherPepper, _ := NewPepper(bytes.Repeat([]byte{8}, 32))
+
+	key := pepper.Key("A123456789")
+	if len(key) != 43 || key != pepper.Key("A123456789") {
+		t.Errorf("Expected a stable 43 character key, got %q", key)
+	}
+	if key == pepper.Key("A123456780") || key == otherPepper.Key("A123456789") {
+		t.Error("Expected different keys for other values and peppers")
+	}
+}
+
+func TestParsePepper_Invalid(t *testing.T) {
+	for _, encoded := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := ParsePepper(encoded); err == nil {
+			t.Errorf("Expected an error for %q", encoded)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
+)
+
+// VerifierGroupsValidity is the validity of signed verifier group metadata
+const VerifierGroupsValidity = 24 * time.Hour
+
+// verifierGroupsContentType is the media type of signed verifier group metadata
+const verifierGroupsContentType = "application/jwt"
+
+// GetVerifierGroups returns the verifier groups sharing a pairwise_id, as a
+// JWT signed with the issuer key. Wallets verify it before deriving a group
+// pairwise_id for a verifier (pairwiseid.ParseGroupMetadata).
+func (s *Service) GetVerifierGroups(ctx context.Context) (string, int, error) {
+	if s.signingKey == nil {
+		vcErr := errors.NewVCError(
+			errors.ErrSysNotSetKeyYetError,
+			"issuer signing key is not set",
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	groups := s.verifierGroups
+	if groups == nil {
+		var err error
+		if groups, err = pairwiseid.NewGroupRegistry(); err != nil {
+			vcErr := errors.NewVCError(
+				errors.ErrSysCheckSettingError,
+				fmt.Sprintf("failed to load verifier groups: %v", err),
+			)
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+	}
+
+	metadata, err := groups.SignMetadata(s.issuerDID, s.signingKey, s.keyID, VerifierGroupsValidity)
+	if err != nil {
+		vcErr := errors.NewVCError(
+			errors.ErrDIDSignJWTError,
+			fmt.Sprintf("failed to sign verifier groups: %v", err),
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	response, _ := json.Marshal(&models.VerifierGroupsResponse{
+		VerifierGroups: metadata,
+		ContentType:    verifierGroupsContentType,
+	})
+	return string(response), http.StatusOK, nil
+}
@@ -0,0 +1,74 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
+)
+
+func TestGetVerifierGroups(t *testing.T) {
+	// Given
+	groups, err := pairwiseid.NewGroupRegistry()
+	if err != nil {
+		t.Fatalf("NewGroupRegistry failed: %v", err)
+	}
+	if _, err := groups.Register(pairwiseid.Group{ID: "group:moda", Domains: []string{"https://verifier.moda.gov.tw", "https://www.example.com"}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, WithVerifierGroups(groups))
+
+	// When
+	result, status, err := service.GetVerifierGroups(context.Background())
+
+	// Then - the metadata verifies with the issuer public key
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetVerifierGroups failed: %d %v", status, err)
+	}
+	var response models.VerifierGroupsResponse
+	if err := json.Unmarshal([]byte(result), &response); err != nil || response.ContentType != "application/jwt" {
+		t.Fatalf("Unexpected response: %s (%v)", result, err)
+	}
+	published, claims, err := pairwiseid.ParseGroupMetadata(response.VerifierGroups, service.PublicKey())
+	if err != nil {
+		t.Fatalf("ParseGroupMetadata failed: %v", err)
+	}
+	if claims.Issuer != "did:example:issuer" {
+		t.Errorf("Expected issuer did:example:issuer, got %s", claims.Issuer)
+	}
+	if group, ok, _ := published.GroupOf("example.com"); !ok || group.ID != "group:moda" {
+		t.Errorf("Expected example.com in group:moda, got %+v", group)
+	}
+}
+
+func TestGetVerifierGroups_NoGroups(t *testing.T) {
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey)
+
+	result, status, err := service.GetVerifierGroups(context.Background())
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetVerifierGroups failed: %d %v", status, err)
+	}
+
+	var response models.VerifierGroupsResponse
+	json.Unmarshal([]byte(result), &response)
+	published, _, err := pairwiseid.ParseGroupMetadata(response.VerifierGroups, service.PublicKey())
+	if err != nil || len(published.Groups()) != 0 {
+		t.Errorf("Expected signed metadata without groups, got %v", err)
+	}
+}
+
+func TestGetVerifierGroups_KeyNotSet(t *testing.T) {
+	service := NewService("did:example:issuer", "")
+
+	_, _, err := service.GetVerifierGroups(context.Background())
+
+	if vcErr, ok := err.(*errors.VCError); !ok || vcErr.Code != errors.ErrSysNotSetKeyYetError {
+		t.Errorf("Expected error %d, got %v", errors.ErrSysNotSetKeyYetError, err)
+	}
+}
@@ -1,24 +1,129 @@
 package credential
 
 import (
+	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
-	"sync"
+	"strconv"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/internal/crypto"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/policy"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
 )
 
-// OpaqueIDSeedRegistry manages opaque ID seeds for pairwise pseudonymous identifiers
+// OpaqueIDSeedRegistry manages opaque ID seeds for pairwise pseudonymous identifiers.
+// Seeds are encrypted with AES-GCM under a key-encryption key (KEK) before they
+// reach the repository, bound to their holder and credential type. The
+// repository never sees a holder UID: seeds are stored under a holder key,
+// HMAC-SHA256 of the UID with a separately managed pepper.
 type OpaqueIDSeedRegistry struct {
-	// In-memory storage for seeds (in production, use persistent storage)
-	seeds map[string]string // key: holderUID:credentialType, value: base64url-encoded seed
-	mu    sync.RWMutex
+	seeds repository.OpaqueIDSeedRepository
+
+	// Key-encryption key (nil if the configured key is missing or invalid)
+	kek *crypto.KeyEncryptionKey
+
+	// Holder key pepper (nil if the configured pepper is missing or invalid)
+	pepper *crypto.Pepper
 }
 
-// NewOpaqueIDSeedRegistry creates a new seed registry
+// NewOpaqueIDSeedRegistry creates an in-memory seed registry with an ephemeral
+// key-encryption key and pepper. Seeds are lost on restart; intended for tests
+// and development.
 func NewOpaqueIDSeedRegistry() *OpaqueIDSeedRegistry {
-	return &OpaqueIDSeedRegistry{
-		seeds: make(map[string]string),
+	r := &OpaqueIDSeedRegistry{seeds: repository.NewMemoryOpaqueIDSeedRepository()}
+
+	// Without a key or pepper every seed operation reports an error
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err == nil {
+		r.kek, _ = crypto.NewKeyEncryptionKey(key)
+	}
+	pepper := make([]byte, crypto.MinPepperSize)
+	if _, err := rand.Read(pepper); err == nil {
+		r.pepper, _ = crypto.NewPepper(pepper)
+	}
+
+	return r
+}
+
+// NewOpaqueIDSeedRegistryWithRepository creates a seed registry persisting
+// encrypted seeds to repo. kek is the base64 encoded AES-128/192/256
+// key-encryption key (Java's VC_KEY_ENC); a missing or invalid key is reported
+// (ErrSysNotSetVCKeyEncError) when a seed is used. pepper is the base64 encoded
+// holder key pepper of at least 32 bytes. It must be kept apart from the
+// key-encryption key and never change; a missing or invalid pepper is reported
+// (ErrSysCheckKeysValueError) when a seed is used.
+func NewOpaqueIDSeedRegistryWithRepository(repo repository.OpaqueIDSeedRepository, kek, pepper string) *OpaqueIDSeedRegistry {
+	r := &OpaqueIDSeedRegistry{seeds: repo}
+	if parsed, err := crypto.ParseKeyEncryptionKey(kek); err == nil {
+		r.kek = parsed
+	}
+	if parsed, err := crypto.ParsePepper(pepper); err == nil {
+		r.pepper = parsed
+	}
+	return r
+}
+
+// NewSQLOpaqueIDSeedRegistry creates a seed registry persisting encrypted seeds
+// to db, with kek and pepper as in NewOpaqueIDSeedRegistryWithRepository.
+// Pending migrations rewrite stored seeds with them, and fail if a key they
+// need is missing or invalid.
+func NewSQLOpaqueIDSeedRegistry(ctx context.Context, db *sql.DB, kek, pepper string) (*OpaqueIDSeedRegistry, error) {
+	r := NewOpaqueIDSeedRegistryWithRepository(nil, kek, pepper)
+	seeds, err := repository.NewSQLOpaqueIDSeedRepository(ctx, db, r.migrationKeys())
+	if err != nil {
+		return nil, err
 	}
+	r.seeds = seeds
+	return r, nil
+}
+
+// CheckKeys reports a missing or invalid key-encryption key
+// (ErrSysNotSetVCKeyEncError) or pepper (ErrSysCheckKeysValueError), so a
+// misconfigured registry can be refused at startup instead of on first use
+func (r *OpaqueIDSeedRegistry) CheckKeys() *errors.VCError {
+	if r.kek == nil {
+		return errors.NewVCError(errors.ErrSysNotSetVCKeyEncError, "opaque ID seed key encryption key is not set")
+	}
+	if r.pepper == nil {
+		return errors.NewVCError(errors.ErrSysCheckKeysValueError, "opaque ID seed holder key pepper is not set")
+	}
+	return nil
+}
+
+// seedHolder identifies the seeds of a holder for a credential type
+type seedHolder struct {
+	uid            string // binds seed ciphers; never stored
+	key            string // HMAC-SHA256(pepper, uid), the repository key
+	credentialType string
+}
+
+// SeedEpoch is a decrypted opaque ID seed and the epoch it belongs to
+type SeedEpoch struct {
+	Seed  string
+	Epoch int
+
+	// ValidUntil is the end of validity of the epoch (nil if it never expires)
+	ValidUntil *time.Time
+}
+
+// PendingSeed is the seed epoch to issue a credential with, and what must be
+// stored for it: nothing if the epoch is already stored, otherwise the new
+// epoch and the retirement of the epoch it replaces. CommitSeed stores it.
+type PendingSeed struct {
+	SeedEpoch
+
+	holder       seedHolder
+	created      *models.OpaqueIDSeed // the new epoch, nil if already stored
+	retire       *models.OpaqueIDSeed // the epoch to retire, nil if none
+	retireReason string
+	retiredBy    string
 }
 
 // GenerateOpaqueIDSeed generates or retrieves a cryptographically secure opaque ID seed
@@ -27,84 +132,197 @@ func NewOpaqueIDSeedRegistry() *OpaqueIDSeedRegistry {
 // The seed is:
 // - 256 bits (32 bytes) of cryptographic random data
 // - Base64url-encoded (43 characters)
-// - Stable for the same holder and credential type
+// - Stable for the same holder and credential type, across restarts, until it is rotated
 // - Used by wallet to derive verifier-specific pseudonyms via HMAC-SHA256
 //
-// @param holderUID Unique identifier for the holder (e.g., national ID hash)
+// @param holderUID Unique identifier for the holder (e.g., national ID hash); only a keyed hash of it is stored
 // @param credentialType Type of credential being issued
 // @return Base64url-encoded 256-bit random seed
-func (r *OpaqueIDSeedRegistry) GenerateOpaqueIDSeed(holderUID, credentialType string) (string, error) {
-	// Create composite key for registry lookup
-	registryKey := fmt.Sprintf("%s:%s", holderUID, credentialType)
+func (r *OpaqueIDSeedRegistry) GenerateOpaqueIDSeed(ctx context.Context, holderUID, credentialType string) (string, *errors.VCError) {
+	current, vcErr := r.CurrentSeed(ctx, holderUID, credentialType, policy.SeedRotation{})
+	if vcErr != nil {
+		return "", vcErr
+	}
+	return current.Seed, nil
+}
+
+// CurrentSeed returns the seed epoch to issue a credential with. The first
+// epoch is created on first use, and a new one after the current epoch was
+// revoked. An expired epoch is rotated if the rotation policy is periodic;
+// otherwise it must be rotated explicitly (RotateSeed) before issuing again.
+func (r *OpaqueIDSeedRegistry) CurrentSeed(ctx context.Context, holderUID, credentialType string, rotation policy.SeedRotation) (*SeedEpoch, *errors.VCError) {
+	pending, vcErr := r.PrepareSeed(ctx, holderUID, credentialType, rotation)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	return r.storeSeed(ctx, pending)
+}
 
-	// Check if seed already exists (to maintain stability across credential renewals)
-	r.mu.RLock()
-	existingSeed, exists := r.seeds[registryKey]
-	r.mu.RUnlock()
+// PrepareSeed works out the seed epoch CurrentSeed would return without
+// storing anything: a new epoch is generated in memory and stored only by
+// CommitSeed.
+func (r *OpaqueIDSeedRegistry) PrepareSeed(ctx context.Context, holderUID, credentialType string, rotation policy.SeedRotation) (*PendingSeed, *errors.VCError) {
+	if r.kek == nil {
+		return nil, errors.NewVCError(errors.ErrSysNotSetVCKeyEncError, "opaque ID seed key encryption key is not set")
+	}
 
-	if exists {
-		return existingSeed, nil
+	holder, vcErr := r.holder(holderUID, credentialType)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	latest, vcErr := r.findLatestSeed(ctx, holder)
+	if vcErr != nil {
+		return nil, vcErr
 	}
 
-	// Generate new 256-bit seed using cryptographic random number generator
-	seedBytes := make([]byte, 32) // 256 bits
-	if _, err := rand.Read(seedBytes); err != nil {
-		return "", fmt.Errorf("failed to generate random seed: %w", err)
+	switch {
+	case latest == nil:
+		return r.newSeed(holder, 1, rotation)
+	case latest.RetiredAt != nil:
+		return r.newSeed(holder, latest.Epoch+1, rotation)
+	case latest.ValidUntil != nil && !time.Now().Before(*latest.ValidUntil):
+		if !rotation.Periodic {
+			return nil, errors.NewVCError(
+				errors.ErrCredGenerateVCError,
+				fmt.Sprintf("opaque ID seed epoch %d expired at %s and must be rotated", latest.Epoch, latest.ValidUntil.Format(time.RFC3339)),
+			)
+		}
+		return r.nextSeed(holder, latest, models.SeedRetireReasonExpired, models.StatusActorSystem, rotation)
+	default:
+		current, vcErr := r.decryptSeed(holder, latest)
+		if vcErr != nil {
+			return nil, vcErr
+		}
+		return &PendingSeed{SeedEpoch: *current, holder: holder}, nil
 	}
+}
 
-	// Encode as base64url (URL-safe, no padding)
-	// Results in 43-character string
-	seed := base64.RawURLEncoding.EncodeToString(seedBytes)
+// CommitSeed stores a prepared seed epoch. It fails if another request stored
+// a different seed for the same epoch first, since the prepared seed was not
+// the one kept.
+func (r *OpaqueIDSeedRegistry) CommitSeed(ctx context.Context, pending *PendingSeed) *errors.VCError {
+	stored, vcErr := r.storeSeed(ctx, pending)
+	if vcErr != nil {
+		return vcErr
+	}
+	if stored.Seed != pending.Seed {
+		return errors.NewVCError(errors.ErrDBInsertError, fmt.Sprintf("opaque ID seed epoch %d changed concurrently", pending.Epoch))
+	}
+	return nil
+}
 
-	// Store for future retrievals
-	r.mu.Lock()
-	r.seeds[registryKey] = seed
-	r.mu.Unlock()
+// RotateSeed retires the current seed epoch and starts the next one. Retired
+// seeds are kept for audit. Wallets derive new pairwise IDs from the new seed;
+// verifiers see the epoch change in the credential.
+func (r *OpaqueIDSeedRegistry) RotateSeed(ctx context.Context, holderUID, credentialType, reason, actor string, rotation policy.SeedRotation) (*SeedEpoch, *errors.VCError) {
+	if r.kek == nil {
+		return nil, errors.NewVCError(errors.ErrSysNotSetVCKeyEncError, "opaque ID seed key encryption key is not set")
+	}
 
-	return seed, nil
+	holder, vcErr := r.holder(holderUID, credentialType)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	latest, vcErr := r.findLatestSeed(ctx, holder)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	var pending *PendingSeed
+	if latest == nil {
+		pending, vcErr = r.newSeed(holder, 1, rotation)
+	} else {
+		pending, vcErr = r.nextSeed(holder, latest, reason, actor, rotation)
+	}
+	if vcErr != nil {
+		return nil, vcErr
+	}
+
+	return r.storeSeed(ctx, pending)
 }
 
-// GetSeed retrieves an existing opaque ID seed if it exists
-func (r *OpaqueIDSeedRegistry) GetSeed(holderUID, credentialType string) (string, bool) {
-	registryKey := fmt.Sprintf("%s:%s", holderUID, credentialType)
+// GetSeed retrieves and decrypts the current opaque ID seed if one exists and is not revoked
+func (r *OpaqueIDSeedRegistry) GetSeed(ctx context.Context, holderUID, credentialType string) (string, bool, *errors.VCError) {
+	if r.kek == nil {
+		return "", false, errors.NewVCError(errors.ErrSysNotSetVCKeyEncError, "opaque ID seed key encryption key is not set")
+	}
 
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	holder, vcErr := r.holder(holderUID, credentialType)
+	if vcErr != nil {
+		return "", false, vcErr
+	}
+	latest, vcErr := r.findLatestSeed(ctx, holder)
+	if vcErr != nil || latest == nil || latest.RetiredAt != nil {
+		return "", false, vcErr
+	}
 
-	seed, exists := r.seeds[registryKey]
-	return seed, exists
+	current, vcErr := r.decryptSeed(holder, latest)
+	if vcErr != nil {
+		return "", false, vcErr
+	}
+
+	return current.Seed, true, nil
 }
 
-// RevokeSeed removes a seed from the registry (for credential revocation)
-func (r *OpaqueIDSeedRegistry) RevokeSeed(holderUID, credentialType string) {
-	registryKey := fmt.Sprintf("%s:%s", holderUID, credentialType)
+// RevokeSeed retires the current seed epoch (for credential revocation). The
+// seed is kept for audit; the next issuance starts a new epoch.
+func (r *OpaqueIDSeedRegistry) RevokeSeed(ctx context.Context, holderUID, credentialType string) *errors.VCError {
+	holder, vcErr := r.holder(holderUID, credentialType)
+	if vcErr != nil {
+		return vcErr
+	}
+	latest, vcErr := r.findLatestSeed(ctx, holder)
+	if vcErr != nil || latest == nil || latest.RetiredAt != nil {
+		return vcErr
+	}
+
+	return r.retire(ctx, latest, models.StatusReasonUnspecified, models.StatusActorSystem)
+}
+
+// SeedHistory lists every seed epoch of a holder and credential type, oldest
+// first. Seeds are returned without their cipher.
+func (r *OpaqueIDSeedRegistry) SeedHistory(ctx context.Context, holderUID, credentialType string) ([]models.OpaqueIDSeed, *errors.VCError) {
+	holder, vcErr := r.holder(holderUID, credentialType)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	seeds, err := r.seeds.FindSeeds(ctx, holder.key, credentialType)
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrDBQueryError, fmt.Sprintf("failed to load opaque ID seeds: %v", err))
+	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	for i := range seeds {
+		seeds[i].SeedCipher = ""
+	}
 
-	delete(r.seeds, registryKey)
+	return seeds, nil
 }
 
 // InjectOpaqueIDSeed adds the opaque_id_seed field to credential subject data
 //
 // This function:
-// 1. Generates or retrieves the opaque ID seed for the holder
+// 1. Prepares the opaque ID seed for the holder (PrepareSeed)
 // 2. Adds it to the credential subject map as "opaque_id_seed" field
 // 3. Ensures the field is marked for selective disclosure in SD-JWT
 //
+// A new or rotated seed is not stored: commit it with CommitSeed once the
+// credential is issued.
+//
 // @param credentialSubject Map of claims to be included in credential
 // @param holderUID Unique identifier for the holder
 // @param credentialType Type of credential being issued
-// @return Modified credential subject with opaque_id_seed, or error
+// @param rotation Seed rotation policy of the credential type
+// @return Modified credential subject with opaque_id_seed and the pending seed epoch, or error
 func (r *OpaqueIDSeedRegistry) InjectOpaqueIDSeed(
+	ctx context.Context,
 	credentialSubject map[string]interface{},
 	holderUID string,
 	credentialType string,
-) (map[string]interface{}, error) {
-	// Generate or retrieve opaque ID seed
-	seed, err := r.GenerateOpaqueIDSeed(holderUID, credentialType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate opaque ID seed: %w", err)
+	rotation policy.SeedRotation,
+) (map[string]interface{}, *PendingSeed, *errors.VCError) {
+	// Retrieve the opaque ID seed, or generate a new or rotated one
+	current, vcErr := r.PrepareSeed(ctx, holderUID, credentialType, rotation)
+	if vcErr != nil {
+		return nil, nil, vcErr
 	}
 
 	// Clone credential subject to avoid modifying input
@@ -114,9 +332,168 @@ func (r *OpaqueIDSeedRegistry) InjectOpaqueIDSeed(
 	}
 
 	// Add opaque_id_seed field
-	result["opaque_id_seed"] = seed
+	result[policy.OpaqueIDSeedClaim] = current.Seed
+
+	return result, current, nil
+}
+
+// holder derives the holder key of a holder UID. Seeds are only issued to
+// identified holders: an empty UID would give every anonymous holder the same seed.
+func (r *OpaqueIDSeedRegistry) holder(holderUID, credentialType string) (seedHolder, *errors.VCError) {
+	if r.pepper == nil {
+		return seedHolder{}, errors.NewVCError(errors.ErrSysCheckKeysValueError, "opaque ID seed holder key pepper is not set")
+	}
+	if holderUID == "" {
+		return seedHolder{}, errors.NewVCError(errors.ErrCredInvalidCredentialSubject, "holder UID is required for an opaque ID seed")
+	}
+
+	return seedHolder{uid: holderUID, key: r.pepper.Key(holderUID), credentialType: credentialType}, nil
+}
+
+// findLatestSeed loads the latest seed epoch (nil if the holder has none)
+func (r *OpaqueIDSeedRegistry) findLatestSeed(ctx context.Context, holder seedHolder) (*models.OpaqueIDSeed, *errors.VCError) {
+	latest, err := r.seeds.FindLatestSeed(ctx, holder.key, holder.credentialType)
+	if stderrors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrDBQueryError, fmt.Sprintf("failed to load opaque ID seed: %v", err))
+	}
+	return latest, nil
+}
+
+// nextSeed prepares the epoch after latest, retiring latest unless it already is
+func (r *OpaqueIDSeedRegistry) nextSeed(holder seedHolder, latest *models.OpaqueIDSeed, reason, actor string, rotation policy.SeedRotation) (*PendingSeed, *errors.VCError) {
+	pending, vcErr := r.newSeed(holder, latest.Epoch+1, rotation)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+	if latest.RetiredAt == nil {
+		pending.retire, pending.retireReason, pending.retiredBy = latest, reason, actor
+	}
+	return pending, nil
+}
+
+// retire records the retirement of an epoch. An epoch retired concurrently by
+// another request is left as it is.
+func (r *OpaqueIDSeedRegistry) retire(ctx context.Context, seed *models.OpaqueIDSeed, reason, actor string) *errors.VCError {
+	retiredAt := time.Now()
+	retired := *seed
+	retired.RetiredAt = &retiredAt
+	retired.RetireReason = reason
+	retired.RetiredBy = actor
+
+	err := r.seeds.RetireSeed(ctx, &retired)
+	if err != nil && !stderrors.Is(err, repository.ErrConflict) {
+		return errors.NewVCError(errors.ErrDBUpdateError, fmt.Sprintf("failed to retire opaque ID seed: %v", err))
+	}
+	return nil
+}
+
+// newSeed generates a new seed epoch, not yet stored
+func (r *OpaqueIDSeedRegistry) newSeed(holder seedHolder, epoch int, rotation policy.SeedRotation) (*PendingSeed, *errors.VCError) {
+	// Generate new 256-bit seed using cryptographic random number generator
+	seedBytes := make([]byte, 32) // 256 bits
+	if _, err := rand.Read(seedBytes); err != nil {
+		return nil, errors.NewVCError(errors.ErrSysGenerateKeyError, fmt.Sprintf("failed to generate random seed: %v", err))
+	}
 
-	return result, nil
+	// Encode as base64url (URL-safe, no padding)
+	// Results in 43-character string
+	seed := base64.RawURLEncoding.EncodeToString(seedBytes)
+
+	seedCipher, err := r.kek.Encrypt([]byte(seed), seedAdditionalData(holder.uid, holder.credentialType, epoch))
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrSysEncryptError, fmt.Sprintf("failed to encrypt opaque ID seed: %v", err))
+	}
+
+	now := time.Now()
+	validUntil := rotation.ValidUntil(now)
+	return &PendingSeed{
+		SeedEpoch: SeedEpoch{Seed: seed, Epoch: epoch, ValidUntil: validUntil},
+		holder:    holder,
+		created: &models.OpaqueIDSeed{
+			HolderKey:      holder.key,
+			CredentialType: holder.credentialType,
+			Epoch:          epoch,
+			SeedCipher:     seedCipher,
+			CreateTime:     now,
+			ValidUntil:     validUntil,
+		},
+	}, nil
+}
+
+// storeSeed stores a prepared seed epoch and returns the stored one. If
+// another request created the same epoch first, the stored one wins.
+func (r *OpaqueIDSeedRegistry) storeSeed(ctx context.Context, pending *PendingSeed) (*SeedEpoch, *errors.VCError) {
+	if pending.retire != nil {
+		if vcErr := r.retire(ctx, pending.retire, pending.retireReason, pending.retiredBy); vcErr != nil {
+			return nil, vcErr
+		}
+	}
+	if pending.created == nil {
+		return &pending.SeedEpoch, nil
+	}
+
+	err := r.seeds.SaveSeed(ctx, pending.created)
+	if stderrors.Is(err, repository.ErrDuplicate) {
+		latest, vcErr := r.findLatestSeed(ctx, pending.holder)
+		if vcErr != nil {
+			return nil, vcErr
+		}
+		if latest == nil || latest.Epoch != pending.Epoch {
+			return nil, errors.NewVCError(errors.ErrDBInsertError, fmt.Sprintf("opaque ID seed epoch %d changed concurrently", pending.Epoch))
+		}
+		return r.decryptSeed(pending.holder, latest)
+	}
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrDBInsertError, fmt.Sprintf("failed to save opaque ID seed: %v", err))
+	}
+
+	return &pending.SeedEpoch, nil
+}
+
+// decryptSeed decrypts a stored seed epoch of holder
+func (r *OpaqueIDSeedRegistry) decryptSeed(holder seedHolder, stored *models.OpaqueIDSeed) (*SeedEpoch, *errors.VCError) {
+	seed, err := r.kek.Decrypt(stored.SeedCipher, seedAdditionalData(holder.uid, holder.credentialType, stored.Epoch))
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrSysDecryptCipherError, fmt.Sprintf("failed to decrypt opaque ID seed: %v", err))
+	}
+
+	return &SeedEpoch{Seed: string(seed), Epoch: stored.Epoch, ValidUntil: stored.ValidUntil}, nil
+}
+
+// seedAdditionalData binds a seed cipher to its holder, credential type and
+// epoch, so a cipher copied to another row fails to decrypt. The raw holder
+// UID keeps the binding of seeds stored before holder keys.
+func seedAdditionalData(holderUID, credentialType string, epoch int) []byte {
+	data, _ := json.Marshal([]string{holderUID, credentialType, strconv.Itoa(epoch)})
+	return data
+}
+
+// bindEpoch re-encrypts the cipher of a seed stored before seed epochs, whose
+// additional data left the epoch out, as an epoch 1 seed (migration 8)
+func (r *OpaqueIDSeedRegistry) bindEpoch(seedCipher, holderUID, credentialType string) (string, error) {
+	unbound, _ := json.Marshal([]string{holderUID, credentialType})
+	seed, err := r.kek.Decrypt(seedCipher, unbound)
+	if err != nil {
+		return "", err
+	}
+	return r.kek.Encrypt(seed, seedAdditionalData(holderUID, credentialType, 1))
+}
+
+// migrationKeys gives the migrations of a SQL seed repository the keys to
+// rewrite stored seeds; a key that is not set leaves its migration to fail
+// while seeds need it
+func (r *OpaqueIDSeedRegistry) migrationKeys() repository.SeedMigrationKeys {
+	var keys repository.SeedMigrationKeys
+	if r.kek != nil {
+		keys.BindEpoch = r.bindEpoch
+	}
+	if r.pepper != nil {
+		keys.HolderKey = r.pepper.Key
+	}
+	return keys
 }
 
 // ValidateOpaqueIDSeed validates that a seed has correct format
@@ -1,8 +1,22 @@
 package credential
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/internal/crypto"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/policy"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
 )
 
 func TestGenerateOpaqueIDSeed(t *testing.T) {
@@ -24,7 +38,7 @@ func TestGenerateOpaqueIDSeed(t *testing.T) {
 			name:           "Empty holder UID",
 			holderUID:      "",
 			credentialType: "age_verification",
-			wantErr:        false, // Should still work, just uses empty string as key
+			wantErr:        true, // Anonymous holders would share one seed
 		},
 		{
 			name:           "Empty credential type",
@@ -36,7 +50,7 @@ func TestGenerateOpaqueIDSeed(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			seed, err := registry.GenerateOpaqueIDSeed(tt.holderUID, tt.credentialType)
+			seed, err := registry.GenerateOpaqueIDSeed(context.Background(), tt.holderUID, tt.credentialType)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GenerateOpaqueIDSeed() error = %v, wantErr %v", err, tt.wantErr)
@@ -70,13 +84,13 @@ func TestGenerateOpaqueIDSeed_Stability(t *testing.T) {
 	credentialType := "age_verification"
 
 	// Generate seed first time
-	seed1, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+	seed1, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() first call error = %v", err)
 	}
 
 	// Generate seed second time (should be same)
-	seed2, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+	seed2, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() second call error = %v", err)
 	}
@@ -91,12 +105,12 @@ func TestGenerateOpaqueIDSeed_UniquenessAcrossHolders(t *testing.T) {
 
 	credentialType := "age_verification"
 
-	seed1, err := registry.GenerateOpaqueIDSeed("holder1", credentialType)
+	seed1, err := registry.GenerateOpaqueIDSeed(context.Background(), "holder1", credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() holder1 error = %v", err)
 	}
 
-	seed2, err := registry.GenerateOpaqueIDSeed("holder2", credentialType)
+	seed2, err := registry.GenerateOpaqueIDSeed(context.Background(), "holder2", credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() holder2 error = %v", err)
 	}
@@ -111,12 +125,12 @@ func TestGenerateOpaqueIDSeed_UniquenessAcrossTypes(t *testing.T) {
 
 	holderUID := "holder123"
 
-	seed1, err := registry.GenerateOpaqueIDSeed(holderUID, "age_verification")
+	seed1, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, "age_verification")
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() type1 error = %v", err)
 	}
 
-	seed2, err := registry.GenerateOpaqueIDSeed(holderUID, "driver_license")
+	seed2, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, "driver_license")
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() type2 error = %v", err)
 	}
@@ -133,19 +147,19 @@ func TestGetSeed(t *testing.T) {
 	credentialType := "age_verification"
 
 	// Seed doesn't exist initially
-	_, exists := registry.GetSeed(holderUID, credentialType)
+	_, exists, _ := registry.GetSeed(context.Background(), holderUID, credentialType)
 	if exists {
 		t.Error("GetSeed() found seed that shouldn't exist")
 	}
 
 	// Generate seed
-	expectedSeed, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+	expectedSeed, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() error = %v", err)
 	}
 
 	// Now it should exist
-	retrievedSeed, exists := registry.GetSeed(holderUID, credentialType)
+	retrievedSeed, exists, _ := registry.GetSeed(context.Background(), holderUID, credentialType)
 	if !exists {
 		t.Error("GetSeed() didn't find seed that should exist")
 	}
@@ -162,22 +176,22 @@ func TestRevokeSeed(t *testing.T) {
 	credentialType := "age_verification"
 
 	// Generate seed
-	_, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+	_, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() error = %v", err)
 	}
 
 	// Verify it exists
-	_, exists := registry.GetSeed(holderUID, credentialType)
+	_, exists, _ := registry.GetSeed(context.Background(), holderUID, credentialType)
 	if !exists {
 		t.Error("GetSeed() didn't find seed before revocation")
 	}
 
 	// Revoke seed
-	registry.RevokeSeed(holderUID, credentialType)
+	registry.RevokeSeed(context.Background(), holderUID, credentialType)
 
 	// Verify it no longer exists
-	_, exists = registry.GetSeed(holderUID, credentialType)
+	_, exists, _ = registry.GetSeed(context.Background(), holderUID, credentialType)
 	if exists {
 		t.Error("GetSeed() still found seed after revocation")
 	}
@@ -220,17 +234,18 @@ func TestInjectOpaqueIDSeed(t *testing.T) {
 			},
 			holderUID:      "",
 			credentialType: "age_verification",
-			wantErr:        false,
-			wantFieldCount: 2,
+			wantErr:        true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := registry.InjectOpaqueIDSeed(
+			result, _, err := registry.InjectOpaqueIDSeed(
+				context.Background(),
 				tt.credentialSubject,
 				tt.holderUID,
 				tt.credentialType,
+				policy.SeedRotation{},
 			)
 
 			if (err != nil) != tt.wantErr {
@@ -282,7 +297,7 @@ func TestValidateOpaqueIDSeed(t *testing.T) {
 	registry := NewOpaqueIDSeedRegistry()
 
 	// Generate valid seed
-	validSeed, err := registry.GenerateOpaqueIDSeed("holder123", "age_verification")
+	validSeed, err := registry.GenerateOpaqueIDSeed(context.Background(), "holder123", "age_verification")
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() error = %v", err)
 	}
@@ -336,7 +351,7 @@ func TestOpaqueIDSeedRegistry_Concurrent(t *testing.T) {
 	credentialType := "age_verification"
 
 	// Generate seed once
-	expectedSeed, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+	expectedSeed, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 	if err != nil {
 		t.Fatalf("GenerateOpaqueIDSeed() error = %v", err)
 	}
@@ -348,7 +363,7 @@ func TestOpaqueIDSeedRegistry_Concurrent(t *testing.T) {
 
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
-			seed, err := registry.GenerateOpaqueIDSeed(holderUID, credentialType)
+			seed, err := registry.GenerateOpaqueIDSeed(context.Background(), holderUID, credentialType)
 			if err != nil {
 				errors <- err
 				return
@@ -369,3 +384,454 @@ func TestOpaqueIDSeedRegistry_Concurrent(t *testing.T) {
 		}
 	}
 }
+
+// testKEK is a base64 encoded AES-256 key-encryption key
+var testKEK = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+// testPepper is a base64 encoded 32 byte holder key pepper
+var testPepper = base64.StdEncoding.EncodeToString([]byte("pepper-0123456789abcdef012345678"))
+
+// testHolderKey returns the holder key of a holder UID under testPepper
+func testHolderKey(holderUID string) string {
+	pepper, _ := crypto.ParsePepper(testPepper)
+	return pepper.Key(holderUID)
+}
+
+func TestOpaqueIDSeedRegistry_SurvivesRestart(t *testing.T) {
+	// Given - a seed issued before a restart
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	seed, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper).GenerateOpaqueIDSeed(ctx, "holder123", "age_verification")
+	if vcErr != nil {
+		t.Fatalf("GenerateOpaqueIDSeed() error = %v", vcErr)
+	}
+
+	// When
+	reopened, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper).GenerateOpaqueIDSeed(ctx, "holder123", "age_verification")
+
+	// Then - the holder keeps the seed, and it is not stored in plain text
+	if vcErr != nil || reopened != seed {
+		t.Errorf("Expected seed %s after restart, got %s (%v)", seed, reopened, vcErr)
+	}
+	stored, err := seeds.FindLatestSeed(ctx, testHolderKey("holder123"), "age_verification")
+	if err != nil {
+		t.Fatalf("FindLatestSeed() error = %v", err)
+	}
+	if strings.Contains(stored.SeedCipher, seed) {
+		t.Error("Expected the stored seed to be encrypted")
+	}
+}
+
+func TestOpaqueIDSeedRegistry_KeyEncryptionErrors(t *testing.T) {
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	if _, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper).GenerateOpaqueIDSeed(ctx, "holder123", "age_verification"); vcErr != nil {
+		t.Fatalf("GenerateOpaqueIDSeed() error = %v", vcErr)
+	}
+
+	t.Run("Key not set", func(t *testing.T) {
+		for _, kek := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+			_, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, kek, testPepper).GenerateOpaqueIDSeed(ctx, "holder456", "age_verification")
+			if vcErr == nil || vcErr.Code != errors.ErrSysNotSetVCKeyEncError {
+				t.Errorf("Expected error %d for key %q, got %v", errors.ErrSysNotSetVCKeyEncError, kek, vcErr)
+			}
+		}
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		otherKEK := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+		_, _, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, otherKEK, testPepper).GetSeed(ctx, "holder123", "age_verification")
+		if vcErr == nil || vcErr.Code != errors.ErrSysDecryptCipherError {
+			t.Errorf("Expected error %d, got %v", errors.ErrSysDecryptCipherError, vcErr)
+		}
+	})
+
+	t.Run("Cipher moved to another holder", func(t *testing.T) {
+		stored, _ := seeds.FindLatestSeed(ctx, testHolderKey("holder123"), "age_verification")
+		moved := *stored
+		moved.HolderKey = testHolderKey("holder789")
+		if err := seeds.SaveSeed(ctx, &moved); err != nil {
+			t.Fatalf("SaveSeed() error = %v", err)
+		}
+
+		_, _, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper).GetSeed(ctx, "holder789", "age_verification")
+		if vcErr == nil || vcErr.Code != errors.ErrSysDecryptCipherError {
+			t.Errorf("Expected error %d, got %v", errors.ErrSysDecryptCipherError, vcErr)
+		}
+	})
+}
+
+func TestOpaqueIDSeedRegistry_CheckKeys(t *testing.T) {
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+
+	tests := []struct {
+		name     string
+		kek      string
+		pepper   string
+		expected int
+	}{
+		{"Both set", testKEK, testPepper, 0},
+		{"Key not set", "", testPepper, errors.ErrSysNotSetVCKeyEncError},
+		{"Invalid key", "not base64!", testPepper, errors.ErrSysNotSetVCKeyEncError},
+		{"Pepper not set", testKEK, "", errors.ErrSysCheckKeysValueError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, tt.kek, tt.pepper).CheckKeys()
+
+			if tt.expected == 0 {
+				if vcErr != nil {
+					t.Errorf("Expected no error, got %v", vcErr)
+				}
+				return
+			}
+			if vcErr == nil || vcErr.Code != tt.expected {
+				t.Errorf("Expected error %d, got %v", tt.expected, vcErr)
+			}
+		})
+	}
+}
+
+func TestGenerate_SeedKeyNotSet(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	registry := NewOpaqueIDSeedRegistryWithRepository(repository.NewMemoryOpaqueIDSeedRepository(), "", testPepper)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies(), WithOpaqueIDSeedRegistry(registry))
+
+	// When
+	_, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+		IssuerDID:           "did:example:issuer",
+		CredentialType:      "IdentityCredential",
+		CredentialSubjectID: "did:example:holder",
+		CredentialSubject:   map[string]interface{}{"name": "Test User"},
+	})
+
+	// Then
+	vcErr, ok := err.(*errors.VCError)
+	if !ok || vcErr.Code != errors.ErrSysNotSetVCKeyEncError || status != http.StatusInternalServerError {
+		t.Errorf("Expected error %d with status 500, got %d %v", errors.ErrSysNotSetVCKeyEncError, status, err)
+	}
+}
+
+func TestRotateSeed(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	registry := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper)
+	first, vcErr := registry.GenerateOpaqueIDSeed(ctx, "holder123", "age_verification")
+	if vcErr != nil {
+		t.Fatalf("GenerateOpaqueIDSeed() error = %v", vcErr)
+	}
+
+	// When
+	rotated, vcErr := registry.RotateSeed(ctx, "holder123", "age_verification", models.StatusReasonKeyCompromise, "admin", policy.SeedRotation{})
+	if vcErr != nil {
+		t.Fatalf("RotateSeed() error = %v", vcErr)
+	}
+
+	// Then - the holder gets a new seed in the next epoch
+	if rotated.Epoch != 2 || rotated.Seed == first {
+		t.Errorf("Expected a new seed in epoch 2, got %+v", rotated)
+	}
+	current, exists, vcErr := registry.GetSeed(ctx, "holder123", "age_verification")
+	if vcErr != nil || !exists || current != rotated.Seed {
+		t.Errorf("Expected current seed %s, got %s (%v)", rotated.Seed, current, vcErr)
+	}
+
+	// And the retired epoch is kept for audit
+	history, vcErr := registry.SeedHistory(ctx, "holder123", "age_verification")
+	if vcErr != nil {
+		t.Fatalf("SeedHistory() error = %v", vcErr)
+	}
+	if len(history) != 2 || history[0].Epoch != 1 || history[1].Epoch != 2 {
+		t.Fatalf("Expected epochs 1 and 2, got %+v", history)
+	}
+	if history[0].RetiredAt == nil || history[0].RetireReason != models.StatusReasonKeyCompromise || history[0].RetiredBy != "admin" {
+		t.Errorf("Expected epoch 1 retired by admin, got %+v", history[0])
+	}
+	if history[1].RetiredAt != nil {
+		t.Errorf("Expected epoch 2 to be current, got %+v", history[1])
+	}
+	for _, seed := range history {
+		if seed.SeedCipher != "" {
+			t.Error("Expected the seed history without seed ciphers")
+		}
+	}
+}
+
+func TestRevokeSeed_NextIssuanceStartsNewEpoch(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	registry := NewOpaqueIDSeedRegistry()
+	first, _ := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{})
+
+	// When
+	if vcErr := registry.RevokeSeed(ctx, "holder123", "age_verification"); vcErr != nil {
+		t.Fatalf("RevokeSeed() error = %v", vcErr)
+	}
+	next, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{})
+
+	// Then
+	if vcErr != nil || next.Epoch != first.Epoch+1 || next.Seed == first.Seed {
+		t.Errorf("Expected a new seed in epoch %d, got %+v (%v)", first.Epoch+1, next, vcErr)
+	}
+}
+
+func TestCurrentSeed_Expired(t *testing.T) {
+	// A negative validity creates epochs that have already expired
+	expired := policy.Duration{Value: -1, Unit: models.TimeUnitDay}
+
+	t.Run("Periodic rotation", func(t *testing.T) {
+		// Given
+		ctx := context.Background()
+		registry := NewOpaqueIDSeedRegistry()
+		first, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{Periodic: true, Validity: expired})
+		if vcErr != nil {
+			t.Fatalf("CurrentSeed() error = %v", vcErr)
+		}
+
+		// When
+		next, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{Periodic: true, Validity: policy.Duration{Value: 1, Unit: models.TimeUnitYear}})
+
+		// Then
+		if vcErr != nil || next.Epoch != 2 || next.Seed == first.Seed || next.ValidUntil == nil {
+			t.Fatalf("Expected a rotated seed in epoch 2, got %+v (%v)", next, vcErr)
+		}
+		history, _ := registry.SeedHistory(ctx, "holder123", "age_verification")
+		if history[0].RetireReason != models.SeedRetireReasonExpired || history[0].RetiredBy != models.StatusActorSystem {
+			t.Errorf("Expected epoch 1 retired as expired by the system, got %+v", history[0])
+		}
+	})
+
+	t.Run("Manual rotation", func(t *testing.T) {
+		// Given
+		ctx := context.Background()
+		registry := NewOpaqueIDSeedRegistry()
+		if _, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{Validity: expired}); vcErr != nil {
+			t.Fatalf("CurrentSeed() error = %v", vcErr)
+		}
+
+		// When
+		_, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{})
+
+		// Then - issuance is blocked until the seed is rotated
+		if vcErr == nil || vcErr.Code != errors.ErrCredGenerateVCError {
+			t.Fatalf("Expected error %d, got %v", errors.ErrCredGenerateVCError, vcErr)
+		}
+		if _, vcErr := registry.RotateSeed(ctx, "holder123", "age_verification", "", "", policy.SeedRotation{}); vcErr != nil {
+			t.Fatalf("RotateSeed() error = %v", vcErr)
+		}
+		if next, vcErr := registry.CurrentSeed(ctx, "holder123", "age_verification", policy.SeedRotation{}); vcErr != nil || next.Epoch != 2 {
+			t.Errorf("Expected epoch 2 after rotation, got %+v (%v)", next, vcErr)
+		}
+	})
+}
+
+func TestOpaqueIDSeedRegistry_CipherBoundToEpoch(t *testing.T) {
+	// Given - a retired seed's cipher copied into a later epoch
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	registry := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper)
+	if _, vcErr := registry.RotateSeed(ctx, "holder123", "age_verification", "", "", policy.SeedRotation{}); vcErr != nil {
+		t.Fatalf("RotateSeed() error = %v", vcErr)
+	}
+	if _, vcErr := registry.RotateSeed(ctx, "holder123", "age_verification", "", "", policy.SeedRotation{}); vcErr != nil {
+		t.Fatalf("RotateSeed() error = %v", vcErr)
+	}
+	retired, _ := seeds.FindLatestSeed(ctx, testHolderKey("holder123"), "age_verification")
+	replayed := *retired
+	replayed.Epoch = 3
+	if err := seeds.SaveSeed(ctx, &replayed); err != nil {
+		t.Fatalf("SaveSeed() error = %v", err)
+	}
+
+	// When
+	_, _, vcErr := registry.GetSeed(ctx, "holder123", "age_verification")
+
+	// Then
+	if vcErr == nil || vcErr.Code != errors.ErrSysDecryptCipherError {
+		t.Errorf("Expected error %d, got %v", errors.ErrSysDecryptCipherError, vcErr)
+	}
+}
+
+func TestOpaqueIDSeedRegistry_BindEpoch(t *testing.T) {
+	// Given - a seed encrypted before seed epochs, without the epoch in its additional data
+	ctx := context.Background()
+	kek, _ := crypto.ParseKeyEncryptionKey(testKEK)
+	unbound, _ := json.Marshal([]string{"holder123", "age_verification"})
+	seedCipher, _ := kek.Encrypt([]byte("unbound-seed"), unbound)
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	registry := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper)
+
+	// Then - it is not accepted as an epoch 1 seed as it is
+	stored := models.OpaqueIDSeed{HolderKey: testHolderKey("holder123"), CredentialType: "age_verification", Epoch: 1, SeedCipher: seedCipher, CreateTime: time.Now()}
+	if _, vcErr := registry.decryptSeed(seedHolder{uid: "holder123", credentialType: "age_verification"}, &stored); vcErr == nil {
+		t.Error("Expected a cipher without its epoch to fail to decrypt")
+	}
+
+	// When - migration 8 re-encrypts it
+	bound, err := registry.migrationKeys().BindEpoch(seedCipher, "holder123", "age_verification")
+	if err != nil {
+		t.Fatalf("BindEpoch() error = %v", err)
+	}
+	stored.SeedCipher = bound
+	if err := seeds.SaveSeed(ctx, &stored); err != nil {
+		t.Fatalf("SaveSeed() error = %v", err)
+	}
+
+	// Then - the holder keeps the seed as epoch 1
+	if seed, exists, vcErr := registry.GetSeed(ctx, "holder123", "age_verification"); vcErr != nil || !exists || seed != "unbound-seed" {
+		t.Errorf("Expected unbound-seed, got %s (%v)", seed, vcErr)
+	}
+
+	// Without a key-encryption key the migration has no key
+	if keys := NewOpaqueIDSeedRegistryWithRepository(seeds, "", testPepper).migrationKeys(); keys.BindEpoch != nil {
+		t.Error("Expected no BindEpoch without a key-encryption key")
+	}
+}
+
+func TestOpaqueIDSeedRegistry_HolderKey(t *testing.T) {
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	registry := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper)
+
+	t.Run("Raw holder UID not stored", func(t *testing.T) {
+		if _, vcErr := registry.GenerateOpaqueIDSeed(ctx, "A123456789", "age_verification"); vcErr != nil {
+			t.Fatalf("GenerateOpaqueIDSeed() error = %v", vcErr)
+		}
+
+		if _, err := seeds.FindLatestSeed(ctx, "A123456789", "age_verification"); !stderrors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected no seed under the raw holder UID, got %v", err)
+		}
+		stored, err := seeds.FindLatestSeed(ctx, testHolderKey("A123456789"), "age_verification")
+		if err != nil || stored.HolderKey == "A123456789" {
+			t.Errorf("Expected the seed under the holder key, got %+v (%v)", stored, err)
+		}
+	})
+
+	t.Run("Pepper not set", func(t *testing.T) {
+		for _, pepper := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+			_, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, pepper).GenerateOpaqueIDSeed(ctx, "A123456789", "age_verification")
+			if vcErr == nil || vcErr.Code != errors.ErrSysCheckKeysValueError {
+				t.Errorf("Expected error %d for pepper %q, got %v", errors.ErrSysCheckKeysValueError, pepper, vcErr)
+			}
+		}
+	})
+
+	t.Run("Other pepper", func(t *testing.T) {
+		otherPepper := base64.StdEncoding.EncodeToString([]byte("pepper-fedcba9876543210fedcba987"))
+		_, exists, vcErr := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, otherPepper).GetSeed(ctx, "A123456789", "age_verification")
+		if vcErr != nil || exists {
+			t.Errorf("Expected no seed under another pepper, got %v (%v)", exists, vcErr)
+		}
+	})
+}
+
+func TestOpaqueIDSeedRegistry_MigrationHolderKey(t *testing.T) {
+	// Given - a seed re-keyed by migration 9 from the raw holder UID
+	ctx := context.Background()
+	seeds := repository.NewMemoryOpaqueIDSeedRepository()
+	registry := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, testPepper)
+	kek, _ := crypto.ParseKeyEncryptionKey(testKEK)
+	seedCipher, _ := kek.Encrypt([]byte("migrated-seed"), seedAdditionalData("A123456789", "age_verification", 1))
+	holderKey := registry.migrationKeys().HolderKey("A123456789")
+	if err := seeds.SaveSeed(ctx, &models.OpaqueIDSeed{HolderKey: holderKey, CredentialType: "age_verification", Epoch: 1, SeedCipher: seedCipher, CreateTime: time.Now()}); err != nil {
+		t.Fatalf("SaveSeed() error = %v", err)
+	}
+
+	// When
+	seed, vcErr := registry.GenerateOpaqueIDSeed(ctx, "A123456789", "age_verification")
+
+	// Then - the holder keeps the seed
+	if vcErr != nil || seed != "migrated-seed" {
+		t.Errorf("Expected migrated-seed, got %s (%v)", seed, vcErr)
+	}
+	if holderKey != testHolderKey("A123456789") {
+		t.Errorf("Expected the registry's holder key, got %s", holderKey)
+	}
+
+	// Without a pepper the migration has no holder key
+	if keys := NewOpaqueIDSeedRegistryWithRepository(seeds, testKEK, "").migrationKeys(); keys.HolderKey != nil {
+		t.Error("Expected no HolderKey without a pepper")
+	}
+}
+
+func TestCommitSeed(t *testing.T) {
+	ctx := context.Background()
+	registry := NewOpaqueIDSeedRegistry()
+
+	// Given - two requests preparing the first epoch of a holder
+	first, vcErr := registry.PrepareSeed(ctx, "holder123", "age_verification", policy.SeedRotation{})
+	if vcErr != nil {
+		t.Fatalf("PrepareSeed() error = %v", vcErr)
+	}
+	second, vcErr := registry.PrepareSeed(ctx, "holder123", "age_verification", policy.SeedRotation{})
+	if vcErr != nil {
+		t.Fatalf("PrepareSeed() error = %v", vcErr)
+	}
+
+	// Then - nothing is stored before a commit
+	if _, found, _ := registry.GetSeed(ctx, "holder123", "age_verification"); found {
+		t.Fatal("Expected no stored seed before CommitSeed")
+	}
+
+	// When
+	if vcErr := registry.CommitSeed(ctx, first); vcErr != nil {
+		t.Fatalf("CommitSeed() error = %v", vcErr)
+	}
+	vcErr = registry.CommitSeed(ctx, second)
+
+	// Then - the first commit wins and the other seed is refused
+	if vcErr == nil || vcErr.Code != errors.ErrDBInsertError {
+		t.Errorf("Expected error %d for the losing seed, got %v", errors.ErrDBInsertError, vcErr)
+	}
+	if seed, _, _ := registry.GetSeed(ctx, "holder123", "age_verification"); seed != first.Seed {
+		t.Errorf("Expected the first committed seed to be stored, got %s", seed)
+	}
+}
+
+func TestGenerate_Pairwise(t *testing.T) {
+	issuerKey, privateKey := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+
+	t.Run("Subject ID required", func(t *testing.T) {
+		// When
+		_, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+			IssuerDID:         "did:example:issuer",
+			CredentialType:    "IdentityCredential",
+			CredentialSubject: map[string]interface{}{"name": "Test User"},
+		})
+
+		// Then
+		vcErr, ok := err.(*errors.VCError)
+		if !ok || vcErr.Code != errors.ErrCredInvalidCredentialSubject || status != http.StatusBadRequest {
+			t.Errorf("Expected error %d with status 400, got %d %v", errors.ErrCredInvalidCredentialSubject, status, err)
+		}
+	})
+
+	t.Run("Pairwise disabled", func(t *testing.T) {
+		// When
+		result, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+			IssuerDID:         "did:example:issuer",
+			CredentialType:    "TestCredential",
+			CredentialSubject: map[string]interface{}{"name": "Test User"},
+		})
+
+		// Then - the credential carries no seed
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("Failed to issue credential: %d %v", status, err)
+		}
+		var response models.CredentialResponseDTO
+		json.Unmarshal([]byte(result), &response)
+		claims := &VCClaims{}
+		if _, err := jwt.ParseWithClaims(response.Credential, claims, func(token *jwt.Token) (interface{}, error) {
+			return &privateKey.PublicKey, nil
+		}); err != nil {
+			t.Fatalf("Failed to verify credential: %v", err)
+		}
+		if _, ok := claims.VC.CredentialSubject[policy.OpaqueIDSeedClaim]; ok || claims.OpaqueIDSeedEpoch != 0 {
+			t.Errorf("Expected no opaque_id_seed, got %v epoch %d", claims.VC.CredentialSubject, claims.OpaqueIDSeedEpoch)
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package credential
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/sdjwt"
+)
+
+// TestGenerate_PolicyExpiration tests that the expiration date follows the credential policy
+func TestGenerate_PolicyExpiration(t *testing.T) {
+	// Given - a 6 month policy and a credential issued on Aug 31
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 6, ExpirationTimeUnit: models.TimeUnitMonth},
+	)))
+	issuanceDate := time.Date(time.Now().Year()-1, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	// When
+	cid := issueTestCredentialAt(t, service, "", issuanceDate)
+
+	// Then - Feb has no 31st, the expiration date is clamped to the end of the month
+	credential, err := service.credentials.FindByCID(context.Background(), cid)
+	if err != nil {
+		t.Fatalf("Failed to load credential: %v", err)
+	}
+
+	lastDayOfFebruary := time.Date(issuanceDate.Year()+1, 3, 0, 0, 0, 0, 0, time.UTC)
+	if !credential.ExpirationDate.Equal(lastDayOfFebruary) {
+		t.Errorf("Expected expiration date %v, got %v", lastDayOfFebruary, credential.ExpirationDate)
+	}
+
+	claims := &VCClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(credential.Content, claims); err != nil {
+		t.Fatalf("Failed to parse credential: %v", err)
+	}
+	if !claims.ExpiresAt.Time.Equal(lastDayOfFebruary) {
+		t.Errorf("Expected exp %v, got %v", lastDayOfFebruary, claims.ExpiresAt.Time)
+	}
+}
+
+// TestGenerate_PolicyErrors tests that policy and date errors reject the request
+func TestGenerate_PolicyErrors(t *testing.T) {
+	issuerKey, _ := testIssuerKeyPEM(t)
+	policies := repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{
+			CredentialType:       "TestCredential",
+			IssuanceDateDuration: 1,
+			IssuanceDateTimeUnit: models.TimeUnitDay,
+			ExpirationDuration:   1,
+			ExpirationTimeUnit:   models.TimeUnitYear,
+		},
+		models.CredentialPolicyEntity{CredentialType: "BadUnitCredential", ExpirationDuration: 1, ExpirationTimeUnit: "WEEK"},
+		models.CredentialPolicyEntity{CredentialType: "BadDurationCredential", ExpirationDuration: 0, ExpirationTimeUnit: models.TimeUnitDay},
+	)
+	service := NewService("did:example:issuer", issuerKey, WithPolicyRepository(policies))
+
+	now := time.Now()
+	at := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name           string
+		credentialType string
+		issuanceDate   *time.Time
+		expirationDate *time.Time
+		expectedCode   int
+		expectedStatus int
+	}{
+		{"Unknown credential type", "UnknownCredential", nil, nil, errors.ErrCredInvalidCredentialType, http.StatusBadRequest},
+		{"Invalid policy time unit", "BadUnitCredential", nil, nil, errors.ErrSysInvalidTimeUnit, http.StatusInternalServerError},
+		{"Invalid policy duration", "BadDurationCredential", nil, nil, errors.ErrSysInvalidTimeDuration, http.StatusInternalServerError},
+		{"Issuance date in the future", "TestCredential", at(now.Add(time.Hour)), nil, errors.ErrCredInvalidIssuanceDate, http.StatusBadRequest},
+		{"Issuance date before window", "TestCredential", at(now.AddDate(0, 0, -2)), nil, errors.ErrCredInvalidIssuanceDate, http.StatusBadRequest},
+		{"Expiration date in the past", "TestCredential", nil, at(now.Add(-time.Hour)), errors.ErrCredInvalidExpirationDate, http.StatusBadRequest},
+		{"Expiration date beyond policy", "TestCredential", nil, at(now.AddDate(2, 0, 0)), errors.ErrCredInvalidExpirationDate, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			_, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+				IssuerDID:         "did:example:issuer",
+				CredentialType:    tt.credentialType,
+				CredentialSubject: map[string]interface{}{"name": "Test User"},
+				IssuanceDate:      tt.issuanceDate,
+				ExpirationDate:    tt.expirationDate,
+			})
+
+			// Then
+			vcErr, ok := err.(*errors.VCError)
+			if !ok || vcErr.Code != tt.expectedCode {
+				t.Fatalf("Expected error code %d, got %v", tt.expectedCode, err)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+
+	// Rejected requests do not consume a status list position
+	cid := issueTestCredential(t, service, "")
+	credential, _ := service.credentials.FindByCID(context.Background(), cid)
+	if credential.TicketNumber != 1 {
+		t.Errorf("Expected ticket number 1, got %d", credential.TicketNumber)
+	}
+}
+
+// TestGenerate_SchemaValidation tests that credential subjects are validated against the policy's VC schema
+func TestGenerate_SchemaValidation(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{
+			CredentialType:     "AgeCredential",
+			ExpirationDuration: 1,
+			ExpirationTimeUnit: models.TimeUnitYear,
+			PairwiseEnabled:    true,
+			VCSchema: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"type": "object",
+				"properties": {
+					"credentialSubject": {
+						"type": "object",
+						"properties": {
+							"opaque_id_seed": {"type": "string", "pattern": "^[A-Za-z0-9_-]{43}$"},
+							"over_18": {"type": "boolean"},
+							"birth_year": {"type": "integer", "minimum": 1900}
+						},
+						"required": ["opaque_id_seed", "over_18"],
+						"additionalProperties": false
+					}
+				}
+			}`,
+		},
+	)))
+	generate := func(subject map[string]interface{}) (string, int, error) {
+		return service.Generate(context.Background(), &models.CredentialRequestDTO{
+			IssuerDID:           "did:example:issuer",
+			CredentialType:      "AgeCredential",
+			CredentialSubjectID: "did:example:holder",
+			CredentialSubject:   subject,
+		})
+	}
+
+	t.Run("Valid subject", func(t *testing.T) {
+		if _, status, err := generate(map[string]interface{}{"over_18": true, "birth_year": 1990}); err != nil || status != http.StatusOK {
+			t.Errorf("Expected status 200, got %d (%v)", status, err)
+		}
+	})
+
+	t.Run("Invalid subject", func(t *testing.T) {
+		// When
+		result, status, err := generate(map[string]interface{}{"birth_year": 1800, "nickname": "T"})
+
+		// Then
+		vcErr, ok := err.(*errors.VCError)
+		if !ok || vcErr.Code != errors.ErrCredDataFieldsInSchemaAndVCDataNotIdentical {
+			t.Fatalf("Expected error code %d, got %v", errors.ErrCredDataFieldsInSchemaAndVCDataNotIdentical, err)
+		}
+		if status != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, status)
+		}
+
+		var response struct {
+			Code   int                 `json:"code"`
+			Errors []errors.FieldError `json:"errors"`
+		}
+		if err := json.Unmarshal([]byte(result), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		paths := make([]string, 0, len(response.Errors))
+		for _, fieldError := range response.Errors {
+			paths = append(paths, fieldError.Path)
+		}
+		expected := []string{"/credentialSubject", "/credentialSubject", "/credentialSubject/birth_year"}
+		if strings.Join(paths, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected error paths %v, got %v", expected, response.Errors)
+		}
+	})
+
+	t.Run("Invalid subject stores no seed", func(t *testing.T) {
+		// When
+		_, _, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+			IssuerDID:           "did:example:issuer",
+			CredentialType:      "AgeCredential",
+			CredentialSubjectID: "did:example:rejected",
+			CredentialSubject:   map[string]interface{}{"birth_year": 1800},
+		})
+
+		// Then - the rejected request left no seed epoch for the holder
+		if err == nil {
+			t.Fatal("Expected the request to be rejected")
+		}
+		seeds, vcErr := service.seedRegistry.SeedHistory(context.Background(), "did:example:rejected", "AgeCredential")
+		if vcErr != nil || len(seeds) != 0 {
+			t.Errorf("Expected no stored seed, got %+v (%v)", seeds, vcErr)
+		}
+	})
+}
+
+// TestGenerate_SelectiveDisclosure tests SD-JWT issuance for a credential type with a selective disclosure setting
+func TestGenerate_SelectiveDisclosure(t *testing.T) {
+	// Given
+	issuerKey, privateKey := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{
+			CredentialType:      "AgeCredential",
+			ExpirationDuration:  1,
+			ExpirationTimeUnit:  models.TimeUnitYear,
+			SelectiveDisclosure: `{"selective_disclosure": {"name": {"always_disclosed": true}}}`,
+			PairwiseEnabled:     true,
+		},
+	)))
+	holderKey := map[string]interface{}{"kty": "EC", "crv": "P-256", "x": "f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU", "y": "x_FEzRjmX-g5-6k9jLDeX2AN6bBVedHHqmqdYJ8KwAE"}
+	request := &models.CredentialRequestDTO{
+		IssuerDID:           "did:example:issuer",
+		CredentialType:      "AgeCredential",
+		CredentialSubjectID: "did:example:holder",
+		CredentialSubject:   map[string]interface{}{"name": "Alice", "over_18": true},
+		HolderPublicKey:     holderKey,
+	}
+
+	// When
+	result, status, err := service.Generate(context.Background(), request)
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (%v)", status, err)
+	}
+	var response models.CredentialResponseDTO
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	parts := strings.Split(response.Credential, "~")
+	if len(parts) != 4 || parts[3] != "" {
+		t.Fatalf("Expected <jwt>~<d1>~<d2>~, got %s", response.Credential)
+	}
+
+	claims := &VCClaims{}
+	token, err := jwt.ParseWithClaims(parts[0], claims, func(token *jwt.Token) (interface{}, error) {
+		return &privateKey.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify credential: %v", err)
+	}
+	if token.Header["typ"] != "vc+sd-jwt" {
+		t.Errorf("Expected typ vc+sd-jwt, got %v", token.Header["typ"])
+	}
+	if claims.SDAlg != "sha-256" {
+		t.Errorf("Expected _sd_alg sha-256, got %q", claims.SDAlg)
+	}
+	if jwk, _ := claims.Cnf["jwk"].(map[string]interface{}); jwk["x"] != holderKey["x"] {
+		t.Errorf("Expected cnf to carry the holder key, got %v", claims.Cnf)
+	}
+
+	subject := claims.VC.CredentialSubject
+	if subject["name"] != "Alice" {
+		t.Errorf("Expected name in plain text, got %v", subject)
+	}
+
+	// The holder UID is neither in the payload nor in a disclosure
+	if claims.Subject != "" || subject["id"] != nil {
+		t.Errorf("Expected no subject ID, got sub %q and id %v", claims.Subject, subject["id"])
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(strings.Split(parts[0], ".")[1])
+	if strings.Contains(string(payload), "did:example:holder") {
+		t.Errorf("Expected the holder UID not to be in the issuer JWT payload, got %s", payload)
+	}
+	for _, encoded := range parts[1:3] {
+		if data, _ := base64.RawURLEncoding.DecodeString(encoded); strings.Contains(string(data), "did:example:holder") {
+			t.Errorf("Expected the holder UID not to be in a disclosure, got %s", data)
+		}
+	}
+
+	if _, ok := subject["over_18"]; ok {
+		t.Error("Expected over_18 to be selectively disclosable")
+	}
+	if _, ok := subject["opaque_id_seed"]; ok {
+		t.Error("Expected opaque_id_seed to be selectively disclosable")
+	}
+
+	// Every disclosure matches a digest in credentialSubject._sd
+	digests, _ := subject["_sd"].([]interface{})
+	disclosed := map[string]bool{}
+	for _, encoded := range parts[1:3] {
+		digest := sdjwt.Digest(encoded)
+		found := false
+		for _, d := range digests {
+			found = found || d == digest
+		}
+		if !found {
+			t.Errorf("Disclosure %s has no digest in %v", encoded, digests)
+		}
+
+		data, _ := base64.RawURLEncoding.DecodeString(encoded)
+		var content []interface{}
+		if err := json.Unmarshal(data, &content); err != nil || len(content) != 3 {
+			t.Fatalf("Invalid disclosure %s", data)
+		}
+		disclosed[content[1].(string)] = true
+	}
+	if !disclosed["over_18"] || !disclosed["opaque_id_seed"] {
+		t.Errorf("Expected over_18 and opaque_id_seed disclosures, got %v", disclosed)
+	}
+
+	// Only the issuer-signed JWT is stored, without the disclosures
+	credential, _ := service.credentials.FindByCID(context.Background(), response.CID)
+	if credential.Content != parts[0] {
+		t.Errorf("Expected stored content to be the issuer-signed JWT, got %s", credential.Content)
+	}
+}
+
+// TestGenerate_InvalidHolderPublicKey tests that a holder key must be a public JWK
+func TestGenerate_InvalidHolderPublicKey(t *testing.T) {
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+
+	for name, holderKey := range map[string]map[string]interface{}{
+		"Missing kty": {"crv": "P-256", "x": "x", "y": "y"},
+		"Private key": {"kty": "EC", "crv": "P-256", "x": "x", "y": "y", "d": "d"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+				IssuerDID:         "did:example:issuer",
+				CredentialType:    "TestCredential",
+				CredentialSubject: map[string]interface{}{"name": "Test"},
+				HolderPublicKey:   holderKey,
+			})
+
+			vcErr, ok := err.(*errors.VCError)
+			if !ok || vcErr.Code != errors.ErrCredInvalidCredentialGenerationRequest {
+				t.Errorf("Expected error code %d, got %v", errors.ErrCredInvalidCredentialGenerationRequest, err)
+			}
+			if status != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	}
+}
@@ -2,13 +2,24 @@ package credential
 
 import (
 	"context"
+	stdcrypto "crypto"
+	"crypto/rand"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/moda-gov-tw/twdiw-issuer-go/internal/crypto"
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/policy"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/sdjwt"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/statuslist"
 )
 
 // Validation limits to prevent DoS attacks
@@ -18,21 +29,153 @@ const (
 	MaxMapDepth                 = 10      // Maximum nesting depth for maps
 )
 
+// DefaultBaseURL is the default public base URL for credential and status list resources
+const DefaultBaseURL = "http://localhost:8080"
+
+// DefaultHolderRotationInterval is the default minimum time between two
+// holder-requested opaque_id_seed rotations
+const DefaultHolderRotationInterval = 30 * 24 * time.Hour
+
 // Service handles credential issuance and management
 type Service struct {
 	// Dependencies would go here (repositories, crypto services, etc.)
 	issuerDID    string
 	issuerKey    string
 	seedRegistry *OpaqueIDSeedRegistry
+	credentials  repository.CredentialRepository
+	policies     repository.CredentialPolicyRepository
+	statusLists  *statuslist.Service
+
+	// Repository the status list service is built on
+	statusListRepository repository.StatusListRepository
+
+	// Signing key parsed from issuerKey (nil if issuerKey is not a valid PEM or JWK)
+	signingKey stdcrypto.Signer
+	keyID      string
+	baseURL    string
+
+	// Ticket sequences per credential type, used to allocate status list positions
+	tickets repository.TicketRepository
+
+	// Verifier groups sharing a pairwise_id (nil: no groups)
+	verifierGroups *pairwiseid.GroupRegistry
+
+	// Minimum time between two holder-requested seed rotations
+	holderRotationInterval time.Duration
+}
+
+// Option configures optional Service dependencies
+type Option func(*Service)
+
+// WithKeyID sets the kid header used when signing credentials
+// (defaults to issuerDID + "#key-1")
+func WithKeyID(kid string) Option {
+	return func(s *Service) {
+		s.keyID = kid
+	}
+}
+
+// WithBaseURL sets the public base URL used to build credential and status list IDs
+func WithBaseURL(baseURL string) Option {
+	return func(s *Service) {
+		s.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithRepository sets the repository issued credentials are persisted to
+// (defaults to an in-memory repository)
+func WithRepository(repo repository.CredentialRepository) Option {
+	return func(s *Service) {
+		s.credentials = repo
+	}
+}
+
+// WithPolicyRepository sets the repository credential policies are loaded from
+// (defaults to an empty in-memory repository, which rejects every credential type)
+func WithPolicyRepository(repo repository.CredentialPolicyRepository) Option {
+	return func(s *Service) {
+		s.policies = repo
+	}
+}
+
+// WithTicketRepository sets the repository ticket numbers are taken from
+// (defaults to an in-memory repository, which restarts every sequence at 1)
+func WithTicketRepository(repo repository.TicketRepository) Option {
+	return func(s *Service) {
+		s.tickets = repo
+	}
+}
+
+// WithStatusListRepository sets the repository status lists are persisted to
+// (defaults to an in-memory repository)
+func WithStatusListRepository(repo repository.StatusListRepository) Option {
+	return func(s *Service) {
+		s.statusListRepository = repo
+	}
 }
 
-// NewService creates a new credential service
-func NewService(issuerDID, issuerKey string) *Service {
-	return &Service{
+// WithOpaqueIDSeedRegistry sets the registry opaque_id_seeds are kept in
+// (defaults to an in-memory registry, which gives every holder new seeds after a restart)
+func WithOpaqueIDSeedRegistry(registry *OpaqueIDSeedRegistry) Option {
+	return func(s *Service) {
+		s.seedRegistry = registry
+	}
+}
+
+// WithVerifierGroups sets the verifier groups published by GetVerifierGroups
+// (defaults to none)
+func WithVerifierGroups(groups *pairwiseid.GroupRegistry) Option {
+	return func(s *Service) {
+		s.verifierGroups = groups
+	}
+}
+
+// WithHolderRotationInterval sets the minimum time between two seed rotations
+// requested by the holder (defaults to DefaultHolderRotationInterval)
+func WithHolderRotationInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.holderRotationInterval = interval
+	}
+}
+
+// NewService creates a new credential service.
+// issuerKey is the issuer's private signing key, encoded as PEM or as a private JWK.
+func NewService(issuerDID, issuerKey string, opts ...Option) *Service {
+	s := &Service{
 		issuerDID:    issuerDID,
 		issuerKey:    issuerKey,
 		seedRegistry: NewOpaqueIDSeedRegistry(),
+		credentials:  repository.NewMemoryCredentialRepository(),
+		policies:     repository.NewMemoryCredentialPolicyRepository(),
+		keyID:        issuerDID + "#key-1",
+		baseURL:      DefaultBaseURL,
+		tickets:      repository.NewMemoryTicketRepository(),
+
+		statusListRepository:   repository.NewMemoryStatusListRepository(),
+		holderRotationInterval: DefaultHolderRotationInterval,
+	}
+
+	// An unparsable key is reported when a credential is signed
+	if signingKey, err := crypto.ParsePrivateKey(issuerKey); err == nil {
+		s.signingKey = signingKey
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	// Status lists are signed with the issuer key and published under the same base URL
+	s.statusLists = statuslist.NewService(s.issuerDID, s.signingKey, s.keyID, s.baseURL, s.statusListRepository)
+
+	return s
+}
+
+// PublicKey returns the issuer's public key, or nil if no valid signing key is set
+func (s *Service) PublicKey() stdcrypto.PublicKey {
+	if s.signingKey == nil {
+		return nil
+	}
+	return s.signingKey.Public()
 }
 
 // Generate generates a new verifiable credential
@@ -121,40 +264,188 @@ func (s *Service) Generate(ctx context.Context, request *models.CredentialReques
 		// Continue processing
 	}
 
+	// Issuer signing key must be loaded before anything is issued
+	if s.signingKey == nil {
+		vcErr := errors.NewVCError(
+			errors.ErrSysNotSetKeyYetError,
+			"issuer signing key is not set",
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	// Load the credential policy and calculate issuance and expiration dates
+	credentialPolicy, vcErr := s.loadPolicy(ctx, request.CredentialType)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+	issuanceDate, expirationDate, vcErr := credentialPolicy.Dates(time.Now(), request.IssuanceDate, request.ExpirationDate)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
 	// Inject opaque_id_seed for pairwise pseudonymous identifiers
-	// This enables Sybil resistance while maintaining privacy
-	credentialSubjectWithSeed, err := s.seedRegistry.InjectOpaqueIDSeed(
-		request.CredentialSubject,
-		request.CredentialSubjectID, // Use as holderUID
-		request.CredentialType,
-	)
+	// This enables Sybil resistance while maintaining privacy; the seed epoch
+	// follows the policy's seed rotation. A new seed epoch is only stored once
+	// the credential is signed.
+	credentialSubjectWithSeed, seedEpoch := request.CredentialSubject, 0
+	var pendingSeed *PendingSeed
+	if credentialPolicy.Pairwise {
+		// The seed is keyed to the subject: anonymous requests would share one seed
+		if request.CredentialSubjectID == "" {
+			vcErr := errors.NewVCError(
+				errors.ErrCredInvalidCredentialSubject,
+				"credential subject ID is required for pairwise credential types",
+			)
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+
+		subject, pending, vcErr := s.seedRegistry.InjectOpaqueIDSeed(
+			ctx,
+			request.CredentialSubject,
+			request.CredentialSubjectID, // Use as holderUID
+			request.CredentialType,
+			credentialPolicy.SeedRotation,
+		)
+		if vcErr != nil {
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+		credentialSubjectWithSeed, seedEpoch, pendingSeed = subject, pending.Epoch, pending
+	}
+
+	// Validate the credential subject (with its opaque_id_seed) against the VC schema
+	if vcErr := credentialPolicy.ValidateSubject(credentialSubjectWithSeed, request.CredentialSubjectID); vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	if vcErr := validateHolderPublicKey(request.HolderPublicKey); vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	// Replace selectively disclosable claims with digests (SD-JWT credential types only).
+	// An SD-JWT VC carries no subject ID (credentialSubject.id, sub): the holder
+	// UID would be visible in every presentation, whatever is disclosed, and
+	// correlate the holder across verifiers. The cnf key binds it to the holder.
+	credentialSubjectClaims, subjectID := credentialSubjectWithSeed, request.CredentialSubjectID
+	var disclosures []*sdjwt.Disclosure
+	if credentialPolicy.SelectiveDisclosure != nil {
+		subjectID = ""
+		var err error
+		credentialSubjectClaims, disclosures, err = credentialPolicy.SelectiveDisclosure.Encode(credentialSubjectWithSeed)
+		if err != nil {
+			vcErr := errors.NewVCError(
+				errors.ErrCredPrepareVCError,
+				fmt.Sprintf("failed to prepare selective disclosures: %v", err),
+			)
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+	}
+
+	cid, err := newCID()
 	if err != nil {
 		vcErr := errors.NewVCError(
-			errors.ErrCredInvalidCredentialSubject,
-			fmt.Sprintf("failed to inject opaque_id_seed: %v", err),
+			errors.ErrCredPrepareVCError,
+			"failed to generate credential ID",
 		)
 		response, _ := json.Marshal(vcErr.Response())
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Load credential policy
-	// 2. Validate against schema
-	// 3. Generate ticket number
-	// 4. Create VC JSON structure with credentialSubjectWithSeed
-	// 5. Sign with issuer key (SD-JWT encoding for selective disclosure)
-	// 6. Update status list
-	// 7. Save to database
+	// Take a ticket to allocate this credential's position in the status list.
+	// The credential is signed with its status entries, so allocation cannot
+	// wait for signing; if issuance fails from here on, the index is revoked.
+	ticketNumber, vcErr := s.takeTicket(ctx, request.CredentialType)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+	statusEntries, vcErr := s.statusLists.Allocate(ctx, request.CredentialType, ticketNumber)
+	if vcErr != nil {
+		vcErr = s.retireTicket(ctx, request.CredentialType, ticketNumber, vcErr)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
 
-	// For now, return a placeholder response
-	// In production, credentialSubjectWithSeed would be used in VC creation
-	_ = credentialSubjectWithSeed // Mark as used for now
+	// Create VC JSON structure with credentialSubjectWithSeed
+	claims := buildVCClaims(vcParams{
+		credentialID:      s.credentialID(cid),
+		credentialType:    request.CredentialType,
+		issuerDID:         s.issuerDID,
+		subjectID:         subjectID,
+		credentialSubject: credentialSubjectClaims,
+		issuanceDate:      issuanceDate,
+		expirationDate:    expirationDate,
+		statusEntries:     statusEntries,
+		holderPublicKey:   request.HolderPublicKey,
+		seedEpoch:         seedEpoch,
+	})
+
+	jwtType := credentialJWTType
+	if credentialPolicy.SelectiveDisclosure != nil {
+		claims.SDAlg = sdjwt.HashAlgorithm
+		jwtType = sdjwt.JWTType
+	}
+
+	// Sign with issuer key
+	credentialJWT, err := crypto.SignJWT(claims, s.signingKey, s.keyID, jwtType)
+	if err != nil {
+		vcErr := errors.NewVCError(
+			errors.ErrCredSignVCError,
+			"failed to sign credential",
+		)
+		vcErr = s.retireTicket(ctx, request.CredentialType, ticketNumber, vcErr)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	// Store the opaque ID seed epoch the credential was signed with
+	if pendingSeed != nil {
+		if vcErr := s.seedRegistry.CommitSeed(ctx, pendingSeed); vcErr != nil {
+			vcErr = s.retireTicket(ctx, request.CredentialType, ticketNumber, vcErr)
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+	}
+
+	// Save to database. Disclosures hold the holder's personal data and are
+	// only returned to the holder, never stored.
+	credential := &models.Credential{
+		CID:                 cid,
+		CredentialType:      request.CredentialType,
+		CredentialSubjectID: request.CredentialSubjectID,
+		IssuanceDate:        issuanceDate,
+		ExpirationDate:      expirationDate,
+		Content:             credentialJWT,
+		TicketNumber:        ticketNumber,
+		LastUpdateTime:      time.Now(),
+		CredentialStatus:    models.CredentialStatusActive,
+		Nonce:               request.Nonce,
+	}
+	if err := s.credentials.Save(ctx, credential); err != nil {
+		vcErr := errors.NewVCError(
+			errors.ErrDBInsertError,
+			fmt.Sprintf("failed to save credential: %v", err),
+		)
+		vcErr = s.retireTicket(ctx, request.CredentialType, ticketNumber, vcErr)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
 
 	credentialResponse := &models.CredentialResponseDTO{
-		CID:        fmt.Sprintf("cred-%d", time.Now().Unix()),
-		Credential: "eyJhbGciOiJFUzI1NiJ9.credential.signature",
+		CID:        cid,
+		Credential: credentialJWT,
 		Nonce:      request.Nonce,
 	}
+	if credentialPolicy.SelectiveDisclosure != nil {
+		credentialResponse.Credential = sdjwt.Format(credentialJWT, disclosures)
+	}
 
 	response, _ := json.Marshal(credentialResponse)
 	return string(response), http.StatusOK, nil
@@ -173,18 +464,14 @@ func (s *Service) Query(ctx context.Context, cid string) (string, int, error) {
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Query database by CID
-	// 2. Return credential if found
+	credential, vcErr := s.findCredential(s.credentials.FindByCID(ctx, cid))
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
 
-	// For now, check if credential exists (placeholder)
-	// Simulate not found for demo
-	vcErr := errors.NewVCError(
-		errors.ErrCredCredentialNotFound,
-		fmt.Sprintf("credential not found: %s", cid),
-	)
-	response, _ := json.Marshal(vcErr.Response())
-	return string(response), vcErr.HTTPStatus(), vcErr
+	response, _ := json.Marshal(credentialResponseOf(credential))
+	return string(response), http.StatusOK, nil
 }
 
 // QueryByNonce queries a credential by nonce
@@ -200,22 +487,21 @@ func (s *Service) QueryByNonce(ctx context.Context, nonce string) (string, int,
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Query database by nonce
-	// 2. Return credential if found
+	// Only the latest credential is returned if nonces collide
+	credential, vcErr := s.findCredential(s.credentials.FindLatestByNonce(ctx, nonce))
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
 
-	// For now, return not found
-	vcErr := errors.NewVCError(
-		errors.ErrCredCredentialNotFound,
-		fmt.Sprintf("credential not found with nonce: %s", nonce),
-	)
-	response, _ := json.Marshal(vcErr.Response())
-	return string(response), vcErr.HTTPStatus(), vcErr
+	response, _ := json.Marshal(credentialResponseOf(credential))
+	return string(response), http.StatusOK, nil
 }
 
-// Revoke revokes a credential
+// Revoke revokes a credential. REVOKED is terminal; revoking twice is rejected.
+// change optionally records the reason code and actor in the status history.
 // Equivalent to Java's CredentialService.revoke()
-func (s *Service) Revoke(ctx context.Context, cid string) (string, int, error) {
+func (s *Service) Revoke(ctx context.Context, cid string, change *models.StatusChange) (string, int, error) {
 	// Validate CID
 	if cid == "" {
 		vcErr := errors.NewVCError(
@@ -226,25 +512,12 @@ func (s *Service) Revoke(ctx context.Context, cid string) (string, int, error) {
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Query credential from database
-	// 2. Check current status
-	// 3. Update status to REVOKED
-	// 4. Update status list
-	// 5. Save changes
-
-	// For now, return success placeholder
-	result := map[string]interface{}{
-		"cid":    cid,
-		"status": "REVOKED",
-	}
-	response, _ := json.Marshal(result)
-	return string(response), http.StatusOK, nil
+	return s.changeStatus(ctx, cid, models.CredentialStatusRevoked, change)
 }
 
-// Suspend suspends a credential
+// Suspend suspends an active credential
 // Equivalent to Java's CredentialService.suspend()
-func (s *Service) Suspend(ctx context.Context, cid string) (string, int, error) {
+func (s *Service) Suspend(ctx context.Context, cid string, change *models.StatusChange) (string, int, error) {
 	// Validate CID
 	if cid == "" {
 		vcErr := errors.NewVCError(
@@ -255,25 +528,12 @@ func (s *Service) Suspend(ctx context.Context, cid string) (string, int, error)
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Query credential from database
-	// 2. Check if already revoked (cannot suspend revoked credential)
-	// 3. Update status to SUSPENDED
-	// 4. Update status list
-	// 5. Save changes
-
-	// For now, return success placeholder
-	result := map[string]interface{}{
-		"cid":    cid,
-		"status": "SUSPENDED",
-	}
-	response, _ := json.Marshal(result)
-	return string(response), http.StatusOK, nil
+	return s.changeStatus(ctx, cid, models.CredentialStatusSuspended, change)
 }
 
 // Recover recovers a suspended credential
 // Equivalent to Java's CredentialService.recover()
-func (s *Service) Recover(ctx context.Context, cid string) (string, int, error) {
+func (s *Service) Recover(ctx context.Context, cid string, change *models.StatusChange) (string, int, error) {
 	// Validate CID
 	if cid == "" {
 		vcErr := errors.NewVCError(
@@ -284,22 +544,224 @@ func (s *Service) Recover(ctx context.Context, cid string) (string, int, error)
 		return string(response), vcErr.HTTPStatus(), vcErr
 	}
 
-	// In a full implementation, this would:
-	// 1. Query credential from database
-	// 2. Check if revoked (cannot recover revoked credential)
-	// 3. Update status to ACTIVE
-	// 4. Update status list
-	// 5. Save changes
+	return s.changeStatus(ctx, cid, models.CredentialStatusActive, change)
+}
+
+// RotateSeed retires a holder's current opaque_id_seed epoch and starts the
+// next one. Credentials issued afterwards carry the new seed and epoch; the
+// retired seed is kept for audit. change optionally records the reason code
+// and actor of the rotation. A rotation gives the holder a new pairwise_id at
+// every verifier, so rotations requested by the holder (HOLDER_REQUEST) are
+// limited to one per holder rotation interval.
+func (s *Service) RotateSeed(ctx context.Context, holderUID, credentialType string, change *models.StatusChange) (string, int, error) {
+	if holderUID == "" {
+		vcErr := errors.NewVCError(
+			errors.ErrCredInvalidCredentialSubject,
+			"holder UID is required",
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	credentialPolicy, vcErr := s.loadPolicy(ctx, credentialType)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	reason, actor := models.StatusReasonUnspecified, models.StatusActorSystem
+	if change != nil {
+		if change.ReasonCode != "" {
+			reason = change.ReasonCode
+		}
+		if change.Actor != "" {
+			actor = change.Actor
+		}
+	}
+
+	if reason == models.StatusReasonHolderRequest {
+		if vcErr := s.checkHolderRotationInterval(ctx, holderUID, credentialType); vcErr != nil {
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+	}
 
-	// For now, return success placeholder
-	result := map[string]interface{}{
-		"cid":    cid,
-		"status": "ACTIVE",
+	seedEpoch, vcErr := s.seedRegistry.RotateSeed(ctx, holderUID, credentialType, reason, actor, credentialPolicy.SeedRotation)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
 	}
-	response, _ := json.Marshal(result)
+
+	response, _ := json.Marshal(&models.OpaqueIDSeedEpochDTO{
+		HolderUID:      holderUID,
+		CredentialType: credentialType,
+		Epoch:          seedEpoch.Epoch,
+		ValidUntil:     seedEpoch.ValidUntil,
+	})
 	return string(response), http.StatusOK, nil
 }
 
+// checkHolderRotationInterval rejects a holder-requested rotation within the
+// holder rotation interval of the previous one
+func (s *Service) checkHolderRotationInterval(ctx context.Context, holderUID, credentialType string) *errors.VCError {
+	seeds, vcErr := s.seedRegistry.SeedHistory(ctx, holderUID, credentialType)
+	if vcErr != nil {
+		return vcErr
+	}
+	for _, seed := range seeds {
+		if seed.RetireReason == models.StatusReasonHolderRequest && seed.RetiredAt != nil && time.Since(*seed.RetiredAt) < s.holderRotationInterval {
+			return errors.NewVCError(
+				errors.ErrCredSeedRotationTooFrequent,
+				fmt.Sprintf("the holder's seed was already rotated on request within %s", s.holderRotationInterval),
+			)
+		}
+	}
+	return nil
+}
+
+// SeedHistory lists every opaque_id_seed epoch of a holder and credential
+// type, oldest first, without the seeds themselves
+func (s *Service) SeedHistory(ctx context.Context, holderUID, credentialType string) (string, int, error) {
+	if holderUID == "" {
+		vcErr := errors.NewVCError(
+			errors.ErrCredInvalidCredentialSubject,
+			"holder UID is required",
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	seeds, vcErr := s.seedRegistry.SeedHistory(ctx, holderUID, credentialType)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	response, _ := json.Marshal(seeds)
+	return string(response), http.StatusOK, nil
+}
+
+// findCredential maps a repository lookup result to a credential or a VCError
+func (s *Service) findCredential(credential *models.Credential, err error) (*models.Credential, *errors.VCError) {
+	if err == nil {
+		return credential, nil
+	}
+	if stderrors.Is(err, repository.ErrNotFound) {
+		return nil, errors.NewVCError(errors.ErrCredCredentialNotFound, "credential not found")
+	}
+	return nil, errors.NewVCError(errors.ErrCredQueryVCError, fmt.Sprintf("failed to query credential: %v", err))
+}
+
+// credentialResponseOf builds the query response for a stored credential
+func credentialResponseOf(credential *models.Credential) *models.CredentialResponseDTO {
+	return &models.CredentialResponseDTO{
+		CID:        credential.CID,
+		Credential: credential.Content,
+		Nonce:      credential.Nonce,
+	}
+}
+
+// GetStatusList returns the latest signed status list credential of a credential type and group name
+// Equivalent to Java's PublicInfoService.getStatusList()
+func (s *Service) GetStatusList(ctx context.Context, credentialType, groupName string) (string, int, error) {
+	statusList, vcErr := s.statusLists.Get(ctx, credentialType, groupName)
+	if vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	response, _ := json.Marshal(statusList)
+	return string(response), http.StatusOK, nil
+}
+
+// loadPolicy loads and validates the policy of a credential type
+// Equivalent to Java's CredentialService.generate() step 2
+func (s *Service) loadPolicy(ctx context.Context, credentialType string) (*policy.Policy, *errors.VCError) {
+	entity, err := s.policies.FindPolicy(ctx, credentialType)
+	if stderrors.Is(err, repository.ErrNotFound) {
+		return nil, errors.NewVCError(
+			errors.ErrCredInvalidCredentialType,
+			fmt.Sprintf("invalid credential type: %s", credentialType),
+		)
+	}
+	if err != nil {
+		return nil, errors.NewVCError(
+			errors.ErrDBQueryError,
+			fmt.Sprintf("failed to load credential policy: %v", err),
+		)
+	}
+
+	return policy.New(entity)
+}
+
+// privateJWKMembers are the JWK members that only appear in private or symmetric keys
+var privateJWKMembers = []string{"d", "p", "q", "dp", "dq", "qi", "oth", "k"}
+
+// validateHolderPublicKey checks that a holder key to bind (cnf) is a public JWK
+func validateHolderPublicKey(jwk map[string]interface{}) *errors.VCError {
+	if jwk == nil {
+		return nil
+	}
+
+	if kty, _ := jwk["kty"].(string); kty == "" {
+		return errors.NewVCError(errors.ErrCredInvalidCredentialGenerationRequest, "invalid holder public key: missing kty")
+	}
+	for _, member := range privateJWKMembers {
+		if _, ok := jwk[member]; ok {
+			return errors.NewVCError(errors.ErrCredInvalidCredentialGenerationRequest, "invalid holder public key: not a public key")
+		}
+	}
+
+	return nil
+}
+
+// sequenceNamePattern restricts the names of ticket sequences (one per credential type)
+var sequenceNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// takeTicket returns the next ticket number for a credential type
+// Equivalent to Java's CredentialService.generate() step 3.3
+func (s *Service) takeTicket(ctx context.Context, credentialType string) (int, *errors.VCError) {
+	if !sequenceNamePattern.MatchString(credentialType) {
+		return 0, errors.NewVCError(errors.ErrDBInvalidSequenceName, fmt.Sprintf("invalid sequence name: %s", credentialType))
+	}
+
+	ticket, err := s.tickets.TakeTicket(ctx, credentialType)
+	if err != nil {
+		return 0, errors.NewVCError(errors.ErrDBInsertError, fmt.Sprintf("fail to take ticket: %v", err))
+	}
+
+	return ticket.TicketNumber, nil
+}
+
+// retireTicket revokes the status list index of a ticket that no credential
+// was issued with, so the index never reads as active. Tickets are not reused.
+func (s *Service) retireTicket(ctx context.Context, credentialType string, ticketNumber int, vcErr *errors.VCError) *errors.VCError {
+	if retireErr := s.statusLists.Update(ctx, credentialType, ticketNumber, models.CredentialStatusRevoked); retireErr != nil {
+		return errors.NewVCError(
+			vcErr.Code,
+			fmt.Sprintf("%s; failed to revoke unused status list index: %v", vcErr.Message, retireErr),
+		)
+	}
+	return vcErr
+}
+
+// credentialID builds the public ID of a credential
+// ex: http://localhost:8080/api/credential/<cid>
+func (s *Service) credentialID(cid string) string {
+	return fmt.Sprintf("%s/api/credential/%s", s.baseURL, cid)
+}
+
+// newCID generates a random (version 4) UUID to identify a credential
+func newCID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
 // validateMapStringLengths validates string lengths and nesting depth in a map
 func validateMapStringLengths(m map[string]interface{}, depth int) error {
 	// Check max depth to prevent stack overflow
@@ -2,15 +2,81 @@ package credential
 
 import (
 	"context"
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
 	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/statuslist"
 )
 
+// testIssuerKeyPEM generates a PEM-encoded P-256 issuer key for tests
+func testIssuerKeyPEM(t *testing.T) (string, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate issuer key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer key: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), privateKey
+}
+
+// withTestPolicies registers a one-year policy for the credential types used in tests
+func withTestPolicies() Option {
+	return WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: models.TimeUnitYear},
+		models.CredentialPolicyEntity{CredentialType: "IdentityCredential", ExpirationDuration: 1, ExpirationTimeUnit: models.TimeUnitYear, PairwiseEnabled: true},
+	))
+}
+
+// issueTestCredential issues a credential and returns its CID
+func issueTestCredential(t *testing.T, service *Service, nonce string) string {
+	t.Helper()
+	return issueTestCredentialAt(t, service, nonce, time.Now())
+}
+
+// issueTestCredentialAt issues a credential with the given issuance date and returns its CID
+func issueTestCredentialAt(t *testing.T, service *Service, nonce string, issuanceDate time.Time) string {
+	t.Helper()
+
+	result, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+		IssuerDID:         "did:example:issuer",
+		CredentialType:    "TestCredential",
+		CredentialSubject: map[string]interface{}{"name": "Test User"},
+		IssuanceDate:      &issuanceDate,
+		Nonce:             nonce,
+	})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to issue credential: %d %v", status, err)
+	}
+
+	var response models.CredentialResponseDTO
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return response.CID
+}
+
 // TestNewService tests service creation
 func TestNewService(t *testing.T) {
 	service := NewService("did:example:issuer", "issuer-key")
@@ -167,11 +233,13 @@ func TestGenerate_MissingCredentialSubject(t *testing.T) {
 // TestGenerate_Success tests successful credential generation
 func TestGenerate_Success(t *testing.T) {
 	// Given
-	service := NewService("did:example:issuer", "issuer-key")
+	issuerKey, privateKey := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
 	ctx := context.Background()
 	request := &models.CredentialRequestDTO{
-		IssuerDID:      "did:example:issuer",
-		CredentialType: "IdentityCredential",
+		IssuerDID:           "did:example:issuer",
+		CredentialType:      "IdentityCredential",
+		CredentialSubjectID: "did:example:holder",
 		CredentialSubject: map[string]interface{}{
 			"name": "Test User",
 			"age":  30,
@@ -208,6 +276,151 @@ func TestGenerate_Success(t *testing.T) {
 	if response.Nonce != request.Nonce {
 		t.Errorf("Expected nonce %s, got %s", request.Nonce, response.Nonce)
 	}
+
+	// Verify the credential signature with the issuer public key
+	claims := &VCClaims{}
+	token, err := jwt.ParseWithClaims(response.Credential, claims, func(token *jwt.Token) (interface{}, error) {
+		return &privateKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatalf("Failed to verify credential signature: %v", err)
+	}
+
+	if kid, _ := token.Header["kid"].(string); kid != "did:example:issuer#key-1" {
+		t.Errorf("Expected kid did:example:issuer#key-1, got %v", token.Header["kid"])
+	}
+
+	if claims.Issuer != "did:example:issuer" || claims.VC.Issuer != "did:example:issuer" {
+		t.Errorf("Expected issuer did:example:issuer, got %s / %s", claims.Issuer, claims.VC.Issuer)
+	}
+
+	if claims.Subject != "did:example:holder" {
+		t.Errorf("Expected subject did:example:holder, got %s", claims.Subject)
+	}
+
+	if len(claims.VC.Type) != 2 || claims.VC.Type[1] != "IdentityCredential" {
+		t.Errorf("Unexpected credential types: %v", claims.VC.Type)
+	}
+
+	if claims.VC.CredentialSubject["name"] != "Test User" {
+		t.Errorf("Expected name claim, got %v", claims.VC.CredentialSubject["name"])
+	}
+
+	if _, ok := claims.VC.CredentialSubject["opaque_id_seed"].(string); !ok {
+		t.Error("Expected opaque_id_seed in credential subject")
+	}
+
+	if claims.OpaqueIDSeedEpoch != 1 {
+		t.Errorf("Expected opaque_id_seed_epoch 1, got %d", claims.OpaqueIDSeedEpoch)
+	}
+
+	if _, err := time.Parse(time.RFC3339, claims.VC.ExpirationDate); err != nil {
+		t.Errorf("Invalid expirationDate: %v", err)
+	}
+
+	if len(claims.VC.CredentialStatus) != 2 {
+		t.Fatalf("Expected revocation and suspension entries, got %+v", claims.VC.CredentialStatus)
+	}
+	for i, purpose := range []string{models.StatusListTypeRevocation, models.StatusListTypeSuspension} {
+		entry := claims.VC.CredentialStatus[i]
+		if entry.StatusPurpose != purpose || entry.StatusListIndex != "0" {
+			t.Errorf("Expected %s entry with index 0, got %+v", purpose, entry)
+		}
+	}
+}
+
+// TestGenerate_InvalidIssuerKey tests generation with an unparsable issuer key
+func TestGenerate_InvalidIssuerKey(t *testing.T) {
+	// Given
+	service := NewService("did:example:issuer", "issuer-key")
+	ctx := context.Background()
+	request := &models.CredentialRequestDTO{
+		CredentialType: "IdentityCredential",
+		CredentialSubject: map[string]interface{}{
+			"name": "Test User",
+		},
+	}
+
+	// When
+	_, status, err := service.Generate(ctx, request)
+
+	// Then
+	vcErr, ok := err.(*errors.VCError)
+	if !ok {
+		t.Fatalf("Expected VCError, got %T", err)
+	}
+
+	if vcErr.Code != errors.ErrSysNotSetKeyYetError {
+		t.Errorf("Expected error code %d, got %d", errors.ErrSysNotSetKeyYetError, vcErr.Code)
+	}
+
+	if status != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+// failingSigner is an issuer key whose signatures always fail
+type failingSigner struct {
+	*ecdsa.PrivateKey
+}
+
+func (failingSigner) Sign(io.Reader, []byte, stdcrypto.SignerOpts) ([]byte, error) {
+	return nil, stderrors.New("signer unavailable")
+}
+
+// TestGenerate_SignFailureRevokesStatusIndex tests that a credential that
+// fails to sign leaves no active status list index behind
+func TestGenerate_SignFailureRevokesStatusIndex(t *testing.T) {
+	// Given - an issuer whose credential signatures fail
+	issuerKey, privateKey := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	service.signingKey = failingSigner{privateKey}
+	ctx := context.Background()
+
+	// When
+	_, status, err := service.Generate(ctx, &models.CredentialRequestDTO{
+		IssuerDID:         "did:example:issuer",
+		CredentialType:    "TestCredential",
+		CredentialSubject: map[string]interface{}{"name": "Test User"},
+		Nonce:             "nonce-1",
+	})
+
+	// Then
+	vcErr, ok := err.(*errors.VCError)
+	if !ok || vcErr.Code != errors.ErrCredSignVCError {
+		t.Fatalf("Expected error code %d, got %v", errors.ErrCredSignVCError, err)
+	}
+	if status != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, status)
+	}
+
+	if _, _, err := service.QueryByNonce(ctx, "nonce-1"); err == nil {
+		t.Error("Expected no credential to be saved")
+	}
+
+	// The allocated index 0 of list r0 is revoked, as no credential holds it
+	result, status, err := service.GetStatusList(ctx, "TestCredential", "r0")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetStatusList failed: %d %v", status, err)
+	}
+
+	var response models.StatusListResponse
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	claims := &statuslist.Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(response.StatusList, claims); err != nil {
+		t.Fatalf("Failed to parse status list: %v", err)
+	}
+	bitstring, err := statuslist.Decode(claims.VC.CredentialSubject.EncodedList)
+	if err != nil {
+		t.Fatalf("Failed to decode status list: %v", err)
+	}
+
+	if revoked, _ := bitstring.Get(0); !revoked {
+		t.Error("Expected the unused index to be revoked")
+	}
 }
 
 // TestQuery_InvalidCID tests query with invalid CID
@@ -339,7 +552,7 @@ func TestRevoke_InvalidCID(t *testing.T) {
 	ctx := context.Background()
 
 	// When
-	_, status, err := service.Revoke(ctx, "")
+	_, status, err := service.Revoke(ctx, "", nil)
 
 	// Then
 	if err == nil {
@@ -363,11 +576,13 @@ func TestRevoke_InvalidCID(t *testing.T) {
 // TestRevoke_Success tests successful credential revocation
 func TestRevoke_Success(t *testing.T) {
 	// Given
-	service := NewService("did:example:issuer", "issuer-key")
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
 	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
 
 	// When
-	result, status, err := service.Revoke(ctx, "test-cid")
+	result, status, err := service.Revoke(ctx, cid, nil)
 
 	// Then
 	if err != nil {
@@ -392,11 +607,13 @@ func TestRevoke_Success(t *testing.T) {
 // TestSuspend_Success tests successful credential suspension
 func TestSuspend_Success(t *testing.T) {
 	// Given
-	service := NewService("did:example:issuer", "issuer-key")
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
 	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
 
 	// When
-	result, status, err := service.Suspend(ctx, "test-cid")
+	result, status, err := service.Suspend(ctx, cid, nil)
 
 	// Then
 	if err != nil {
@@ -421,11 +638,16 @@ func TestSuspend_Success(t *testing.T) {
 // TestRecover_Success tests successful credential recovery
 func TestRecover_Success(t *testing.T) {
 	// Given
-	service := NewService("did:example:issuer", "issuer-key")
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
 	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
+	if _, _, err := service.Suspend(ctx, cid, nil); err != nil {
+		t.Fatalf("Failed to suspend credential: %v", err)
+	}
 
 	// When
-	result, status, err := service.Recover(ctx, "test-cid")
+	result, status, err := service.Recover(ctx, cid, nil)
 
 	// Then
 	if err != nil {
@@ -447,6 +669,87 @@ func TestRecover_Success(t *testing.T) {
 	}
 }
 
+// TestRevoke_NotFound tests revocation of a credential that was never issued
+func TestRevoke_NotFound(t *testing.T) {
+	// Given
+	service := NewService("did:example:issuer", "issuer-key")
+	ctx := context.Background()
+
+	// When
+	_, status, err := service.Revoke(ctx, "test-cid", nil)
+
+	// Then
+	vcErr, ok := err.(*errors.VCError)
+	if !ok {
+		t.Fatalf("Expected VCError, got %T", err)
+	}
+
+	if vcErr.Code != errors.ErrCredCredentialNotFound {
+		t.Errorf("Expected error code %d, got %d", errors.ErrCredCredentialNotFound, vcErr.Code)
+	}
+
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, status)
+	}
+}
+
+// TestQuery_Success tests querying an issued credential by CID and by nonce
+func TestQuery_Success(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
+
+	// When
+	byCID, status, err := service.Query(ctx, cid)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Query failed: %d %v", status, err)
+	}
+	byNonce, status, err := service.QueryByNonce(ctx, "test-nonce")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("QueryByNonce failed: %d %v", status, err)
+	}
+
+	// Then
+	for _, result := range []string{byCID, byNonce} {
+		var response models.CredentialResponseDTO
+		if err := json.Unmarshal([]byte(result), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.CID != cid {
+			t.Errorf("Expected CID %s, got %s", cid, response.CID)
+		}
+		if response.Credential == "" || response.Nonce != "test-nonce" {
+			t.Errorf("Expected stored credential and nonce, got %+v", response)
+		}
+	}
+}
+
+// TestQueryByNonce_ReturnsLatest tests that the latest credential wins when nonces collide
+func TestQueryByNonce_ReturnsLatest(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	issueTestCredentialAt(t, service, "shared-nonce", time.Now().Add(-time.Hour))
+	latest := issueTestCredentialAt(t, service, "shared-nonce", time.Now())
+
+	// When
+	result, _, err := service.QueryByNonce(ctx, "shared-nonce")
+
+	// Then
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var response models.CredentialResponseDTO
+	json.Unmarshal([]byte(result), &response)
+	if response.CID != latest {
+		t.Errorf("Expected latest CID %s, got %s", latest, response.CID)
+	}
+}
+
 // TestGenerate_CredentialSubjectTooLarge tests generation with too many fields in credential subject
 func TestGenerate_CredentialSubjectTooLarge(t *testing.T) {
 	// Given
@@ -614,3 +917,121 @@ func TestGenerate_CredentialTypeTooLong(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, status)
 	}
 }
+
+// TestRotateSeed_Success tests seed rotation and its effect on issued credentials
+func TestRotateSeed_Success(t *testing.T) {
+	// Given - a credential issued in seed epoch 1
+	ctx := context.Background()
+	issuerKey, privateKey := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	request := &models.CredentialRequestDTO{
+		IssuerDID:           "did:example:issuer",
+		CredentialType:      "IdentityCredential",
+		CredentialSubjectID: "did:example:holder",
+		CredentialSubject:   map[string]interface{}{"name": "Test User"},
+	}
+	if _, status, err := service.Generate(ctx, request); err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to issue credential: %d %v", status, err)
+	}
+
+	// When
+	result, status, err := service.RotateSeed(ctx, "did:example:holder", "IdentityCredential", &models.StatusChange{ReasonCode: models.StatusReasonHolderRequest, Actor: "admin"})
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("RotateSeed failed: %d %v", status, err)
+	}
+	var rotated models.OpaqueIDSeedEpochDTO
+	if err := json.Unmarshal([]byte(result), &rotated); err != nil || rotated.Epoch != 2 {
+		t.Errorf("Expected epoch 2, got %s (%v)", result, err)
+	}
+
+	// And the next credential carries the new epoch
+	result, _, _ = service.Generate(ctx, request)
+	var response models.CredentialResponseDTO
+	json.Unmarshal([]byte(result), &response)
+	claims := &VCClaims{}
+	if _, err := jwt.ParseWithClaims(response.Credential, claims, func(token *jwt.Token) (interface{}, error) {
+		return &privateKey.PublicKey, nil
+	}); err != nil {
+		t.Fatalf("Failed to verify credential: %v", err)
+	}
+	if claims.OpaqueIDSeedEpoch != 2 {
+		t.Errorf("Expected opaque_id_seed_epoch 2, got %d", claims.OpaqueIDSeedEpoch)
+	}
+
+	// And the history records both epochs
+	result, status, err = service.SeedHistory(ctx, "did:example:holder", "IdentityCredential")
+	var history []models.OpaqueIDSeed
+	if err != nil || json.Unmarshal([]byte(result), &history) != nil || len(history) != 2 || history[0].RetireReason != models.StatusReasonHolderRequest {
+		t.Errorf("Expected 2 epochs with a holder request rotation, got %d %s (%v)", status, result, err)
+	}
+	if strings.Contains(result, "seed_cipher") {
+		t.Error("Expected the seed history without seed ciphers")
+	}
+	if strings.Contains(result, "did:example:holder") {
+		t.Error("Expected the seed history without the raw holder UID")
+	}
+}
+
+// TestRotateSeed_HolderRequestInterval tests that holder-requested rotations are rate limited
+func TestRotateSeed_HolderRequestInterval(t *testing.T) {
+	// Given - a holder whose seed was just rotated on request
+	ctx := context.Background()
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies(), WithHolderRotationInterval(time.Hour))
+	holderRequest := &models.StatusChange{ReasonCode: models.StatusReasonHolderRequest, Actor: "holder"}
+	if _, status, err := service.RotateSeed(ctx, "did:example:holder", "IdentityCredential", nil); err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to create seed: %d %v", status, err)
+	}
+	if _, status, err := service.RotateSeed(ctx, "did:example:holder", "IdentityCredential", holderRequest); err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to rotate seed: %d %v", status, err)
+	}
+
+	t.Run("Holder request within the interval", func(t *testing.T) {
+		_, status, err := service.RotateSeed(ctx, "did:example:holder", "IdentityCredential", holderRequest)
+
+		vcErr, ok := err.(*errors.VCError)
+		if !ok || vcErr.Code != errors.ErrCredSeedRotationTooFrequent || status != http.StatusTooManyRequests {
+			t.Errorf("Expected error %d (429), got %d %v", errors.ErrCredSeedRotationTooFrequent, status, err)
+		}
+	})
+
+	t.Run("Operator rotation", func(t *testing.T) {
+		change := &models.StatusChange{ReasonCode: models.StatusReasonKeyCompromise, Actor: "admin"}
+		if _, status, err := service.RotateSeed(ctx, "did:example:holder", "IdentityCredential", change); err != nil || status != http.StatusOK {
+			t.Errorf("Expected an operator rotation to be allowed, got %d %v", status, err)
+		}
+	})
+
+	t.Run("Other holder", func(t *testing.T) {
+		if _, status, err := service.RotateSeed(ctx, "did:example:other", "IdentityCredential", holderRequest); err != nil || status != http.StatusOK {
+			t.Errorf("Expected another holder's rotation to be allowed, got %d %v", status, err)
+		}
+	})
+}
+
+// TestRotateSeed_InvalidRequest tests seed rotation with invalid parameters
+func TestRotateSeed_InvalidRequest(t *testing.T) {
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+
+	tests := []struct {
+		name           string
+		holderUID      string
+		credentialType string
+		expected       int
+	}{
+		{"Missing holder", "", "TestCredential", errors.ErrCredInvalidCredentialSubject},
+		{"Unknown credential type", "did:example:holder", "UnknownCredential", errors.ErrCredInvalidCredentialType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.RotateSeed(context.Background(), tt.holderUID, tt.credentialType, nil)
+			if vcErr, ok := err.(*errors.VCError); !ok || vcErr.Code != tt.expected {
+				t.Errorf("Expected error %d, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+)
+
+// maxStatusTransitionAttempts bounds retries when a credential's status
+// changes concurrently between reading and updating it
+const maxStatusTransitionAttempts = 3
+
+// statusTransitions lists the allowed credential status transitions.
+// REVOKED is terminal.
+var statusTransitions = map[string]map[string]bool{
+	models.CredentialStatusActive: {
+		models.CredentialStatusSuspended: true,
+		models.CredentialStatusRevoked:   true,
+	},
+	models.CredentialStatusSuspended: {
+		models.CredentialStatusActive:  true,
+		models.CredentialStatusRevoked: true,
+	},
+	models.CredentialStatusRevoked: {},
+}
+
+// checkStatusTransition validates moving a credential from one status to another.
+// It reports noop=true when the credential is already in the target status and
+// the request can succeed without a transition (suspend twice, recover an active credential).
+func checkStatusTransition(from, to string) (noop bool, vcErr *errors.VCError) {
+	allowed, known := statusTransitions[from]
+	if !known {
+		return false, errors.NewVCError(
+			errors.ErrCredentialStatusUnknownError,
+			fmt.Sprintf("unknown credential status: %s", from),
+		)
+	}
+
+	if from == models.CredentialStatusRevoked {
+		switch to {
+		case models.CredentialStatusSuspended:
+			return false, errors.NewVCError(
+				errors.ErrCredRevokedCredCannotBeSuspendedError,
+				"revoked credential cannot be suspended",
+			)
+		case models.CredentialStatusActive:
+			return false, errors.NewVCError(
+				errors.ErrCredRevokedCredCannotBeRecoveredError,
+				"revoked credential cannot be recovered",
+			)
+		default:
+			return false, errors.NewVCError(
+				errors.ErrCredInvalidStatusTransition,
+				"credential is already revoked",
+			)
+		}
+	}
+
+	if from == to {
+		return true, nil
+	}
+
+	if !allowed[to] {
+		return false, errors.NewVCError(
+			errors.ErrCredInvalidStatusTransition,
+			fmt.Sprintf("invalid credential status transition: %s -> %s", from, to),
+		)
+	}
+
+	return false, nil
+}
+
+// changeStatus applies a status transition to an issued credential and records it in the status history
+func (s *Service) changeStatus(ctx context.Context, cid, credentialStatus string, change *models.StatusChange) (string, int, error) {
+	transition := &models.CredentialStatusHistory{
+		CID:        cid,
+		ToStatus:   credentialStatus,
+		ReasonCode: models.StatusReasonUnspecified,
+		Actor:      models.StatusActorSystem,
+	}
+	if change != nil {
+		if change.ReasonCode != "" {
+			transition.ReasonCode = change.ReasonCode
+		}
+		if change.Actor != "" {
+			transition.Actor = change.Actor
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		credential, vcErr := s.findCredential(s.credentials.FindByCID(ctx, cid))
+		if vcErr != nil {
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+
+		noop, vcErr := checkStatusTransition(credential.CredentialStatus, credentialStatus)
+		if vcErr != nil {
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+		if noop {
+			break
+		}
+
+		transition.FromStatus = credential.CredentialStatus
+		transition.Timestamp = time.Now()
+
+		// Flip the status list bits first, so a published list never lags behind the stored status
+		if vcErr := s.statusLists.Update(ctx, credential.CredentialType, credential.TicketNumber, credentialStatus); vcErr != nil {
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+
+		err := s.credentials.TransitionStatus(ctx, transition)
+		if err == nil {
+			break
+		}
+
+		// Restore the status list bits of the status that is still stored. If
+		// that fails too, the published list disagrees with the stored status.
+		if restoreErr := s.restoreStatusList(ctx, cid); restoreErr != nil {
+			vcErr = errors.NewVCError(
+				errors.ErrDBUpdateError,
+				fmt.Sprintf("failed to update credential status: %v; failed to restore status list: %v", err, restoreErr),
+			)
+			response, _ := json.Marshal(vcErr.Response())
+			return string(response), vcErr.HTTPStatus(), vcErr
+		}
+
+		// Status changed since it was read; re-evaluate against the new status
+		if stderrors.Is(err, repository.ErrConflict) && attempt < maxStatusTransitionAttempts {
+			continue
+		}
+
+		vcErr = errors.NewVCError(
+			errors.ErrDBUpdateError,
+			fmt.Sprintf("failed to update credential status: %v", err),
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	result := map[string]interface{}{
+		"cid":    cid,
+		"status": credentialStatus,
+	}
+	response, _ := json.Marshal(result)
+	return string(response), http.StatusOK, nil
+}
+
+// restoreStatusList sets the status list bits of a credential back to its stored status
+func (s *Service) restoreStatusList(ctx context.Context, cid string) error {
+	credential, err := s.credentials.FindByCID(ctx, cid)
+	if err != nil {
+		return err
+	}
+	if vcErr := s.statusLists.Update(ctx, credential.CredentialType, credential.TicketNumber, credential.CredentialStatus); vcErr != nil {
+		return vcErr
+	}
+	return nil
+}
+
+// StatusHistory returns the status transitions of a credential, oldest first
+func (s *Service) StatusHistory(ctx context.Context, cid string) (string, int, error) {
+	// Validate CID
+	if cid == "" {
+		vcErr := errors.NewVCError(
+			errors.ErrCredInvalidCredentialID,
+			"invalid credential ID",
+		)
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	history, err := s.credentials.FindStatusHistory(ctx, cid)
+	if _, vcErr := s.findCredential(nil, err); vcErr != nil {
+		response, _ := json.Marshal(vcErr.Response())
+		return string(response), vcErr.HTTPStatus(), vcErr
+	}
+
+	response, _ := json.Marshal(history)
+	return string(response), http.StatusOK, nil
+}
@@ -0,0 +1,259 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/statuslist"
+)
+
+// TestCheckStatusTransition tests the credential status state machine
+func TestCheckStatusTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		noop     bool
+		expected int
+	}{
+		{"Active to suspended", models.CredentialStatusActive, models.CredentialStatusSuspended, false, 0},
+		{"Active to revoked", models.CredentialStatusActive, models.CredentialStatusRevoked, false, 0},
+		{"Active to active", models.CredentialStatusActive, models.CredentialStatusActive, true, 0},
+		{"Suspended to active", models.CredentialStatusSuspended, models.CredentialStatusActive, false, 0},
+		{"Suspended to revoked", models.CredentialStatusSuspended, models.CredentialStatusRevoked, false, 0},
+		{"Suspended to suspended", models.CredentialStatusSuspended, models.CredentialStatusSuspended, true, 0},
+		{"Revoked to revoked", models.CredentialStatusRevoked, models.CredentialStatusRevoked, false, errors.ErrCredInvalidStatusTransition},
+		{"Revoked to suspended", models.CredentialStatusRevoked, models.CredentialStatusSuspended, false, errors.ErrCredRevokedCredCannotBeSuspendedError},
+		{"Revoked to active", models.CredentialStatusRevoked, models.CredentialStatusActive, false, errors.ErrCredRevokedCredCannotBeRecoveredError},
+		{"Unknown status", "EXPIRED", models.CredentialStatusActive, false, errors.ErrCredentialStatusUnknownError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noop, vcErr := checkStatusTransition(tt.from, tt.to)
+
+			if noop != tt.noop {
+				t.Errorf("Expected noop %v, got %v", tt.noop, noop)
+			}
+
+			code := 0
+			if vcErr != nil {
+				code = vcErr.Code
+			}
+			if code != tt.expected {
+				t.Errorf("Expected error code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+// TestRevoke_Twice tests that revoking a revoked credential returns 400
+func TestRevoke_Twice(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
+	if _, _, err := service.Revoke(ctx, cid, nil); err != nil {
+		t.Fatalf("First revoke failed: %v", err)
+	}
+
+	// When
+	_, status, err := service.Revoke(ctx, cid, nil)
+
+	// Then
+	vcErr, ok := err.(*errors.VCError)
+	if !ok {
+		t.Fatalf("Expected VCError, got %T", err)
+	}
+
+	if vcErr.Code != errors.ErrCredInvalidStatusTransition {
+		t.Errorf("Expected error code %d, got %d", errors.ErrCredInvalidStatusTransition, vcErr.Code)
+	}
+
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, status)
+	}
+}
+
+// TestRecover_RevokedCredential tests that a revoked credential cannot be recovered or suspended
+func TestRecover_RevokedCredential(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
+	service.Revoke(ctx, cid, nil)
+
+	// When
+	_, recoverStatus, recoverErr := service.Recover(ctx, cid, nil)
+	_, suspendStatus, suspendErr := service.Suspend(ctx, cid, nil)
+
+	// Then
+	if vcErr, ok := recoverErr.(*errors.VCError); !ok || vcErr.Code != errors.ErrCredRevokedCredCannotBeRecoveredError {
+		t.Errorf("Expected error code %d, got %v", errors.ErrCredRevokedCredCannotBeRecoveredError, recoverErr)
+	}
+	if recoverStatus != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, recoverStatus)
+	}
+
+	if vcErr, ok := suspendErr.(*errors.VCError); !ok || vcErr.Code != errors.ErrCredRevokedCredCannotBeSuspendedError {
+		t.Errorf("Expected error code %d, got %v", errors.ErrCredRevokedCredCannotBeSuspendedError, suspendErr)
+	}
+	if suspendStatus != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, suspendStatus)
+	}
+}
+
+// TestStatusHistory tests that each transition is recorded with reason code and actor
+func TestStatusHistory(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	cid := issueTestCredential(t, service, "test-nonce")
+
+	// When
+	service.Suspend(ctx, cid, &models.StatusChange{ReasonCode: models.StatusReasonHolderRequest, Actor: "admin"})
+	service.Suspend(ctx, cid, nil) // no-op, not recorded
+	service.Recover(ctx, cid, &models.StatusChange{Actor: "admin"})
+	service.Revoke(ctx, cid, &models.StatusChange{ReasonCode: models.StatusReasonKeyCompromise})
+	service.Revoke(ctx, cid, nil) // rejected, not recorded
+	result, status, err := service.StatusHistory(ctx, cid)
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("StatusHistory failed: %d %v", status, err)
+	}
+
+	var history []models.CredentialStatusHistory
+	if err := json.Unmarshal([]byte(result), &history); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	expected := []models.CredentialStatusHistory{
+		{FromStatus: "ACTIVE", ToStatus: "SUSPENDED", ReasonCode: models.StatusReasonHolderRequest, Actor: "admin"},
+		{FromStatus: "SUSPENDED", ToStatus: "ACTIVE", ReasonCode: models.StatusReasonUnspecified, Actor: "admin"},
+		{FromStatus: "ACTIVE", ToStatus: "REVOKED", ReasonCode: models.StatusReasonKeyCompromise, Actor: models.StatusActorSystem},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("Expected %d history entries, got %d", len(expected), len(history))
+	}
+	for i, want := range expected {
+		got := history[i]
+		if got.CID != cid || got.FromStatus != want.FromStatus || got.ToStatus != want.ToStatus ||
+			got.ReasonCode != want.ReasonCode || got.Actor != want.Actor || got.Timestamp.IsZero() {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+// TestStatusHistory_NotFound tests status history of a credential that was never issued
+func TestStatusHistory_NotFound(t *testing.T) {
+	service := NewService("did:example:issuer", "issuer-key")
+
+	_, status, err := service.StatusHistory(context.Background(), "test-cid")
+
+	if vcErr, ok := err.(*errors.VCError); !ok || vcErr.Code != errors.ErrCredCredentialNotFound {
+		t.Errorf("Expected error code %d, got %v", errors.ErrCredCredentialNotFound, err)
+	}
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, status)
+	}
+}
+
+// TestRevoke_UpdatesStatusList tests that revoking a credential sets its bit in the published status list
+func TestRevoke_UpdatesStatusList(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies())
+	ctx := context.Background()
+	issueTestCredential(t, service, "nonce-1")
+	cid := issueTestCredential(t, service, "nonce-2")
+
+	// When
+	if _, _, err := service.Revoke(ctx, cid, nil); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	// Then - the second credential holds index 1 of list r0
+	result, status, err := service.GetStatusList(ctx, "TestCredential", "r0")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetStatusList failed: %d %v", status, err)
+	}
+
+	var response models.StatusListResponse
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	claims := &statuslist.Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(response.StatusList, claims); err != nil {
+		t.Fatalf("Failed to parse status list: %v", err)
+	}
+	bitstring, err := statuslist.Decode(claims.VC.CredentialSubject.EncodedList)
+	if err != nil {
+		t.Fatalf("Failed to decode status list: %v", err)
+	}
+
+	if revoked, _ := bitstring.Get(1); !revoked {
+		t.Error("Expected revoked bit to be set")
+	}
+	if revoked, _ := bitstring.Get(0); revoked {
+		t.Error("Expected bit of the other credential to be clear")
+	}
+}
+
+// unavailableDB fails status transitions, and status list saves once
+// savesLeft (if not negative) is used up
+type unavailableDB struct {
+	*repository.MemoryCredentialRepository
+	*repository.MemoryStatusListRepository
+	savesLeft int
+}
+
+func (db *unavailableDB) TransitionStatus(ctx context.Context, transition *models.CredentialStatusHistory) error {
+	return stderrors.New("database unavailable")
+}
+
+func (db *unavailableDB) SaveStatusList(ctx context.Context, statusList *models.StatusList) error {
+	if db.savesLeft == 0 {
+		return stderrors.New("status list store unavailable")
+	}
+	db.savesLeft--
+	return db.MemoryStatusListRepository.SaveStatusList(ctx, statusList)
+}
+
+func TestRevoke_ReportsFailedStatusListRestore(t *testing.T) {
+	// Given - a credential whose status transition fails
+	issuerKey, _ := testIssuerKeyPEM(t)
+	db := &unavailableDB{
+		MemoryCredentialRepository: repository.NewMemoryCredentialRepository(),
+		MemoryStatusListRepository: repository.NewMemoryStatusListRepository(),
+		savesLeft:                  -1,
+	}
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies(), WithRepository(db), WithStatusListRepository(db))
+	cid := issueTestCredential(t, service, "nonce-1")
+
+	// When - the revoked bit is published, but cannot be cleared again
+	db.savesLeft = 1
+	_, status, err := service.Revoke(context.Background(), cid, nil)
+
+	// Then - both failures are reported
+	vcErr, ok := err.(*errors.VCError)
+	if !ok || vcErr.Code != errors.ErrDBUpdateError || status != http.StatusInternalServerError {
+		t.Fatalf("Expected ErrDBUpdateError, got %d %v", status, err)
+	}
+	if !strings.Contains(vcErr.Message, "database unavailable") || !strings.Contains(vcErr.Message, "failed to restore status list") {
+		t.Errorf("Expected the transition and restore failures to be reported, got %q", vcErr.Message)
+	}
+}
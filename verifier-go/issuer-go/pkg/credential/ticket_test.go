@@ -0,0 +1,141 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+)
+
+// TestGenerate_ConcurrentTickets tests that concurrent issuance never shares a CID or status list position
+func TestGenerate_ConcurrentTickets(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	credentials := repository.NewMemoryCredentialRepository()
+	service := NewService("did:example:issuer", issuerKey, withTestPolicies(), WithRepository(credentials))
+
+	const issued = 20
+	cids := make(chan string, issued)
+	var wg sync.WaitGroup
+
+	// When
+	for i := 0; i < issued; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+				IssuerDID:         "did:example:issuer",
+				CredentialType:    "TestCredential",
+				CredentialSubject: map[string]interface{}{"name": "Test User"},
+			})
+			if err != nil || status != http.StatusOK {
+				t.Errorf("Failed to issue credential: %d %v", status, err)
+				return
+			}
+			var response models.CredentialResponseDTO
+			json.Unmarshal([]byte(result), &response)
+			cids <- response.CID
+		}()
+	}
+	wg.Wait()
+	close(cids)
+
+	// Then
+	seenCIDs := make(map[string]bool)
+	seenTickets := make(map[int]bool)
+	for cid := range cids {
+		if seenCIDs[cid] {
+			t.Errorf("CID %s issued twice", cid)
+		}
+		seenCIDs[cid] = true
+
+		credential, err := credentials.FindByCID(context.Background(), cid)
+		if err != nil {
+			t.Fatalf("Failed to load credential: %v", err)
+		}
+		if seenTickets[credential.TicketNumber] {
+			t.Errorf("Ticket %d taken twice", credential.TicketNumber)
+		}
+		seenTickets[credential.TicketNumber] = true
+	}
+	for ticketNumber := 1; ticketNumber <= issued; ticketNumber++ {
+		if !seenTickets[ticketNumber] {
+			t.Errorf("Ticket %d was never taken", ticketNumber)
+		}
+	}
+}
+
+// TestGenerate_TicketsSurviveRestart tests that a new service on the same ticket repository continues the sequence
+func TestGenerate_TicketsSurviveRestart(t *testing.T) {
+	// Given
+	issuerKey, _ := testIssuerKeyPEM(t)
+	tickets := repository.NewMemoryTicketRepository()
+	credentials := repository.NewMemoryCredentialRepository()
+	issueTestCredential(t, NewService("did:example:issuer", issuerKey, withTestPolicies(), WithTicketRepository(tickets)), "")
+
+	// When
+	restarted := NewService("did:example:issuer", issuerKey, withTestPolicies(), WithTicketRepository(tickets), WithRepository(credentials))
+	cid := issueTestCredential(t, restarted, "")
+
+	// Then
+	credential, _ := credentials.FindByCID(context.Background(), cid)
+	if credential.TicketNumber != 2 {
+		t.Errorf("Expected ticket 2, got %d", credential.TicketNumber)
+	}
+}
+
+// failingTicketRepository fails every ticket request
+type failingTicketRepository struct{}
+
+func (failingTicketRepository) TakeTicket(ctx context.Context, credentialType string) (*models.Ticket, error) {
+	return nil, stderrors.New("database is locked")
+}
+
+// TestGenerate_TicketErrors tests that issuance fails when no ticket can be taken
+func TestGenerate_TicketErrors(t *testing.T) {
+	issuerKey, _ := testIssuerKeyPEM(t)
+	policies := repository.NewMemoryCredentialPolicyRepository(
+		models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: models.TimeUnitYear},
+		models.CredentialPolicyEntity{CredentialType: "Test Credential", ExpirationDuration: 1, ExpirationTimeUnit: models.TimeUnitYear},
+	)
+
+	tests := []struct {
+		name           string
+		credentialType string
+		tickets        repository.TicketRepository
+		expectedCode   int
+		expectedStatus int
+	}{
+		{"Invalid sequence name", "Test Credential", repository.NewMemoryTicketRepository(), errors.ErrDBInvalidSequenceName, http.StatusBadRequest},
+		{"Ticket repository failure", "TestCredential", failingTicketRepository{}, errors.ErrDBInsertError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			service := NewService("did:example:issuer", issuerKey, WithPolicyRepository(policies), WithTicketRepository(tt.tickets))
+
+			// When
+			_, status, err := service.Generate(context.Background(), &models.CredentialRequestDTO{
+				IssuerDID:         "did:example:issuer",
+				CredentialType:    tt.credentialType,
+				CredentialSubject: map[string]interface{}{"name": "Test User"},
+			})
+
+			// Then
+			vcErr, ok := err.(*errors.VCError)
+			if !ok || vcErr.Code != tt.expectedCode {
+				t.Fatalf("Expected error code %d, got %v", tt.expectedCode, err)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
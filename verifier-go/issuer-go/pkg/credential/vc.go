@@ -0,0 +1,107 @@
+package credential
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/statuslist"
+)
+
+// W3C Verifiable Credentials Data Model constants
+const (
+	CredentialsContextV1     = "https://www.w3.org/2018/credentials/v1"
+	VerifiableCredentialType = "VerifiableCredential"
+	StatusListEntryType      = statuslist.EntryType
+	StatusListSize           = statuslist.Size // bits per status list (16 KB bitstring)
+	credentialJWTType        = "JWT"
+)
+
+// VCClaims represents the JWT claim set of an issued credential.
+// It mirrors the structure validated by the verifier's crypto.VCClaims.
+type VCClaims struct {
+	jwt.RegisteredClaims
+	VC VerifiableCredential `json:"vc"`
+
+	// Cnf binds the credential to the holder's public key, ex: {"jwk": {...}}
+	Cnf map[string]interface{} `json:"cnf,omitempty"`
+
+	// SDAlg is the digest algorithm of an SD-JWT credential's disclosures
+	SDAlg string `json:"_sd_alg,omitempty"`
+
+	// OpaqueIDSeedEpoch is the epoch of the credential's opaque_id_seed, so
+	// verifiers can tell a seed rotation from a new holder
+	OpaqueIDSeedEpoch int `json:"opaque_id_seed_epoch,omitempty"`
+}
+
+// VerifiableCredential represents the "vc" claim of a JWT-VC
+type VerifiableCredential struct {
+	Context           []string                `json:"@context"`
+	ID                string                  `json:"id,omitempty"`
+	Type              []string                `json:"type"`
+	Issuer            string                  `json:"issuer"`
+	IssuanceDate      string                  `json:"issuanceDate"`
+	ExpirationDate    string                  `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]interface{}  `json:"credentialSubject"`
+	CredentialStatus  []CredentialStatusEntry `json:"credentialStatus,omitempty"`
+}
+
+// CredentialStatusEntry represents a Bitstring Status List entry
+type CredentialStatusEntry = statuslist.Entry
+
+// vcParams collects everything needed to build a credential payload
+type vcParams struct {
+	credentialID      string
+	credentialType    string
+	issuerDID         string
+	subjectID         string
+	credentialSubject map[string]interface{}
+	issuanceDate      time.Time
+	expirationDate    time.Time
+	statusEntries     []CredentialStatusEntry
+	holderPublicKey   map[string]interface{}
+	seedEpoch         int
+}
+
+// buildVCClaims builds the W3C VC payload and its JWT registered claims
+// Equivalent to Java's CredentialPrepareTask.prepare()
+func buildVCClaims(p vcParams) *VCClaims {
+	subject := make(map[string]interface{}, len(p.credentialSubject)+1)
+	for k, v := range p.credentialSubject {
+		subject[k] = v
+	}
+	if p.subjectID != "" {
+		subject["id"] = p.subjectID
+	}
+
+	issuanceDate := p.issuanceDate.UTC()
+	expirationDate := p.expirationDate.UTC()
+
+	var cnf map[string]interface{}
+	if p.holderPublicKey != nil {
+		cnf = map[string]interface{}{"jwk": p.holderPublicKey}
+	}
+
+	return &VCClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        p.credentialID,
+			Issuer:    p.issuerDID,
+			Subject:   p.subjectID,
+			IssuedAt:  jwt.NewNumericDate(issuanceDate),
+			NotBefore: jwt.NewNumericDate(issuanceDate),
+			ExpiresAt: jwt.NewNumericDate(expirationDate),
+		},
+		VC: VerifiableCredential{
+			Context:           []string{CredentialsContextV1},
+			ID:                p.credentialID,
+			Type:              []string{VerifiableCredentialType, p.credentialType},
+			Issuer:            p.issuerDID,
+			IssuanceDate:      issuanceDate.Format(time.RFC3339),
+			ExpirationDate:    expirationDate.Format(time.RFC3339),
+			CredentialSubject: subject,
+			CredentialStatus:  p.statusEntries,
+		},
+		Cnf:               cnf,
+		OpaqueIDSeedEpoch: p.seedEpoch,
+	}
+}
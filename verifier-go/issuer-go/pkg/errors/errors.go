@@ -63,6 +63,8 @@ const (
 	ErrCredentialStatusUnknownError           = 61050
 	ErrCredSuspendVCError                     = 61051
 	ErrCredRecoverVCError                     = 61052
+	ErrCredSeedRotationTooFrequent            = 61053
+	ErrCredInvalidStatusTransition            = 61054
 
 	// Credential data errors (613xx)
 	ErrCredDataInvalidCredentialDataSettingRequest  = 61301
@@ -150,6 +152,16 @@ const (
 type VCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+
+	// Errors lists the invalid fields of the request, if any
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes an invalid field by its JSON pointer path,
+// ex: "/credentialSubject/birth_year"
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
 }
 
 // Error implements the error interface
@@ -173,6 +185,8 @@ func (e *VCError) HTTPStatus() int {
 		ErrCredInvalidNonce,
 		ErrCredInvalidCredentialType,
 		ErrCredInvalidCredentialSubject,
+		ErrCredInvalidExpirationDate,
+		ErrCredInvalidIssuanceDate,
 		ErrInfoInvalidCredentialType,
 		ErrInfoInvalidGroupName,
 		ErrInfoInvalidSchemaName,
@@ -188,8 +202,10 @@ func (e *VCError) HTTPStatus() int {
 		ErrSeqInvalidFunctionSwitchSettingRequest,
 		ErrCredInvalidCredentialTransferRequest,
 		ErrCredDataInvalidDataField,
+		ErrCredDataFieldsInSchemaAndVCDataNotIdentical,
 		ErrSeqInvalidIssuerMetadataDataField,
 		ErrCredInvalidDIDFormat,
+		ErrCredInvalidStatusTransition,
 		ErrCredRevokedCredCannotBeSuspendedError,
 		ErrCredRevokedCredCannotBeRecoveredError:
 		return http.StatusBadRequest
@@ -200,6 +216,9 @@ func (e *VCError) HTTPStatus() int {
 		ErrInfoPublicKeyNotFound:
 		return http.StatusNotFound
 
+	case ErrCredSeedRotationTooFrequent:
+		return http.StatusTooManyRequests
+
 	default:
 		return http.StatusInternalServerError
 	}
@@ -207,8 +226,12 @@ func (e *VCError) HTTPStatus() int {
 
 // Response returns the error as an error response
 func (e *VCError) Response() map[string]interface{} {
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"code":    e.Code,
 		"message": e.Message,
 	}
+	if len(e.Errors) > 0 {
+		response["errors"] = e.Errors
+	}
+	return response
 }
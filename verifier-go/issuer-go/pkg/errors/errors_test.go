@@ -40,9 +40,13 @@ func TestVCError_HTTPStatus(t *testing.T) {
 	}{
 		{"Bad request - invalid CID", ErrCredInvalidCredentialID, http.StatusBadRequest},
 		{"Bad request - invalid type", ErrCredInvalidCredentialType, http.StatusBadRequest},
+		{"Bad request - already revoked", ErrCredInvalidStatusTransition, http.StatusBadRequest},
+		{"Bad request - revoked cannot be recovered", ErrCredRevokedCredCannotBeRecoveredError, http.StatusBadRequest},
 		{"Not found - credential", ErrCredCredentialNotFound, http.StatusNotFound},
 		{"Not found - schema", ErrInfoSchemaNotFound, http.StatusNotFound},
+		{"Too many requests - seed rotation", ErrCredSeedRotationTooFrequent, http.StatusTooManyRequests},
 		{"Internal server error", ErrCredGenerateVCError, http.StatusInternalServerError},
+		{"Internal server error - revoke failed", ErrCredRevokeVCError, http.StatusInternalServerError},
 		{"Unknown error", Unknown, http.StatusInternalServerError},
 	}
 
@@ -73,6 +77,26 @@ func TestVCError_Response(t *testing.T) {
 	if response["message"] != message {
 		t.Errorf("Expected response message %s, got %v", message, response["message"])
 	}
+
+	if _, ok := response["errors"]; ok {
+		t.Error("Expected no errors field without field errors")
+	}
+}
+
+// TestVCError_ResponseWithFieldErrors tests that field errors are included in the response
+func TestVCError_ResponseWithFieldErrors(t *testing.T) {
+	err := NewVCError(ErrCredDataFieldsInSchemaAndVCDataNotIdentical, "credential subject does not match the vc schema")
+	err.Errors = []FieldError{{Path: "/credentialSubject/over_18", Message: "missing property"}}
+
+	response := err.Response()
+
+	fieldErrors, ok := response["errors"].([]FieldError)
+	if !ok || len(fieldErrors) != 1 || fieldErrors[0].Path != "/credentialSubject/over_18" {
+		t.Errorf("Expected field error for /credentialSubject/over_18, got %v", response["errors"])
+	}
+	if err.HTTPStatus() != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, err.HTTPStatus())
+	}
 }
 
 // TestErrorConstants tests that error constants have expected values
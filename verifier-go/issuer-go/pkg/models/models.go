@@ -11,6 +11,7 @@ type CredentialRequestDTO struct {
 	IssuanceDate         *time.Time             `json:"issuance_date,omitempty"`
 	ExpirationDate       *time.Time             `json:"expiration_date,omitempty"`
 	Nonce                string                 `json:"nonce,omitempty"`
+	HolderPublicKey      map[string]interface{} `json:"holder_public_key,omitempty"` // public JWK bound to an SD-JWT credential (cnf)
 }
 
 // CredentialResponseDTO represents the response from credential generation
@@ -34,6 +35,22 @@ type Credential struct {
 	Nonce               string    `json:"nonce"`
 }
 
+// CredentialStatusHistory represents a single credential status transition
+type CredentialStatusHistory struct {
+	CID        string    `json:"cid"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ReasonCode string    `json:"reason_code"`
+	Actor      string    `json:"actor"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// StatusChange carries the reason and actor of a revoke/suspend/recover request
+type StatusChange struct {
+	ReasonCode string `json:"reason_code,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+}
+
 // CredentialPolicyEntity represents credential policy configuration
 type CredentialPolicyEntity struct {
 	CredentialType       string `json:"credential_type"`
@@ -46,6 +63,17 @@ type CredentialPolicyEntity struct {
 	ExpirationDuration   int    `json:"expiration_duration"`
 	ExpirationTimeUnit   string `json:"expiration_time_unit"`
 	FuncSwitch           string `json:"func_switch"`
+	SelectiveDisclosure  string `json:"selective_disclosure"` // SD-JWT setting; empty issues a plain VC-JWT
+
+	// Rotation policy of opaque_id_seeds (SeedRotation*; empty is MANUAL) and
+	// validity of a seed epoch (unset: seeds do not expire)
+	SeedRotation         string `json:"seed_rotation"`
+	SeedValidityDuration int    `json:"seed_validity_duration"`
+	SeedValidityTimeUnit string `json:"seed_validity_time_unit"`
+
+	// PairwiseEnabled issues an opaque_id_seed with every credential of the
+	// type; such credentials require a credential subject ID
+	PairwiseEnabled bool `json:"pairwise_enabled"`
 }
 
 // Ticket represents a credential ticket entity
@@ -55,12 +83,57 @@ type Ticket struct {
 	LastUpdateTime time.Time `json:"last_update_time"`
 }
 
+// OpaqueIDSeed is one epoch of the opaque_id_seed of a holder for a credential
+// type. Epochs start at 1; rotating a seed retires the current epoch, which is
+// kept for audit, and creates the next one. SeedCipher is the seed encrypted
+// with the key-encryption key; the plaintext seed is never persisted.
+// HolderKey is a keyed hash of the holder UID: raw holder identifiers are
+// never stored.
+type OpaqueIDSeed struct {
+	HolderKey      string     `json:"holder_key"`
+	CredentialType string     `json:"credential_type"`
+	Epoch          int        `json:"epoch"`
+	SeedCipher     string     `json:"-"`
+	CreateTime     time.Time  `json:"create_time"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"` // nil: the epoch does not expire
+	RetiredAt      *time.Time `json:"retired_at,omitempty"`  // nil: the current epoch
+	RetireReason   string     `json:"retire_reason,omitempty"`
+	RetiredBy      string     `json:"retired_by,omitempty"`
+}
+
+// OpaqueIDSeedEpochDTO is the response of a seed rotation; the seed itself is
+// only ever delivered inside a credential
+type OpaqueIDSeedEpochDTO struct {
+	HolderUID      string     `json:"holder_uid"`
+	CredentialType string     `json:"credential_type"`
+	Epoch          int        `json:"epoch"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+}
+
+// SeedRotation represents opaque_id_seed rotation policy constants
+const (
+	// SeedRotationManual rotates seeds only on request; an expired seed blocks issuance until rotated
+	SeedRotationManual = "MANUAL"
+
+	// SeedRotationPeriodic rotates a seed at issuance once its epoch has expired
+	SeedRotationPeriodic = "PERIODIC"
+)
+
+// SeedRetireReasonExpired is the retire reason of a seed epoch rotated because it expired
+const SeedRetireReasonExpired = "EXPIRED"
+
 // StatusList represents a status list entity
 type StatusList struct {
+	CredentialType string    `json:"credential_type"`
 	GroupName      string    `json:"group_name"`
 	Content        string    `json:"content"`
 	LastUpdateTime time.Time `json:"last_update_time"`
 	StatusListType string    `json:"status_list_type"`
+
+	// EncodedList is the GZIP-compressed, multibase-encoded bitstring signed into Content
+	EncodedList string `json:"encoded_list"`
+	// Version is incremented on every update, for optimistic locking
+	Version int `json:"version"`
 }
 
 // QueryCredentialRequest represents a request to query a credential
@@ -97,6 +170,12 @@ type StatusListResponse struct {
 	ContentType string `json:"content_type"`
 }
 
+// VerifierGroupsResponse represents the signed verifier group metadata
+type VerifierGroupsResponse struct {
+	VerifierGroups string `json:"verifier_groups"`
+	ContentType    string `json:"content_type"`
+}
+
 // CredentialStatus represents credential status constants
 const (
 	CredentialStatusActive    = "ACTIVE"
@@ -104,6 +183,19 @@ const (
 	CredentialStatusSuspended = "SUSPENDED"
 )
 
+// StatusReason represents status change reason code constants
+const (
+	StatusReasonUnspecified          = "UNSPECIFIED"
+	StatusReasonKeyCompromise        = "KEY_COMPROMISE"
+	StatusReasonSuperseded           = "SUPERSEDED"
+	StatusReasonCessationOfOperation = "CESSATION_OF_OPERATION"
+	StatusReasonPrivilegeWithdrawn   = "PRIVILEGE_WITHDRAWN"
+	StatusReasonHolderRequest        = "HOLDER_REQUEST"
+)
+
+// StatusActorSystem is the actor recorded when a status change names no actor
+const StatusActorSystem = "system"
+
 // StatusListType represents status list type constants
 const (
 	StatusListTypeRevocation = "revocation"
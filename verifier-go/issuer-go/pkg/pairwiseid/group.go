@@ -0,0 +1,207 @@
+package pairwiseid
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GroupIDPrefix starts every verifier group ID. Canonical domains never
+// contain ':', so a group's pairwise_id can never collide with a domain's.
+const GroupIDPrefix = "group:"
+
+// groupNamePattern restricts the part of a group ID after GroupIDPrefix
+var groupNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+var (
+	// ErrInvalidGroup is returned for a group ID that is not "group:<name>" or a group without domains
+	ErrInvalidGroup = errors.New("pairwiseid: invalid verifier group")
+
+	// ErrDomainInGroup is returned when a domain already belongs to another verifier group
+	ErrDomainInGroup = errors.New("pairwiseid: domain already belongs to another verifier group")
+)
+
+// Group is a declared set of verifier domains sharing one pairwise_id
+// (design "Group Pseudonyms"), ex: {"group:social-media", ["forum.example.com", "example.org"]}
+type Group struct {
+	ID      string   `json:"group_id"`
+	Name    string   `json:"name,omitempty"`
+	Domains []string `json:"domains"`
+}
+
+// GroupRegistry maps canonical verifier domains to the group they belong to.
+// A domain belongs to at most one group. It is safe for concurrent use.
+type GroupRegistry struct {
+	list *List
+
+	mu      sync.RWMutex
+	groups  map[string]Group  // by group ID
+	domains map[string]string // canonical domain -> group ID
+}
+
+// NewGroupRegistry creates an empty group registry canonicalizing domains
+// with the embedded Public Suffix List
+func NewGroupRegistry() (*GroupRegistry, error) {
+	list, err := DefaultList()
+	if err != nil {
+		return nil, err
+	}
+	return list.NewGroupRegistry(), nil
+}
+
+// NewGroupRegistry creates an empty group registry canonicalizing domains with l
+func (l *List) NewGroupRegistry() *GroupRegistry {
+	return &GroupRegistry{
+		list:    l,
+		groups:  make(map[string]Group),
+		domains: make(map[string]string),
+	}
+}
+
+// Register adds a group, or replaces the members of a group with the same ID.
+// Member domains may be given as URLs or host names; they are stored in
+// canonical form, without duplicates.
+func (r *GroupRegistry) Register(group Group) (Group, error) {
+	if err := validateGroupID(group.ID); err != nil {
+		return Group{}, err
+	}
+
+	domains := make([]string, 0, len(group.Domains))
+	seen := make(map[string]bool, len(group.Domains))
+	for _, member := range group.Domains {
+		domain, err := r.list.CanonicalDomain(member)
+		if err != nil {
+			return Group{}, fmt.Errorf("%w: member %q: %v", ErrInvalidGroup, member, err)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return Group{}, fmt.Errorf("%w: %s has no domains", ErrInvalidGroup, group.ID)
+	}
+	sort.Strings(domains)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, domain := range domains {
+		if owner, ok := r.domains[domain]; ok && owner != group.ID {
+			return Group{}, fmt.Errorf("%w: %s is a member of %s", ErrDomainInGroup, domain, owner)
+		}
+	}
+
+	if previous, ok := r.groups[group.ID]; ok {
+		for _, domain := range previous.Domains {
+			delete(r.domains, domain)
+		}
+	}
+	registered := Group{ID: group.ID, Name: group.Name, Domains: domains}
+	r.groups[group.ID] = registered
+	for _, domain := range domains {
+		r.domains[domain] = group.ID
+	}
+
+	return copyGroup(registered), nil
+}
+
+// Remove removes a group; it reports whether the group existed
+func (r *GroupRegistry) Remove(groupID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, ok := r.groups[groupID]
+	if !ok {
+		return false
+	}
+	for _, domain := range group.Domains {
+		delete(r.domains, domain)
+	}
+	delete(r.groups, groupID)
+	return true
+}
+
+// Group returns a group by ID
+func (r *GroupRegistry) Group(groupID string) (Group, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[groupID]
+	return copyGroup(group), ok
+}
+
+// Groups returns every group, ordered by ID
+func (r *GroupRegistry) Groups() []Group {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make([]Group, 0, len(r.groups))
+	for _, group := range r.groups {
+		groups = append(groups, copyGroup(group))
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups
+}
+
+// GroupOf returns the group a verifier URL's canonical domain belongs to
+func (r *GroupRegistry) GroupOf(verifierURL string) (Group, bool, error) {
+	domain, err := r.list.CanonicalDomain(verifierURL)
+	if err != nil {
+		return Group{}, false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groupID, ok := r.domains[domain]
+	if !ok {
+		return Group{}, false, nil
+	}
+	return copyGroup(r.groups[groupID]), true, nil
+}
+
+// Derive returns the pairwise_id of a holder for a verifier: the group's
+// pairwise_id if the verifier's domain belongs to a group, its own otherwise
+func (r *GroupRegistry) Derive(opaqueIDSeed, verifierURL string) (string, error) {
+	domain, err := r.list.CanonicalDomain(verifierURL)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	groupID, ok := r.domains[domain]
+	r.mu.RUnlock()
+
+	if ok {
+		return DeriveForGroup(opaqueIDSeed, groupID)
+	}
+	return DeriveFromDomain(opaqueIDSeed, domain)
+}
+
+// DeriveForGroup returns the pairwise_id shared by the members of a group:
+// base64url(HMAC-SHA256(opaque_id_seed, group_id)) without padding
+func DeriveForGroup(opaqueIDSeed, groupID string) (string, error) {
+	if err := validateGroupID(groupID); err != nil {
+		return "", err
+	}
+	return derive(opaqueIDSeed, groupID)
+}
+
+// validateGroupID checks that a group ID is GroupIDPrefix followed by a lowercase name
+func validateGroupID(groupID string) error {
+	name, ok := strings.CutPrefix(groupID, GroupIDPrefix)
+	if !ok || !groupNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: group ID %q must be %s<name> with a lowercase name", ErrInvalidGroup, groupID, GroupIDPrefix)
+	}
+	return nil
+}
+
+// copyGroup copies a group so callers cannot modify the registry's domains
+func copyGroup(group Group) Group {
+	group.Domains = append([]string(nil), group.Domains...)
+	return group
+}
@@ -0,0 +1,179 @@
+package pairwiseid
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testGroup is the verifier group of the design document's group test vector
+var testGroup = Group{
+	ID:      "group:social_media_sites",
+	Name:    "Social media sites",
+	Domains: []string{"https://forum.example.com", "social.example.org", "https://www.example.org/login"},
+}
+
+func newTestGroupRegistry(t *testing.T) *GroupRegistry {
+	t.Helper()
+
+	registry, err := NewGroupRegistry()
+	if err != nil {
+		t.Fatalf("NewGroupRegistry failed: %v", err)
+	}
+	if _, err := registry.Register(testGroup); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	return registry
+}
+
+func TestDeriveForGroup_TestVector(t *testing.T) {
+	pairwiseID, err := DeriveForGroup(testSeed, "group:social_media_sites")
+	if err != nil {
+		t.Fatalf("DeriveForGroup failed: %v", err)
+	}
+	if pairwiseID != "aNnwGzh-YON3bA3vUu6L9J9080c_Q4pXtSmAnzLr9ZU" {
+		t.Errorf("Expected aNnwGzh-YON3bA3vUu6L9J9080c_Q4pXtSmAnzLr9ZU, got %s", pairwiseID)
+	}
+}
+
+func TestDeriveForGroup_InvalidGroupID(t *testing.T) {
+	for _, groupID := range []string{"", "group:", "social_media_sites", "group:Social", "group:a b", "example.com"} {
+		if _, err := DeriveForGroup(testSeed, groupID); !errors.Is(err, ErrInvalidGroup) {
+			t.Errorf("Expected ErrInvalidGroup for %q, got %v", groupID, err)
+		}
+	}
+}
+
+func TestGroupRegistry_Register(t *testing.T) {
+	registry := newTestGroupRegistry(t)
+
+	t.Run("Canonical members", func(t *testing.T) {
+		group, ok := registry.Group("group:social_media_sites")
+		if !ok {
+			t.Fatal("Expected the group to be registered")
+		}
+		if strings.Join(group.Domains, ",") != "example.com,example.org" {
+			t.Errorf("Expected example.com,example.org, got %v", group.Domains)
+		}
+	})
+
+	t.Run("Domain in another group", func(t *testing.T) {
+		_, err := registry.Register(Group{ID: "group:news", Domains: []string{"news.example.com"}})
+		if !errors.Is(err, ErrDomainInGroup) {
+			t.Errorf("Expected ErrDomainInGroup, got %v", err)
+		}
+	})
+
+	t.Run("Invalid groups", func(t *testing.T) {
+		for _, group := range []Group{
+			{ID: "news", Domains: []string{"news.example.net"}},
+			{ID: "group:news"},
+			{ID: "group:news", Domains: []string{"https://192.168.1.10"}},
+		} {
+			if _, err := registry.Register(group); !errors.Is(err, ErrInvalidGroup) {
+				t.Errorf("Expected ErrInvalidGroup for %+v, got %v", group, err)
+			}
+		}
+	})
+
+	t.Run("Replace members", func(t *testing.T) {
+		// Given
+		_, err := registry.Register(Group{ID: "group:social_media_sites", Domains: []string{"example.org"}})
+		if err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		// When
+		_, ok, _ := registry.GroupOf("https://forum.example.com")
+
+		// Then - the removed member is free to join another group
+		if ok {
+			t.Error("Expected example.com to have left the group")
+		}
+		if _, err := registry.Register(Group{ID: "group:news", Domains: []string{"example.com"}}); err != nil {
+			t.Errorf("Register failed: %v", err)
+		}
+	})
+}
+
+func TestGroupRegistry_Derive(t *testing.T) {
+	registry := newTestGroupRegistry(t)
+
+	// Members share the group's pairwise_id
+	for _, verifier := range []string{"https://forum.example.com", "https://api.social.example.org/callback"} {
+		pairwiseID, err := registry.Derive(testSeed, verifier)
+		if err != nil || pairwiseID != "aNnwGzh-YON3bA3vUu6L9J9080c_Q4pXtSmAnzLr9ZU" {
+			t.Errorf("Expected the group pairwise_id for %s, got %s (%v)", verifier, pairwiseID, err)
+		}
+	}
+
+	// Other verifiers keep their own
+	pairwiseID, err := registry.Derive(testSeed, "https://shop.example.co.uk")
+	if err != nil || pairwiseID != "I95Ntn2GJ_FbuA_JQOjF1IV9Qw_mcmqhGTUqW9VkgWo" {
+		t.Errorf("Expected the domain pairwise_id, got %s (%v)", pairwiseID, err)
+	}
+
+	if !registry.Remove("group:social_media_sites") || registry.Remove("group:social_media_sites") {
+		t.Error("Expected the group to be removed once")
+	}
+	pairwiseID, _ = registry.Derive(testSeed, "https://forum.example.com")
+	if pairwiseID != "Eb_qEC0NFX0U-Q_Ss2Zkh4bRVMVfhj1kMZSiKs4hViw" {
+		t.Errorf("Expected the domain pairwise_id after removal, got %s", pairwiseID)
+	}
+}
+
+func TestGroupMetadata(t *testing.T) {
+	registry := newTestGroupRegistry(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	t.Run("Round trip", func(t *testing.T) {
+		// Given
+		metadata, err := registry.SignMetadata("did:example:issuer", key, "did:example:issuer#key-1", time.Hour)
+		if err != nil {
+			t.Fatalf("SignMetadata failed: %v", err)
+		}
+
+		// When
+		parsed, claims, err := ParseGroupMetadata(metadata, &key.PublicKey)
+
+		// Then
+		if err != nil {
+			t.Fatalf("ParseGroupMetadata failed: %v", err)
+		}
+		if claims.Issuer != "did:example:issuer" || len(claims.Groups) != 1 {
+			t.Errorf("Unexpected claims: %+v", claims)
+		}
+		group, ok, err := parsed.GroupOf("forum.example.com")
+		if err != nil || !ok || group.ID != "group:social_media_sites" {
+			t.Errorf("Expected forum.example.com in group:social_media_sites, got %+v (%v)", group, err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		valid, _ := registry.SignMetadata("did:example:issuer", key, "", time.Hour)
+		expired, _ := registry.SignMetadata("did:example:issuer", key, "", -time.Minute)
+
+		tests := []struct {
+			name     string
+			metadata string
+			key      *ecdsa.PublicKey
+		}{
+			{"Other key", valid, &otherKey.PublicKey},
+			{"Expired", expired, &key.PublicKey},
+			{"Tampered", valid[:len(valid)-4] + "AAAA", &key.PublicKey},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, _, err := ParseGroupMetadata(tt.metadata, tt.key); !errors.Is(err, ErrInvalidGroupMetadata) {
+					t.Errorf("Expected ErrInvalidGroupMetadata, got %v", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package pairwiseid
+
+import (
+	stdcrypto "crypto"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/internal/crypto"
+)
+
+// GroupMetadataType is the typ header of signed verifier group metadata
+const GroupMetadataType = "verifier-groups+jwt"
+
+// groupMetadataAlgorithms are the JWS algorithms accepted for group metadata
+var groupMetadataAlgorithms = []string{"ES256", "ES384", "ES512", "EdDSA", "RS256"}
+
+// ErrInvalidGroupMetadata is returned when group metadata is not a valid signed verifier group list
+var ErrInvalidGroupMetadata = errors.New("pairwiseid: invalid verifier group metadata")
+
+// GroupMetadataClaims is the JWT claim set of signed verifier group metadata
+type GroupMetadataClaims struct {
+	jwt.RegisteredClaims
+	Groups []Group `json:"verifier_groups"`
+}
+
+// SignMetadata publishes the registry's groups as a JWT signed by issuer,
+// valid for validity. Wallets and verifiers check it with ParseGroupMetadata
+// before trusting a group's membership.
+func (r *GroupRegistry) SignMetadata(issuer string, key stdcrypto.Signer, kid string, validity time.Duration) (string, error) {
+	now := time.Now()
+	claims := &GroupMetadataClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(validity)),
+		},
+		Groups: r.Groups(),
+	}
+
+	return crypto.SignJWT(claims, key, kid, GroupMetadataType)
+}
+
+// ParseGroupMetadata verifies signed group metadata with the publisher's
+// public key and returns its groups in a registry canonicalizing domains with
+// the embedded Public Suffix List. Expired metadata, metadata without an
+// expiry and groups that would not register are rejected.
+func ParseGroupMetadata(metadata string, key stdcrypto.PublicKey) (*GroupRegistry, *GroupMetadataClaims, error) {
+	claims := &GroupMetadataClaims{}
+	token, err := jwt.ParseWithClaims(metadata, claims, func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods(groupMetadataAlgorithms), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGroupMetadata, err)
+	}
+	if typ, _ := token.Header["typ"].(string); typ != GroupMetadataType {
+		return nil, nil, fmt.Errorf("%w: unexpected typ %q", ErrInvalidGroupMetadata, typ)
+	}
+
+	registry, err := NewGroupRegistry()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, group := range claims.Groups {
+		if _, err := registry.Register(group); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGroupMetadata, err)
+		}
+	}
+
+	return registry, claims, nil
+}
@@ -0,0 +1,108 @@
+package pairwiseid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// seedSize is the size of an opaque_id_seed (256 bits)
+const seedSize = 32
+
+var (
+	// ErrInvalidSeed is returned when an opaque_id_seed is not a base64url encoded 256-bit value
+	ErrInvalidSeed = errors.New("pairwiseid: invalid opaque_id_seed")
+
+	// ErrInvalidDomain is returned when a verifier URL has no usable DNS host name
+	ErrInvalidDomain = errors.New("pairwiseid: invalid verifier domain")
+
+	// ErrPublicSuffix is returned when a verifier host is itself a public suffix (e.g. "com", "github.io")
+	ErrPublicSuffix = errors.New("pairwiseid: verifier domain is a public suffix")
+)
+
+// Derive returns the pairwise_id of a holder for a verifier:
+// base64url(HMAC-SHA256(opaque_id_seed, canonical_domain)) without padding.
+// verifierURL may be a URL or a bare host name; it is canonicalized with the
+// embedded Public Suffix List.
+func Derive(opaqueIDSeed, verifierURL string) (string, error) {
+	list, err := DefaultList()
+	if err != nil {
+		return "", err
+	}
+	domain, err := list.CanonicalDomain(verifierURL)
+	if err != nil {
+		return "", err
+	}
+	return DeriveFromDomain(opaqueIDSeed, domain)
+}
+
+// DeriveFromDomain returns the pairwise_id for an already canonical domain
+func DeriveFromDomain(opaqueIDSeed, canonicalDomain string) (string, error) {
+	return derive(opaqueIDSeed, canonicalDomain)
+}
+
+// derive returns base64url(HMAC-SHA256(opaque_id_seed, input)) without padding
+func derive(opaqueIDSeed, input string) (string, error) {
+	seed, err := ParseSeed(opaqueIDSeed)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte(input))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ParseSeed decodes an opaque_id_seed (base64url, with or without padding, of 32 bytes)
+func ParseSeed(opaqueIDSeed string) ([]byte, error) {
+	seed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(opaqueIDSeed, "="))
+	if err != nil || len(seed) != seedSize {
+		return nil, ErrInvalidSeed
+	}
+	return seed, nil
+}
+
+// CanonicalDomain canonicalizes a verifier URL with the embedded Public Suffix List
+func CanonicalDomain(verifierURL string) (string, error) {
+	list, err := DefaultList()
+	if err != nil {
+		return "", err
+	}
+	return list.CanonicalDomain(verifierURL)
+}
+
+// CanonicalDomain canonicalizes a verifier URL (design §2.2):
+//  1. extract the host name (a bare host, optionally with a port, is accepted)
+//  2. lowercase it and convert IDN labels to punycode A-labels
+//  3. remove a "www." prefix
+//  4. reduce it to eTLD+1
+func (l *List) CanonicalDomain(verifierURL string) (string, error) {
+	raw := strings.TrimSpace(verifierURL)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDomain, err)
+	}
+
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDomain, verifierURL)
+	}
+
+	host, err = idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDomain, err)
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+
+	return l.EffectiveTLDPlusOne(host)
+}
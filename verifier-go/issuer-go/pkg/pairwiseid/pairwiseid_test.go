@@ -0,0 +1,115 @@
+package pairwiseid
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// testSeed is the opaque_id_seed of the design document's test vectors
+// (hex a3d7f9c8b2e1a4f6d8c9b7e2a5f8d3c1b4e7a9f2d6c8b3e5a7f9d2c4b6e8a1f3)
+const testSeed = "o9f5yLLhpPbYybfipfjTwbTnqfLWyLPlp_nSxLboofM"
+
+func TestDerive_TestVectors(t *testing.T) {
+	// Test Vectors 1-5 of the PAIRWISE-PSEUDONYM-DESIGN.md spec change on the
+	// spec/pairwise-test-vectors branch. The vectors in this tree are
+	// placeholders that no HMAC-SHA256 produces.
+	tests := []struct {
+		name     string
+		verifier string
+		domain   string
+		expected string
+	}{
+		{"Basic derivation", "forum.example.com", "example.com", "Eb_qEC0NFX0U-Q_Ss2Zkh4bRVMVfhj1kMZSiKs4hViw"},
+		{"Different domains", "social.example.org", "example.org", "3uIpYxdDLjQ9j8AQKdX7uGC0gvt0BrdNqcWCJyoVJ3s"},
+		{"Same registrable domain", "https://api.forum.example.com/callback", "example.com", "Eb_qEC0NFX0U-Q_Ss2Zkh4bRVMVfhj1kMZSiKs4hViw"},
+		{"Multi-label public suffix", "https://shop.example.co.uk", "example.co.uk", "I95Ntn2GJ_FbuA_JQOjF1IV9Qw_mcmqhGTUqW9VkgWo"},
+		{"IDN", "https://www.Bücher.de/login", "xn--bcher-kva.de", "hFTMbXnzMNaLhUE-iYuQTDdKE-bL7aIAsDHpVaU-8S4"},
+		{"IDN top-level domain", "https://論壇.台灣", "xn--ypsv87i.xn--kpry57d", "C8u-Hf_GP1XXqloS33oVppVN8NcTecmcYE0FDljXg5g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain, err := CanonicalDomain(tt.verifier)
+			if err != nil || domain != tt.domain {
+				t.Fatalf("Expected canonical domain %s, got %s (%v)", tt.domain, domain, err)
+			}
+
+			pairwiseID, err := Derive(testSeed, tt.verifier)
+			if err != nil {
+				t.Fatalf("Derive failed: %v", err)
+			}
+			if pairwiseID != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, pairwiseID)
+			}
+		})
+	}
+}
+
+func TestDerive_PaddedSeed(t *testing.T) {
+	// Given - the seed as a Dart wallet encodes it (base64Url.encode pads)
+	padded := testSeed + "="
+
+	// When
+	pairwiseID, err := Derive(padded, "forum.example.com")
+
+	// Then
+	if err != nil || pairwiseID != "Eb_qEC0NFX0U-Q_Ss2Zkh4bRVMVfhj1kMZSiKs4hViw" {
+		t.Errorf("Expected the unpadded seed's pairwise_id, got %s (%v)", pairwiseID, err)
+	}
+}
+
+func TestDerive_InvalidSeed(t *testing.T) {
+	seeds := []string{
+		"",
+		"a3d7f9c8b2e1a4f6d8c9b7e2a5f8d3c1b4e7a9f2d6c8b3e5a7f9d2c4b6e8a1f3",
+		base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+		base64.StdEncoding.EncodeToString([]byte{0xfb, 0xff}),
+	}
+
+	for _, seed := range seeds {
+		if _, err := Derive(seed, "example.com"); !errors.Is(err, ErrInvalidSeed) {
+			t.Errorf("Expected ErrInvalidSeed for %q, got %v", seed, err)
+		}
+	}
+}
+
+func TestCanonicalDomain(t *testing.T) {
+	tests := []struct {
+		verifier string
+		expected string
+		err      error
+	}{
+		{"https://FORUM.Example.Com/callback", "example.com", nil},
+		{"https://www.forum.example.com", "example.com", nil},
+		{"https://api.forum.example.com:8443/oidvp", "example.com", nil},
+		{"forum.example.com:443", "example.com", nil},
+		{"https://example.com./", "example.com", nil},
+		{"https://www.example.com", "example.com", nil},
+		{"https://verifier.moda.gov.tw", "moda.gov.tw", nil},
+		{"https://xn--bcher-kva.de", "xn--bcher-kva.de", nil},
+		{"https://alice.github.io/app", "alice.github.io", nil},
+		{"https://a.b.foo.kawasaki.jp", "b.foo.kawasaki.jp", nil},
+		{"https://a.city.kawasaki.jp", "city.kawasaki.jp", nil},
+		{"https://github.io", "", ErrPublicSuffix},
+		{"https://www.co.uk", "", ErrPublicSuffix},
+		{"com", "", ErrPublicSuffix},
+		{"", "", ErrInvalidDomain},
+		{"https://192.168.1.10/callback", "", ErrInvalidDomain},
+		{"https://[::1]:8080", "", ErrInvalidDomain},
+		{"https://exa mple.com", "", ErrInvalidDomain},
+		{"https://bad_host.example.com", "", ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.verifier, func(t *testing.T) {
+			domain, err := CanonicalDomain(tt.verifier)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if domain != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, domain)
+			}
+		})
+	}
+}
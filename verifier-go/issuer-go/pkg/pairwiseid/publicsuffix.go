@@ -0,0 +1,161 @@
+package pairwiseid
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+)
+
+//go:generate curl -sSfL -o public_suffix_list.dat https://publicsuffix.org/list/public_suffix_list.dat
+
+// embeddedList is the Public Suffix List shipped with the package. Run
+// `go generate` in this directory to refresh it, or load a newer copy at
+// runtime with LoadList.
+//
+//go:embed public_suffix_list.dat
+var embeddedList string
+
+// Rule kinds of the Public Suffix List (https://publicsuffix.org/list/)
+const (
+	ruleNormal    = 1 << iota // example.com
+	ruleWildcard              // *.example.com
+	ruleException             // !www.example.com
+)
+
+// privateMarker starts the PRIVATE DOMAINS section of the list
+const privateMarker = "===BEGIN PRIVATE DOMAINS==="
+
+// rule is the set of rule kinds registered for one suffix
+type rule struct {
+	kinds   int
+	private bool
+}
+
+// List is a parsed Public Suffix List. Rules are stored as A-labels
+// (punycode), so lookups must use ASCII domains.
+type List struct {
+	rules map[string]rule
+}
+
+var (
+	defaultList     *List
+	defaultListErr  error
+	defaultListOnce sync.Once
+)
+
+// DefaultList returns the embedded Public Suffix List
+func DefaultList() (*List, error) {
+	defaultListOnce.Do(func() {
+		defaultList, defaultListErr = ParseList(strings.NewReader(embeddedList))
+	})
+	return defaultList, defaultListErr
+}
+
+// LoadList reads a Public Suffix List file, e.g. a copy newer than the embedded one
+func LoadList(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open public suffix list: %w", err)
+	}
+	defer f.Close()
+
+	return ParseList(f)
+}
+
+// ParseList parses a Public Suffix List in the publicsuffix.org format,
+// including both the ICANN and the PRIVATE DOMAINS sections
+func ParseList(r io.Reader) (*List, error) {
+	list := &List{rules: make(map[string]rule)}
+	private := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "//") {
+			if strings.Contains(line, privateMarker) {
+				private = true
+			}
+			continue
+		}
+		// A rule is the first whitespace-delimited token of a line
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, kind := fields[0], ruleNormal
+		switch {
+		case strings.HasPrefix(name, "*."):
+			name, kind = name[2:], ruleWildcard
+		case strings.HasPrefix(name, "!"):
+			name, kind = name[1:], ruleException
+		}
+
+		ascii, err := idna.ToASCII(name)
+		if err != nil || ascii == "" {
+			return nil, fmt.Errorf("invalid public suffix rule %q: %v", fields[0], err)
+		}
+		ascii = strings.ToLower(ascii)
+
+		existing := list.rules[ascii]
+		list.rules[ascii] = rule{kinds: existing.kinds | kind, private: existing.private || private}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read public suffix list: %w", err)
+	}
+	if len(list.rules) == 0 {
+		return nil, fmt.Errorf("public suffix list has no rules")
+	}
+
+	return list, nil
+}
+
+// PublicSuffix returns the public suffix of an ASCII, lowercase domain, and
+// whether it comes from the ICANN section of the list. Domains matching no
+// rule fall back to the implicit "*" rule (their last label).
+func (l *List) PublicSuffix(domain string) (suffix string, icann bool) {
+	labels := strings.Split(domain, ".")
+
+	// Exception rules take priority: the suffix is the rule minus its first label
+	for i := range labels {
+		if r, ok := l.rules[strings.Join(labels[i:], ".")]; ok && r.kinds&ruleException != 0 {
+			return strings.Join(labels[i+1:], "."), !r.private
+		}
+	}
+
+	// Otherwise the matching rule with the most labels wins
+	for i := range labels {
+		if r, ok := l.rules[strings.Join(labels[i:], ".")]; ok && r.kinds&ruleNormal != 0 {
+			return strings.Join(labels[i:], "."), !r.private
+		}
+		if i+1 < len(labels) {
+			if r, ok := l.rules[strings.Join(labels[i+1:], ".")]; ok && r.kinds&ruleWildcard != 0 {
+				return strings.Join(labels[i:], "."), !r.private
+			}
+		}
+	}
+
+	return labels[len(labels)-1], false
+}
+
+// EffectiveTLDPlusOne returns the registrable domain (eTLD+1) of an ASCII,
+// lowercase domain
+func (l *List) EffectiveTLDPlusOne(domain string) (string, error) {
+	if domain == "" || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+	}
+
+	suffix, _ := l.PublicSuffix(domain)
+	if len(domain) <= len(suffix) {
+		return "", fmt.Errorf("%w: %q", ErrPublicSuffix, domain)
+	}
+
+	rest := domain[:len(domain)-len(suffix)-1]
+	return rest[strings.LastIndex(rest, ".")+1:] + "." + suffix, nil
+}
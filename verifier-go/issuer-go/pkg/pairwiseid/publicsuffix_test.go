@@ -0,0 +1,133 @@
+package pairwiseid
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testList = `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+*.ck
+!www.ck
+
+// 公司.香港
+香港
+公司.香港
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+github.io	trailing text is ignored
+// ===END PRIVATE DOMAINS===
+`
+
+func TestParseList(t *testing.T) {
+	list, err := ParseList(strings.NewReader(testList))
+	if err != nil {
+		t.Fatalf("ParseList failed: %v", err)
+	}
+
+	tests := []struct {
+		domain string
+		suffix string
+		icann  bool
+	}{
+		{"example.com", "com", true},
+		{"a.b.example.co.uk", "co.uk", true},
+		{"example.uk", "uk", true},
+		{"a.example.ck", "example.ck", true},
+		{"www.ck", "ck", true},
+		{"a.www.ck", "ck", true},
+		{"shop.xn--55qx5d.xn--j6w193g", "xn--55qx5d.xn--j6w193g", true},
+		{"alice.github.io", "github.io", false},
+		{"example.unknown", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			suffix, icann := list.PublicSuffix(tt.domain)
+			if suffix != tt.suffix || icann != tt.icann {
+				t.Errorf("Expected %s (icann %v), got %s (icann %v)", tt.suffix, tt.icann, suffix, icann)
+			}
+		})
+	}
+}
+
+func TestParseList_Empty(t *testing.T) {
+	if _, err := ParseList(strings.NewReader("// comments only\n\n")); err == nil {
+		t.Error("Expected an error for a list without rules")
+	}
+}
+
+func TestEffectiveTLDPlusOne(t *testing.T) {
+	list, err := ParseList(strings.NewReader(testList))
+	if err != nil {
+		t.Fatalf("ParseList failed: %v", err)
+	}
+
+	tests := []struct {
+		domain   string
+		expected string
+		err      error
+	}{
+		{"a.b.example.co.uk", "example.co.uk", nil},
+		{"a.example.ck", "a.example.ck", nil},
+		{"a.www.ck", "www.ck", nil},
+		{"example.ck", "", ErrPublicSuffix},
+		{"co.uk", "", ErrPublicSuffix},
+		{"", "", ErrInvalidDomain},
+		{"a..example.com", "", ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			domain, err := list.EffectiveTLDPlusOne(tt.domain)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if domain != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, domain)
+			}
+		})
+	}
+}
+
+func TestLoadList(t *testing.T) {
+	// Given - an updated list that adds a new public suffix
+	path := filepath.Join(t.TempDir(), "public_suffix_list.dat")
+	if err := os.WriteFile(path, []byte(testList+"example.com\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write list: %v", err)
+	}
+
+	// When
+	list, err := LoadList(path)
+	if err != nil {
+		t.Fatalf("LoadList failed: %v", err)
+	}
+
+	// Then - subdomains of the new suffix are separate registrable domains
+	if domain, err := list.CanonicalDomain("https://forum.example.com"); err != nil || domain != "forum.example.com" {
+		t.Errorf("Expected forum.example.com, got %s (%v)", domain, err)
+	}
+
+	if _, err := LoadList(filepath.Join(t.TempDir(), "missing.dat")); err == nil {
+		t.Error("Expected an error for a missing list")
+	}
+}
+
+func TestDefaultList(t *testing.T) {
+	list, err := DefaultList()
+	if err != nil {
+		t.Fatalf("DefaultList failed: %v", err)
+	}
+
+	if suffix, icann := list.PublicSuffix("moda.gov.tw"); suffix != "gov.tw" || !icann {
+		t.Errorf("Expected gov.tw (icann), got %s (icann %v)", suffix, icann)
+	}
+	if suffix, icann := list.PublicSuffix("alice.github.io"); suffix != "github.io" || icann {
+		t.Errorf("Expected github.io (private), got %s (icann %v)", suffix, icann)
+	}
+}
@@ -0,0 +1,324 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/sdjwt"
+)
+
+// OpaqueIDSeedClaim is the credential subject claim holding the pairwise seed
+const OpaqueIDSeedClaim = "opaque_id_seed"
+
+// maxDurationYears bounds any policy duration
+// Equivalent to Java's CredentialService.checkValidityPeriod()
+const maxDurationYears = 1000
+
+// Duration is a policy duration in calendar units
+// Equivalent to Java's Policy.Duration
+type Duration struct {
+	Value int
+	Unit  string
+}
+
+// SeedRotation is the opaque_id_seed rotation policy of a credential type
+type SeedRotation struct {
+	// Periodic rotates an expired seed at the next issuance; otherwise an
+	// expired seed must be rotated explicitly before issuing again
+	Periodic bool
+
+	// Validity is the lifetime of a seed epoch (zero value: no expiry)
+	Validity Duration
+}
+
+// ValidUntil returns the end of validity of a seed epoch created at now (nil if seeds never expire)
+func (r SeedRotation) ValidUntil(now time.Time) *time.Time {
+	if r.Validity.Value == 0 {
+		return nil
+	}
+	validUntil := Add(now, r.Validity)
+	return &validUntil
+}
+
+// Policy is a validated credential policy
+// Equivalent to Java's Policy
+type Policy struct {
+	CredentialType string
+
+	// IssuanceWindow bounds how far in the past a caller-supplied issuance
+	// date may lie (zero value: no lower bound)
+	IssuanceWindow Duration
+
+	// Validity is the effective duration of an issued credential; it sets the
+	// default expiration date and bounds a caller-supplied one
+	Validity Duration
+
+	// Schema validates credential subjects (nil if the policy has no VC schema)
+	Schema *Schema
+
+	// SelectiveDisclosure sets which claims of an SD-JWT credential are
+	// always disclosed (nil: credentials are issued as plain VC-JWTs)
+	SelectiveDisclosure *sdjwt.Config
+
+	// SeedRotation sets how long an opaque_id_seed epoch is valid and how it
+	// is rotated (zero value: seeds never expire and are rotated manually)
+	SeedRotation SeedRotation
+
+	// Pairwise issues an opaque_id_seed with every credential; such
+	// credentials require a credential subject ID to key the seed to
+	Pairwise bool
+
+	// Entity is the policy configuration this policy was built from
+	Entity *models.CredentialPolicyEntity
+}
+
+// New validates a policy entity and builds its Policy
+func New(entity *models.CredentialPolicyEntity) (*Policy, *errors.VCError) {
+	if entity == nil || entity.CredentialType == "" {
+		return nil, errors.NewVCError(errors.ErrCredInvalidCredentialType, "invalid credential type")
+	}
+
+	validity, vcErr := newDuration(entity.ExpirationDuration, entity.ExpirationTimeUnit, true)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+
+	issuanceWindow, vcErr := newDuration(entity.IssuanceDateDuration, entity.IssuanceDateTimeUnit, false)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+
+	var schema *Schema
+	if strings.TrimSpace(entity.VCSchema) != "" {
+		if schema, vcErr = CompileSchema(entity.VCSchema); vcErr != nil {
+			return nil, vcErr
+		}
+	}
+
+	var selectiveDisclosure *sdjwt.Config
+	if strings.TrimSpace(entity.SelectiveDisclosure) != "" {
+		if selectiveDisclosure, vcErr = newSelectiveDisclosure(entity.SelectiveDisclosure); vcErr != nil {
+			return nil, vcErr
+		}
+	}
+
+	seedRotation, vcErr := newSeedRotation(entity.SeedRotation, entity.SeedValidityDuration, entity.SeedValidityTimeUnit)
+	if vcErr != nil {
+		return nil, vcErr
+	}
+
+	return &Policy{
+		CredentialType:      entity.CredentialType,
+		IssuanceWindow:      issuanceWindow,
+		Validity:            validity,
+		Schema:              schema,
+		SelectiveDisclosure: selectiveDisclosure,
+		SeedRotation:        seedRotation,
+		Pairwise:            entity.PairwiseEnabled,
+		Entity:              entity,
+	}, nil
+}
+
+// newDuration validates a duration setting. An optional duration may be left unset (0).
+func newDuration(value int, unit string, required bool) (Duration, *errors.VCError) {
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+
+	if !required && value == 0 && unit == "" {
+		return Duration{}, nil
+	}
+
+	if !IsValidTimeUnit(unit) {
+		return Duration{}, errors.NewVCError(errors.ErrSysInvalidTimeUnit, fmt.Sprintf("invalid time unit: %q", unit))
+	}
+
+	if value <= 0 || !withinMaxDuration(value, unit) {
+		return Duration{}, errors.NewVCError(errors.ErrSysInvalidTimeDuration, fmt.Sprintf("invalid time duration: %d %s", value, unit))
+	}
+
+	return Duration{Value: value, Unit: unit}, nil
+}
+
+// newSeedRotation validates a seed rotation setting. A periodic rotation
+// needs a seed validity; a manual one may leave it unset.
+func newSeedRotation(rotation string, validityDuration int, validityTimeUnit string) (SeedRotation, *errors.VCError) {
+	rotation = strings.ToUpper(strings.TrimSpace(rotation))
+
+	var periodic bool
+	switch rotation {
+	case "", models.SeedRotationManual:
+	case models.SeedRotationPeriodic:
+		periodic = true
+	default:
+		return SeedRotation{}, errors.NewVCError(errors.ErrSysCheckSettingError, fmt.Sprintf("invalid seed rotation: %q", rotation))
+	}
+
+	validity, vcErr := newDuration(validityDuration, validityTimeUnit, periodic)
+	if vcErr != nil {
+		return SeedRotation{}, vcErr
+	}
+
+	return SeedRotation{Periodic: periodic, Validity: validity}, nil
+}
+
+// newSelectiveDisclosure parses a selective disclosure setting. The
+// opaque_id_seed can never be always disclosed: verifiers must only learn
+// the pairwise identifiers derived from it.
+func newSelectiveDisclosure(setting string) (*sdjwt.Config, *errors.VCError) {
+	config, err := sdjwt.ParseConfig(setting)
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrCredPrepareVCError, err.Error())
+	}
+	if config.AlwaysDisclosed(OpaqueIDSeedClaim) {
+		return nil, errors.NewVCError(
+			errors.ErrCredPrepareVCError,
+			fmt.Sprintf("invalid selective disclosure setting: %s must be selectively disclosable", OpaqueIDSeedClaim),
+		)
+	}
+	return config, nil
+}
+
+// IsValidTimeUnit reports whether unit is one of the models.TimeUnit* constants (case-insensitive)
+// Equivalent to Java's CredentialService.isValidTimeUnit()
+func IsValidTimeUnit(unit string) bool {
+	switch strings.ToUpper(unit) {
+	case models.TimeUnitSecond, models.TimeUnitMinute, models.TimeUnitHour,
+		models.TimeUnitDay, models.TimeUnitMonth, models.TimeUnitYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// withinMaxDuration reports whether a duration is shorter than maxDurationYears
+func withinMaxDuration(value int, unit string) bool {
+	var years int
+	switch unit {
+	case models.TimeUnitYear:
+		years = value
+	case models.TimeUnitMonth:
+		years = value / 12
+	case models.TimeUnitDay:
+		years = value / 365
+	case models.TimeUnitHour:
+		years = value / (365 * 24)
+	case models.TimeUnitMinute:
+		years = value / (365 * 24 * 60)
+	case models.TimeUnitSecond:
+		years = value / (365 * 24 * 60 * 60)
+	}
+	return years < maxDurationYears
+}
+
+// Add adds a duration to t. MONTH and YEAR use calendar arithmetic and clamp
+// to the last day of the resulting month, ex: Jan 31 + 1 MONTH = Feb 28 (or 29).
+// Equivalent to Java's DateUtils.calculate()
+func Add(t time.Time, d Duration) time.Time {
+	switch d.Unit {
+	case models.TimeUnitYear:
+		return addMonths(t, 12*d.Value)
+	case models.TimeUnitMonth:
+		return addMonths(t, d.Value)
+	case models.TimeUnitDay:
+		return t.AddDate(0, 0, d.Value)
+	case models.TimeUnitHour:
+		return addSeconds(t, int64(d.Value)*60*60)
+	case models.TimeUnitMinute:
+		return addSeconds(t, int64(d.Value)*60)
+	case models.TimeUnitSecond:
+		return addSeconds(t, int64(d.Value))
+	default:
+		return t
+	}
+}
+
+// addSeconds adds seconds to t in whole days first, since a time.Duration
+// overflows past ~292 years
+func addSeconds(t time.Time, seconds int64) time.Time {
+	const secondsPerDay = 24 * 60 * 60
+	return t.AddDate(0, 0, int(seconds/secondsPerDay)).Add(time.Duration(seconds%secondsPerDay) * time.Second)
+}
+
+// Subtract subtracts a duration from t, with the same calendar rules as Add
+func Subtract(t time.Time, d Duration) time.Time {
+	return Add(t, Duration{Value: -d.Value, Unit: d.Unit})
+}
+
+// addMonths adds months to t without overflowing into the following month
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	// Day 0 of the next month is the last day of this one
+	lastDay := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// Dates computes the issuance and expiration dates of a credential issued at
+// now. Caller-supplied dates (nil if absent) are validated against the policy:
+//   - the issuance date cannot be later than now, nor earlier than the issuance window allows
+//   - the expiration date cannot be earlier than the issuance date or now,
+//     nor later than the issuance date plus the policy validity
+//
+// Equivalent to Java's CredentialService.generate() step 3.2
+func (p *Policy) Dates(now time.Time, issuanceDate, expirationDate *time.Time) (time.Time, time.Time, *errors.VCError) {
+	issued := now
+	if issuanceDate != nil {
+		issued = *issuanceDate
+
+		if issued.After(now) {
+			return time.Time{}, time.Time{}, errors.NewVCError(
+				errors.ErrCredInvalidIssuanceDate,
+				"IssuanceDate is not valid: The issuance date cannot be later than the current time.",
+			)
+		}
+		if p.IssuanceWindow.Value > 0 && issued.Before(Subtract(now, p.IssuanceWindow)) {
+			return time.Time{}, time.Time{}, errors.NewVCError(
+				errors.ErrCredInvalidIssuanceDate,
+				fmt.Sprintf("IssuanceDate is not valid: The issuance date cannot be earlier than %d %s ago.", p.IssuanceWindow.Value, p.IssuanceWindow.Unit),
+			)
+		}
+	}
+
+	latestExpiration := Add(issued, p.Validity)
+	if expirationDate == nil {
+		return issued, latestExpiration, nil
+	}
+
+	expires := *expirationDate
+	if expires.Before(issued) {
+		return time.Time{}, time.Time{}, errors.NewVCError(
+			errors.ErrCredInvalidExpirationDate,
+			"ExpirationDate is not valid: The expiration date cannot be earlier than the issuance date.",
+		)
+	}
+	if expires.Before(now) {
+		return time.Time{}, time.Time{}, errors.NewVCError(
+			errors.ErrCredInvalidExpirationDate,
+			"ExpirationDate is not valid: The expiration date cannot be earlier than the current time.",
+		)
+	}
+	if expires.After(latestExpiration) {
+		return time.Time{}, time.Time{}, errors.NewVCError(
+			errors.ErrCredInvalidExpirationDate,
+			fmt.Sprintf("ExpirationDate is not valid: The expiration date cannot be later than %d %s after the issuance date.", p.Validity.Value, p.Validity.Unit),
+		)
+	}
+
+	return issued, expires, nil
+}
+
+// ValidateSubject validates a credential subject against the policy's VC
+// schema; every subject is accepted if the policy has none
+func (p *Policy) ValidateSubject(credentialSubject map[string]interface{}, subjectID string) *errors.VCError {
+	if p.Schema == nil {
+		return nil
+	}
+	return p.Schema.ValidateSubject(credentialSubject, subjectID)
+}
@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		entity   *models.CredentialPolicyEntity
+		expected int
+	}{
+		{"Nil policy", nil, errors.ErrCredInvalidCredentialType},
+		{"Missing credential type", &models.CredentialPolicyEntity{ExpirationDuration: 1, ExpirationTimeUnit: "YEAR"}, errors.ErrCredInvalidCredentialType},
+		{"Missing validity", &models.CredentialPolicyEntity{CredentialType: "TestCredential"}, errors.ErrSysInvalidTimeUnit},
+		{"Unknown unit", &models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "WEEK"}, errors.ErrSysInvalidTimeUnit},
+		{"Zero duration", &models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 0, ExpirationTimeUnit: "DAY"}, errors.ErrSysInvalidTimeDuration},
+		{"Negative duration", &models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: -1, ExpirationTimeUnit: "DAY"}, errors.ErrSysInvalidTimeDuration},
+		{"Too long", &models.CredentialPolicyEntity{CredentialType: "TestCredential", ExpirationDuration: 12000, ExpirationTimeUnit: "MONTH"}, errors.ErrSysInvalidTimeDuration},
+		{"Invalid issuance window unit", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR",
+			IssuanceDateDuration: 3, IssuanceDateTimeUnit: "FORTNIGHT",
+		}, errors.ErrSysInvalidTimeUnit},
+		{"Invalid vc schema", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR", VCSchema: `{"type": "object"}`,
+		}, errors.ErrCredDataInvalidVCSchema},
+		{"Invalid selective disclosure setting", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR", SelectiveDisclosure: `{"selective_disclosure": {"_sd": {}}}`,
+		}, errors.ErrCredPrepareVCError},
+		{"Always disclosed opaque_id_seed", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR",
+			SelectiveDisclosure: `{"selective_disclosure": {"opaque_id_seed": {"always_disclosed": true}}}`,
+		}, errors.ErrCredPrepareVCError},
+		{"Unknown seed rotation", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR", SeedRotation: "DAILY",
+		}, errors.ErrSysCheckSettingError},
+		{"Periodic seed rotation without validity", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR", SeedRotation: "PERIODIC",
+		}, errors.ErrSysInvalidTimeUnit},
+		{"Invalid seed validity", &models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR",
+			SeedValidityDuration: -1, SeedValidityTimeUnit: "YEAR",
+		}, errors.ErrSysInvalidTimeDuration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, vcErr := New(tt.entity)
+			if vcErr == nil || vcErr.Code != tt.expected {
+				t.Errorf("Expected error code %d, got %v", tt.expected, vcErr)
+			}
+		})
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		policy, vcErr := New(&models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 6, ExpirationTimeUnit: "month",
+		})
+		if vcErr != nil {
+			t.Fatalf("Unexpected error: %v", vcErr)
+		}
+		if policy.Validity != (Duration{Value: 6, Unit: models.TimeUnitMonth}) {
+			t.Errorf("Expected 6 MONTH validity, got %+v", policy.Validity)
+		}
+		if policy.IssuanceWindow != (Duration{}) {
+			t.Errorf("Expected no issuance window, got %+v", policy.IssuanceWindow)
+		}
+		if policy.SeedRotation != (SeedRotation{}) || policy.SeedRotation.ValidUntil(time.Now()) != nil {
+			t.Errorf("Expected manual seed rotation without expiry, got %+v", policy.SeedRotation)
+		}
+		if policy.Pairwise {
+			t.Error("Expected pairwise seeds to be opt-in")
+		}
+	})
+
+	t.Run("Periodic seed rotation", func(t *testing.T) {
+		policy, vcErr := New(&models.CredentialPolicyEntity{
+			CredentialType: "TestCredential", ExpirationDuration: 1, ExpirationTimeUnit: "YEAR",
+			SeedRotation: "periodic", SeedValidityDuration: 2, SeedValidityTimeUnit: "YEAR",
+		})
+		if vcErr != nil {
+			t.Fatalf("Unexpected error: %v", vcErr)
+		}
+
+		now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+		validUntil := policy.SeedRotation.ValidUntil(now)
+		if !policy.SeedRotation.Periodic || validUntil == nil || !validUntil.Equal(now.AddDate(2, 0, 0)) {
+			t.Errorf("Expected periodic rotation valid for 2 years, got %+v (%v)", policy.SeedRotation, validUntil)
+		}
+	})
+}
+
+func TestAdd(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		d        Duration
+		expected time.Time
+	}{
+		{"Month clamps to end of leap February", base, Duration{1, "MONTH"}, time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC)},
+		{"Month clamps to end of February", base.AddDate(1, 0, 0), Duration{1, "MONTH"}, time.Date(2025, 2, 28, 10, 30, 0, 0, time.UTC)},
+		{"Months cross a year", base, Duration{13, "MONTH"}, time.Date(2025, 2, 28, 10, 30, 0, 0, time.UTC)},
+		{"Year from leap day", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Duration{1, "YEAR"}, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"Four years from leap day", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Duration{4, "YEAR"}, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"Day", base, Duration{1, "DAY"}, time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{"Hour", base, Duration{14, "HOUR"}, time.Date(2024, 2, 1, 0, 30, 0, 0, time.UTC)},
+		{"Minute", base, Duration{30, "MINUTE"}, time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"Second", base, Duration{90, "SECOND"}, time.Date(2024, 1, 31, 10, 31, 30, 0, time.UTC)},
+		{"Seconds beyond time.Duration range", base, Duration{500 * 365 * 24 * 60 * 60, "SECOND"}, base.AddDate(0, 0, 500*365)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Add(tt.t, tt.d); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if got := Subtract(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Duration{1, "MONTH"}); !got.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Feb 29, got %v", got)
+	}
+}
+
+func TestDates(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	policy, vcErr := New(&models.CredentialPolicyEntity{
+		CredentialType:       "TestCredential",
+		IssuanceDateDuration: 7,
+		IssuanceDateTimeUnit: models.TimeUnitDay,
+		ExpirationDuration:   1,
+		ExpirationTimeUnit:   models.TimeUnitYear,
+	})
+	if vcErr != nil {
+		t.Fatalf("Unexpected error: %v", vcErr)
+	}
+
+	at := func(t time.Time) *time.Time { return &t }
+
+	t.Run("Defaults", func(t *testing.T) {
+		issued, expires, vcErr := policy.Dates(now, nil, nil)
+		if vcErr != nil {
+			t.Fatalf("Unexpected error: %v", vcErr)
+		}
+		if !issued.Equal(now) || !expires.Equal(now.AddDate(1, 0, 0)) {
+			t.Errorf("Expected (%v, %v), got (%v, %v)", now, now.AddDate(1, 0, 0), issued, expires)
+		}
+	})
+
+	t.Run("Caller dates within policy", func(t *testing.T) {
+		issuanceDate := now.AddDate(0, 0, -3)
+		expirationDate := now.AddDate(0, 6, 0)
+
+		issued, expires, vcErr := policy.Dates(now, &issuanceDate, &expirationDate)
+		if vcErr != nil {
+			t.Fatalf("Unexpected error: %v", vcErr)
+		}
+		if !issued.Equal(issuanceDate) || !expires.Equal(expirationDate) {
+			t.Errorf("Expected (%v, %v), got (%v, %v)", issuanceDate, expirationDate, issued, expires)
+		}
+	})
+
+	tests := []struct {
+		name           string
+		issuanceDate   *time.Time
+		expirationDate *time.Time
+		expected       int
+	}{
+		{"Issuance in the future", at(now.Add(time.Minute)), nil, errors.ErrCredInvalidIssuanceDate},
+		{"Issuance before window", at(now.AddDate(0, 0, -8)), nil, errors.ErrCredInvalidIssuanceDate},
+		{"Expiration before issuance", at(now.AddDate(0, 0, -2)), at(now.AddDate(0, 0, -3)), errors.ErrCredInvalidExpirationDate},
+		{"Expiration in the past", at(now.AddDate(0, 0, -3)), at(now.AddDate(0, 0, -1)), errors.ErrCredInvalidExpirationDate},
+		{"Expiration beyond validity", nil, at(now.AddDate(1, 0, 1)), errors.ErrCredInvalidExpirationDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, vcErr := policy.Dates(now, tt.issuanceDate, tt.expirationDate)
+			if vcErr == nil || vcErr.Code != tt.expected {
+				t.Errorf("Expected error code %d, got %v", tt.expected, vcErr)
+			}
+		})
+	}
+}
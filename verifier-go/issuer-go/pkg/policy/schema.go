@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+)
+
+// schemaURL is the location the policy's VC schema is registered under; the
+// credential subject schema is compiled from its properties.credentialSubject
+const schemaURL = "urn:twdiw:vc-schema"
+
+// schemaErrorPrinter renders schema validation messages
+var schemaErrorPrinter = message.NewPrinter(language.English)
+
+// Schema is a compiled VC schema. It validates the credential subject against
+// the schema's properties.credentialSubject.
+// Equivalent to Java's VcSchema
+type Schema struct {
+	subject *jsonschema.Schema
+
+	// declaresID reports whether the schema lists the fixed "id" property
+	declaresID bool
+}
+
+// CompileSchema compiles a VC schema (JSON Schema, draft 2020-12 unless
+// "$schema" says otherwise). References to other documents are not loaded.
+func CompileSchema(vcSchema string) (*Schema, *errors.VCError) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(vcSchema))
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrCredDataInvalidVCSchema, fmt.Sprintf("vc schema is not valid JSON: %v", err))
+	}
+
+	properties, _ := objectValue(doc, "properties")
+	credentialSubject, ok := objectValue(properties, "credentialSubject")
+	if !ok {
+		return nil, errors.NewVCError(errors.ErrCredDataInvalidVCSchema, "properties in vc schema is invalid: missing credentialSubject")
+	}
+	subjectProperties, _ := objectValue(credentialSubject, "properties")
+	_, declaresID := subjectProperties["id"]
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, errors.NewVCError(errors.ErrCredDataInvalidVCSchema, fmt.Sprintf("invalid vc schema: %v", err))
+	}
+
+	subject, err := compiler.Compile(schemaURL + "#/properties/credentialSubject")
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrCredDataInvalidVCSchema, fmt.Sprintf("invalid vc schema: %v", err))
+	}
+
+	return &Schema{subject: subject, declaresID: declaresID}, nil
+}
+
+// ValidateSubject validates a credential subject. subjectID is the holder's
+// DID, only checked if the schema declares "id" (the issuer sets it, it is
+// not part of the credential data). On failure the error lists every invalid
+// field, ex: "/credentialSubject/birth_year".
+// Equivalent to Java's CredentialDataService.compareKeySet()
+func (s *Schema) ValidateSubject(credentialSubject map[string]interface{}, subjectID string) *errors.VCError {
+	subject := make(map[string]interface{}, len(credentialSubject)+1)
+	for k, v := range credentialSubject {
+		subject[k] = v
+	}
+	if s.declaresID && subjectID != "" {
+		subject["id"] = subjectID
+	}
+
+	// Round-trip through JSON so the validator sees JSON types only
+	data, err := json.Marshal(subject)
+	if err != nil {
+		return errors.NewVCError(errors.ErrCredInvalidCredentialSubject, fmt.Sprintf("invalid credential subject: %v", err))
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return errors.NewVCError(errors.ErrCredInvalidCredentialSubject, fmt.Sprintf("invalid credential subject: %v", err))
+	}
+
+	err = s.subject.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	vcErr := errors.NewVCError(
+		errors.ErrCredDataFieldsInSchemaAndVCDataNotIdentical,
+		"data fields in the schema and vc data are not identical",
+	)
+	if validationErr, ok := err.(*jsonschema.ValidationError); ok {
+		vcErr.Errors = fieldErrors(validationErr)
+	} else {
+		vcErr.Errors = []errors.FieldError{{Path: "/credentialSubject", Message: err.Error()}}
+	}
+	return vcErr
+}
+
+// fieldErrors flattens a validation error into its leaf causes, sorted by path
+func fieldErrors(validationErr *jsonschema.ValidationError) []errors.FieldError {
+	var result []errors.FieldError
+
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			result = append(result, errors.FieldError{
+				Path:    "/credentialSubject" + jsonPointer(e.InstanceLocation),
+				Message: e.ErrorKind.LocalizedString(schemaErrorPrinter),
+			})
+			return
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// jsonPointer builds an RFC 6901 JSON pointer from reference tokens
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return sb.String()
+}
+
+// objectValue returns v[key] if v is an object and v[key] is an object
+func objectValue(v interface{}, key string) (map[string]interface{}, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := obj[key].(map[string]interface{})
+	return value, ok
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+)
+
+// ageVerificationSchema is the VC schema of the pairwise pseudonym design
+const ageVerificationSchema = `{
+	"$id": "https://tw.gov.moda/schemas/age_verification_v2.json",
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"credentialSubject": {
+			"type": "object",
+			"properties": {
+				"opaque_id_seed": {"type": "string", "pattern": "^[A-Za-z0-9_-]{43}$", "minLength": 43, "maxLength": 43},
+				"over_18": {"type": "boolean"},
+				"over_21": {"type": "boolean"},
+				"birth_year": {"type": "integer"}
+			},
+			"required": ["opaque_id_seed", "over_18"],
+			"additionalProperties": false
+		}
+	}
+}`
+
+const testSeed = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestCompileSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"Not JSON", `{"type":`},
+		{"No credentialSubject", `{"type": "object", "properties": {"name": {"type": "string"}}}`},
+		{"Invalid keyword value", `{"properties": {"credentialSubject": {"type": "thing"}}}`},
+		{"Invalid pattern", `{"properties": {"credentialSubject": {"properties": {"name": {"pattern": "("}}}}}`},
+		{"Remote reference", `{"properties": {"credentialSubject": {"$ref": "https://example.com/subject.json"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, vcErr := CompileSchema(tt.schema)
+			if vcErr == nil || vcErr.Code != errors.ErrCredDataInvalidVCSchema {
+				t.Errorf("Expected error code %d, got %v", errors.ErrCredDataInvalidVCSchema, vcErr)
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateSubject(t *testing.T) {
+	schema, vcErr := CompileSchema(ageVerificationSchema)
+	if vcErr != nil {
+		t.Fatalf("CompileSchema failed: %v", vcErr)
+	}
+
+	tests := []struct {
+		name     string
+		subject  map[string]interface{}
+		expected []errors.FieldError
+	}{
+		{
+			name:    "Valid",
+			subject: map[string]interface{}{"opaque_id_seed": testSeed, "over_18": true, "birth_year": 1990},
+		},
+		{
+			name:    "Missing required field",
+			subject: map[string]interface{}{"opaque_id_seed": testSeed},
+			expected: []errors.FieldError{
+				{Path: "/credentialSubject", Message: "missing property 'over_18'"},
+			},
+		},
+		{
+			name:    "Wrong types",
+			subject: map[string]interface{}{"opaque_id_seed": testSeed, "over_18": "yes", "birth_year": 1990.5},
+			expected: []errors.FieldError{
+				{Path: "/credentialSubject/birth_year", Message: "got number, want integer"},
+				{Path: "/credentialSubject/over_18", Message: "got string, want boolean"},
+			},
+		},
+		{
+			name:    "Pattern mismatch",
+			subject: map[string]interface{}{"opaque_id_seed": strings.Repeat("!", 43), "over_18": false},
+			expected: []errors.FieldError{
+				{Path: "/credentialSubject/opaque_id_seed", Message: "'" + strings.Repeat("!", 43) + "' does not match pattern '^[A-Za-z0-9_-]{43}$'"},
+			},
+		},
+		{
+			name:    "Additional property",
+			subject: map[string]interface{}{"opaque_id_seed": testSeed, "over_18": true, "name": "Test User"},
+			expected: []errors.FieldError{
+				{Path: "/credentialSubject", Message: "additional properties 'name' not allowed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcErr := schema.ValidateSubject(tt.subject, "did:example:holder")
+
+			if tt.expected == nil {
+				if vcErr != nil {
+					t.Fatalf("Unexpected error: %v (%+v)", vcErr, vcErr.Errors)
+				}
+				return
+			}
+			if vcErr == nil || vcErr.Code != errors.ErrCredDataFieldsInSchemaAndVCDataNotIdentical {
+				t.Fatalf("Expected error code %d, got %v", errors.ErrCredDataFieldsInSchemaAndVCDataNotIdentical, vcErr)
+			}
+			if !reflect.DeepEqual(vcErr.Errors, tt.expected) {
+				t.Errorf("Expected field errors %+v, got %+v", tt.expected, vcErr.Errors)
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateSubject_ID(t *testing.T) {
+	// Given - a schema declaring the fixed "id" property, as Java's VC schemas do
+	schema, vcErr := CompileSchema(`{"properties": {"credentialSubject": {
+		"properties": {"id": {"type": "string", "pattern": "^did:"}, "name": {"type": "string"}},
+		"required": ["id", "name"],
+		"additionalProperties": false
+	}}}`)
+	if vcErr != nil {
+		t.Fatalf("CompileSchema failed: %v", vcErr)
+	}
+
+	// When / Then - the holder DID is validated as the subject id
+	if vcErr := schema.ValidateSubject(map[string]interface{}{"name": "Test User"}, "did:example:holder"); vcErr != nil {
+		t.Errorf("Unexpected error: %v (%+v)", vcErr, vcErr.Errors)
+	}
+	if vcErr := schema.ValidateSubject(map[string]interface{}{"name": "Test User"}, "holder"); vcErr == nil {
+		t.Error("Expected error for a subject id that is not a DID")
+	}
+
+	// A schema without "id" does not see the holder DID
+	schema, _ = CompileSchema(ageVerificationSchema)
+	if vcErr := schema.ValidateSubject(map[string]interface{}{"opaque_id_seed": testSeed, "over_18": true}, "did:example:holder"); vcErr != nil {
+		t.Errorf("Unexpected error: %v (%+v)", vcErr, vcErr.Errors)
+	}
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+// MemoryCredentialRepository is an in-memory CredentialRepository.
+// Contents are lost on restart; intended for tests and development.
+type MemoryCredentialRepository struct {
+	credentials map[string]models.Credential
+	history     map[string][]models.CredentialStatusHistory
+	mu          sync.RWMutex
+}
+
+// NewMemoryCredentialRepository creates a new in-memory credential repository
+func NewMemoryCredentialRepository() *MemoryCredentialRepository {
+	return &MemoryCredentialRepository{
+		credentials: make(map[string]models.Credential),
+		history:     make(map[string][]models.CredentialStatusHistory),
+	}
+}
+
+// Save stores a newly issued credential
+func (r *MemoryCredentialRepository) Save(ctx context.Context, credential *models.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.credentials[credential.CID]; exists {
+		return ErrDuplicate
+	}
+
+	r.credentials[credential.CID] = *credential
+	return nil
+}
+
+// FindByCID returns the credential with the given CID
+func (r *MemoryCredentialRepository) FindByCID(ctx context.Context, cid string) (*models.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credential, exists := r.credentials[cid]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return &credential, nil
+}
+
+// FindLatestByNonce returns the most recently issued credential with the given nonce
+func (r *MemoryCredentialRepository) FindLatestByNonce(ctx context.Context, nonce string) (*models.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.Credential
+	for _, credential := range r.credentials {
+		if credential.Nonce != nonce {
+			continue
+		}
+		if latest == nil || credential.IssuanceDate.After(latest.IssuanceDate) {
+			c := credential
+			latest = &c
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	return latest, nil
+}
+
+// TransitionStatus moves a credential to a new status and records the transition
+func (r *MemoryCredentialRepository) TransitionStatus(ctx context.Context, transition *models.CredentialStatusHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, exists := r.credentials[transition.CID]
+	if !exists {
+		return ErrNotFound
+	}
+	if credential.CredentialStatus != transition.FromStatus {
+		return ErrConflict
+	}
+
+	credential.CredentialStatus = transition.ToStatus
+	credential.LastUpdateTime = transition.Timestamp
+	r.credentials[transition.CID] = credential
+	r.history[transition.CID] = append(r.history[transition.CID], *transition)
+	return nil
+}
+
+// FindStatusHistory returns the status transitions of a credential, oldest first
+func (r *MemoryCredentialRepository) FindStatusHistory(ctx context.Context, cid string) ([]models.CredentialStatusHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.credentials[cid]; !exists {
+		return nil, ErrNotFound
+	}
+
+	history := make([]models.CredentialStatusHistory, len(r.history[cid]))
+	copy(history, r.history[cid])
+	return history, nil
+}
+
+// MemoryStatusListRepository is an in-memory StatusListRepository
+type MemoryStatusListRepository struct {
+	statusLists map[string]models.StatusList
+	mu          sync.RWMutex
+}
+
+// NewMemoryStatusListRepository creates a new in-memory status list repository
+func NewMemoryStatusListRepository() *MemoryStatusListRepository {
+	return &MemoryStatusListRepository{
+		statusLists: make(map[string]models.StatusList),
+	}
+}
+
+// FindStatusList returns the latest status list of a credential type and group name
+func (r *MemoryStatusListRepository) FindStatusList(ctx context.Context, credentialType, groupName string) (*models.StatusList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statusList, exists := r.statusLists[credentialType+"/"+groupName]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return &statusList, nil
+}
+
+// SaveStatusList creates or replaces a status list, checking its version
+func (r *MemoryStatusListRepository) SaveStatusList(ctx context.Context, statusList *models.StatusList) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := statusList.CredentialType + "/" + statusList.GroupName
+	current, exists := r.statusLists[key]
+
+	switch {
+	case statusList.Version <= 1 && exists:
+		return ErrDuplicate
+	case statusList.Version > 1 && (!exists || current.Version != statusList.Version-1):
+		return ErrConflict
+	}
+
+	r.statusLists[key] = *statusList
+	return nil
+}
+
+// MemoryCredentialPolicyRepository is an in-memory CredentialPolicyRepository
+type MemoryCredentialPolicyRepository struct {
+	policies map[string]models.CredentialPolicyEntity
+	mu       sync.RWMutex
+}
+
+// NewMemoryCredentialPolicyRepository creates a new in-memory policy repository holding policies
+func NewMemoryCredentialPolicyRepository(policies ...models.CredentialPolicyEntity) *MemoryCredentialPolicyRepository {
+	r := &MemoryCredentialPolicyRepository{
+		policies: make(map[string]models.CredentialPolicyEntity, len(policies)),
+	}
+	for _, policy := range policies {
+		r.policies[policy.CredentialType] = policy
+	}
+	return r
+}
+
+// FindPolicy returns the policy of a credential type
+func (r *MemoryCredentialPolicyRepository) FindPolicy(ctx context.Context, credentialType string) (*models.CredentialPolicyEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, exists := r.policies[credentialType]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces the policy of policy.CredentialType
+func (r *MemoryCredentialPolicyRepository) SavePolicy(ctx context.Context, policy *models.CredentialPolicyEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policies[policy.CredentialType] = *policy
+	return nil
+}
+
+// MemoryTicketRepository is an in-memory TicketRepository
+type MemoryTicketRepository struct {
+	tickets map[string]int
+	mu      sync.Mutex
+}
+
+// NewMemoryTicketRepository creates a new in-memory ticket repository
+func NewMemoryTicketRepository() *MemoryTicketRepository {
+	return &MemoryTicketRepository{
+		tickets: make(map[string]int),
+	}
+}
+
+// TakeTicket increments and returns the sequence of a credential type
+func (r *MemoryTicketRepository) TakeTicket(ctx context.Context, credentialType string) (*models.Ticket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tickets[credentialType]++
+	return &models.Ticket{
+		CredentialType: credentialType,
+		TicketNumber:   r.tickets[credentialType],
+		LastUpdateTime: time.Now(),
+	}, nil
+}
+
+// MemoryOpaqueIDSeedRepository is an in-memory OpaqueIDSeedRepository.
+// Seeds are lost on restart; intended for tests and development.
+type MemoryOpaqueIDSeedRepository struct {
+	seeds map[seedKey][]models.OpaqueIDSeed // epochs, oldest first
+	mu    sync.RWMutex
+}
+
+// seedKey identifies the seeds of a holder for a credential type
+type seedKey struct {
+	holderKey      string
+	credentialType string
+}
+
+// NewMemoryOpaqueIDSeedRepository creates a new in-memory seed repository
+func NewMemoryOpaqueIDSeedRepository() *MemoryOpaqueIDSeedRepository {
+	return &MemoryOpaqueIDSeedRepository{
+		seeds: make(map[seedKey][]models.OpaqueIDSeed),
+	}
+}
+
+// FindLatestSeed returns the highest epoch of a holder and credential type
+func (r *MemoryOpaqueIDSeedRepository) FindLatestSeed(ctx context.Context, holderKey, credentialType string) (*models.OpaqueIDSeed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	epochs := r.seeds[seedKey{holderKey, credentialType}]
+	if len(epochs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	seed := epochs[len(epochs)-1]
+	return &seed, nil
+}
+
+// FindSeeds returns every epoch of a holder and credential type, oldest first
+func (r *MemoryOpaqueIDSeedRepository) FindSeeds(ctx context.Context, holderKey, credentialType string) ([]models.OpaqueIDSeed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.OpaqueIDSeed{}, r.seeds[seedKey{holderKey, credentialType}]...), nil
+}
+
+// SaveSeed stores a new epoch
+func (r *MemoryOpaqueIDSeedRepository) SaveSeed(ctx context.Context, seed *models.OpaqueIDSeed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := seedKey{seed.HolderKey, seed.CredentialType}
+	epochs := r.seeds[key]
+	for _, existing := range epochs {
+		if existing.Epoch == seed.Epoch {
+			return ErrDuplicate
+		}
+	}
+
+	epochs = append(epochs, *seed)
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i].Epoch < epochs[j].Epoch })
+	r.seeds[key] = epochs
+	return nil
+}
+
+// RetireSeed records the retirement of an epoch
+func (r *MemoryOpaqueIDSeedRepository) RetireSeed(ctx context.Context, seed *models.OpaqueIDSeed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	epochs := r.seeds[seedKey{seed.HolderKey, seed.CredentialType}]
+	for i := range epochs {
+		if epochs[i].Epoch != seed.Epoch {
+			continue
+		}
+		if epochs[i].RetiredAt != nil {
+			return ErrConflict
+		}
+		retiredAt := *seed.RetiredAt
+		epochs[i].RetiredAt = &retiredAt
+		epochs[i].RetireReason = seed.RetireReason
+		epochs[i].RetiredBy = seed.RetiredBy
+		return nil
+	}
+
+	return ErrNotFound
+}
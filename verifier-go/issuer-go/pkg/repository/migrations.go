@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// timeLayout is the storage format for timestamps. A fixed-width UTC layout
+// keeps lexical and chronological order identical so ORDER BY works on text.
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// migration is a single versioned schema change. rewrite, if set, runs after
+// the statements in the same transaction to rewrite stored data.
+type migration struct {
+	version    int
+	statements []string
+	rewrite    func(ctx context.Context, tx *sql.Tx, keys SeedMigrationKeys) error
+}
+
+// SeedMigrationKeys give migrations the secrets to rewrite stored opaque ID
+// seeds. A migration that must rewrite seeds fails while any are stored and
+// its key is not set, so the issuer never starts with seeds it cannot use.
+type SeedMigrationKeys struct {
+	// BindEpoch re-encrypts the cipher of a seed stored before seed epochs,
+	// binding it to epoch 1 (migration 8)
+	BindEpoch func(seedCipher, holderUID, credentialType string) (string, error)
+
+	// HolderKey derives the holder key seeds are stored under from a holder
+	// UID, re-keying the seeds stored under raw holder UIDs (migration 9)
+	HolderKey func(holderUID string) string
+}
+
+// migrations lists every schema change in order. Append new entries with the
+// next version number; never edit an entry that has already shipped.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS credential (
+				cid                   TEXT PRIMARY KEY,
+				credential_type       TEXT NOT NULL,
+				credential_subject_id TEXT NOT NULL DEFAULT '',
+				issuance_date         TEXT NOT NULL,
+				expiration_date       TEXT NOT NULL,
+				content               TEXT NOT NULL,
+				ticket_number         INTEGER NOT NULL,
+				last_update_time      TEXT NOT NULL,
+				credential_status     TEXT NOT NULL,
+				nonce                 TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_credential_nonce ON credential (nonce, issuance_date)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS credential_status_history (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				cid         TEXT NOT NULL REFERENCES credential (cid),
+				from_status TEXT NOT NULL,
+				to_status   TEXT NOT NULL,
+				reason_code TEXT NOT NULL,
+				actor       TEXT NOT NULL,
+				changed_at  TEXT NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_credential_status_history_cid ON credential_status_history (cid, id)`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS status_list (
+				credential_type  TEXT NOT NULL,
+				group_name       TEXT NOT NULL,
+				status_list_type TEXT NOT NULL,
+				encoded_list     TEXT NOT NULL,
+				content          TEXT NOT NULL,
+				version          INTEGER NOT NULL,
+				last_update_time TEXT NOT NULL,
+				PRIMARY KEY (credential_type, group_name)
+			)`,
+		},
+	},
+	{
+		version: 4,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS credential_policy (
+				credential_type         TEXT PRIMARY KEY,
+				issuer_identifier       TEXT NOT NULL DEFAULT '',
+				issuer_metadata         TEXT NOT NULL DEFAULT '',
+				vc_schema               TEXT NOT NULL DEFAULT '',
+				vc_data_source          TEXT NOT NULL DEFAULT '',
+				issuance_date_duration  INTEGER NOT NULL DEFAULT 0,
+				issuance_date_time_unit TEXT NOT NULL DEFAULT '',
+				expiration_duration     INTEGER NOT NULL,
+				expiration_time_unit    TEXT NOT NULL,
+				func_switch             TEXT NOT NULL DEFAULT ''
+			)`,
+		},
+	},
+	{
+		version: 5,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS ticket (
+				credential_type  TEXT PRIMARY KEY,
+				ticket_number    INTEGER NOT NULL,
+				last_update_time TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 6,
+		statements: []string{
+			`ALTER TABLE credential_policy ADD COLUMN selective_disclosure TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 7,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS opaque_id_seed (
+				holder_uid      TEXT NOT NULL,
+				credential_type TEXT NOT NULL,
+				seed_cipher     TEXT NOT NULL,
+				create_time     TEXT NOT NULL,
+				PRIMARY KEY (holder_uid, credential_type)
+			)`,
+		},
+	},
+	{
+		// Seed epochs: rebuild opaque_id_seed with the epoch in its primary key
+		// (existing seeds become epoch 1, re-encrypted to bind the epoch) and
+		// add seed rotation policies
+		version: 8,
+		statements: []string{
+			`CREATE TABLE opaque_id_seed_epoch (
+				holder_uid      TEXT NOT NULL,
+				credential_type TEXT NOT NULL,
+				epoch           INTEGER NOT NULL,
+				seed_cipher     TEXT NOT NULL,
+				create_time     TEXT NOT NULL,
+				valid_until     TEXT NOT NULL DEFAULT '',
+				retired_at      TEXT NOT NULL DEFAULT '',
+				retire_reason   TEXT NOT NULL DEFAULT '',
+				retired_by      TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (holder_uid, credential_type, epoch)
+			)`,
+			`INSERT INTO opaque_id_seed_epoch (holder_uid, credential_type, epoch, seed_cipher, create_time)
+				SELECT holder_uid, credential_type, 1, seed_cipher, create_time FROM opaque_id_seed`,
+			`DROP TABLE opaque_id_seed`,
+			`ALTER TABLE opaque_id_seed_epoch RENAME TO opaque_id_seed`,
+			`ALTER TABLE credential_policy ADD COLUMN seed_rotation TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE credential_policy ADD COLUMN seed_validity_duration INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE credential_policy ADD COLUMN seed_validity_time_unit TEXT NOT NULL DEFAULT ''`,
+		},
+		rewrite: bindSeedEpochs,
+	},
+	{
+		// Holder keys: seeds are looked up by a keyed hash of the holder UID;
+		// every seed stored under a raw UID is re-keyed. Pairwise seeds become
+		// opt-in per credential type; existing policies keep theirs.
+		version: 9,
+		statements: []string{
+			`ALTER TABLE opaque_id_seed RENAME COLUMN holder_uid TO holder_key`,
+			`ALTER TABLE credential_policy ADD COLUMN pairwise_enabled INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE credential_policy SET pairwise_enabled = 1`,
+		},
+		rewrite: hashSeedHolders,
+	},
+}
+
+// Migrate brings the database schema up to date. Applied versions are
+// recorded in schema_migrations, so running it repeatedly is safe. Migrations
+// rewriting stored opaque ID seeds fail while any are stored; open the
+// database with NewSQLOpaqueIDSeedRepository and the seed keys first.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return migrate(ctx, db, SeedMigrationKeys{})
+}
+
+// migrate applies pending migrations, rewriting stored seeds with keys
+func migrate(ctx context.Context, db *sql.DB, keys SeedMigrationKeys) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if err := applyMigration(ctx, db, m, keys); err != nil {
+			return fmt.Errorf("migration %d failed: %w", m.version, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration in a transaction unless it is already applied
+func applyMigration(ctx context.Context, db *sql.DB, m migration, keys SeedMigrationKeys) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, m.version).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if m.rewrite != nil {
+		if err := m.rewrite(ctx, tx, keys); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		m.version, formatTime(time.Now())); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// bindSeedEpochs re-encrypts the seeds stored before seed epochs, whose
+// ciphers were not bound to an epoch, as epoch 1 seeds
+func bindSeedEpochs(ctx context.Context, tx *sql.Tx, keys SeedMigrationKeys) error {
+	type storedSeed struct{ holderUID, credentialType, seedCipher string }
+
+	rows, err := tx.QueryContext(ctx, `SELECT holder_uid, credential_type, seed_cipher FROM opaque_id_seed`)
+	if err != nil {
+		return err
+	}
+	var seeds []storedSeed
+	for rows.Next() {
+		var seed storedSeed
+		if err := rows.Scan(&seed.holderUID, &seed.credentialType, &seed.seedCipher); err != nil {
+			rows.Close()
+			return err
+		}
+		seeds = append(seeds, seed)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(seeds) > 0 && keys.BindEpoch == nil {
+		return fmt.Errorf("%d opaque ID seeds must be re-encrypted: open the database with NewSQLOpaqueIDSeedRepository and the key-encryption key", len(seeds))
+	}
+	for _, seed := range seeds {
+		seedCipher, err := keys.BindEpoch(seed.seedCipher, seed.holderUID, seed.credentialType)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt opaque ID seed: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE opaque_id_seed SET seed_cipher = ? WHERE holder_uid = ? AND credential_type = ? AND epoch = 1`,
+			seedCipher, seed.holderUID, seed.credentialType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashSeedHolders re-keys the seeds stored under raw holder UIDs by their
+// holder keys, so no holder UID is left in storage
+func hashSeedHolders(ctx context.Context, tx *sql.Tx, keys SeedMigrationKeys) error {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT holder_key FROM opaque_id_seed`)
+	if err != nil {
+		return err
+	}
+	var holderUIDs []string
+	for rows.Next() {
+		var holderUID string
+		if err := rows.Scan(&holderUID); err != nil {
+			rows.Close()
+			return err
+		}
+		holderUIDs = append(holderUIDs, holderUID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(holderUIDs) > 0 && keys.HolderKey == nil {
+		return fmt.Errorf("opaque ID seeds of %d holders must be re-keyed: open the database with NewSQLOpaqueIDSeedRepository and the holder key pepper", len(holderUIDs))
+	}
+	for _, holderUID := range holderUIDs {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE opaque_id_seed SET holder_key = ? WHERE holder_key = ?`,
+			keys.HolderKey(holderUID), holderUID); err != nil {
+			return fmt.Errorf("failed to re-key opaque ID seeds: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// formatTime converts a time to its storage representation
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// parseTime converts a stored timestamp back to a time.Time
+func parseTime(s string) (time.Time, error) {
+	return time.ParseInLocation(timeLayout, s, time.UTC)
+}
+
+// formatOptionalTime converts an optional time to its storage representation (empty if nil)
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// parseOptionalTime converts a stored optional timestamp back to a *time.Time
+func parseOptionalTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested entity does not exist
+	ErrNotFound = errors.New("repository: entity not found")
+
+	// ErrDuplicate is returned when an entity with the same key already exists
+	ErrDuplicate = errors.New("repository: duplicate entity")
+
+	// ErrConflict is returned when an entity changed since it was read
+	ErrConflict = errors.New("repository: concurrent modification")
+)
+
+// CredentialRepository persists issued credentials
+// Equivalent to Java's CredentialRepository
+type CredentialRepository interface {
+	// Save stores a newly issued credential (ErrDuplicate if the CID already exists)
+	Save(ctx context.Context, credential *models.Credential) error
+
+	// FindByCID returns the credential with the given CID (ErrNotFound if absent)
+	FindByCID(ctx context.Context, cid string) (*models.Credential, error)
+
+	// FindLatestByNonce returns the most recently issued credential with the
+	// given nonce; only the newest one is accepted when nonces collide
+	FindLatestByNonce(ctx context.Context, nonce string) (*models.Credential, error)
+
+	// TransitionStatus moves a credential from transition.FromStatus to
+	// transition.ToStatus and appends the transition to its status history, atomically.
+	// Returns ErrConflict if the current status is no longer transition.FromStatus.
+	TransitionStatus(ctx context.Context, transition *models.CredentialStatusHistory) error
+
+	// FindStatusHistory returns the status transitions of a credential, oldest first
+	FindStatusHistory(ctx context.Context, cid string) ([]models.CredentialStatusHistory, error)
+}
+
+// StatusListRepository persists Bitstring Status Lists
+// Equivalent to Java's StatusListRepository
+type StatusListRepository interface {
+	// FindStatusList returns the latest status list of a credential type and
+	// group name, ex: ("IdentityCredential", "r0") (ErrNotFound if absent)
+	FindStatusList(ctx context.Context, credentialType, groupName string) (*models.StatusList, error)
+
+	// SaveStatusList stores a status list. Version 1 creates the list
+	// (ErrDuplicate if it exists); a higher version replaces version-1
+	// (ErrConflict if the stored version differs).
+	SaveStatusList(ctx context.Context, statusList *models.StatusList) error
+}
+
+// CredentialPolicyRepository persists credential policies
+// Equivalent to Java's CredentialPolicyRepository
+type CredentialPolicyRepository interface {
+	// FindPolicy returns the policy of a credential type (ErrNotFound if absent)
+	FindPolicy(ctx context.Context, credentialType string) (*models.CredentialPolicyEntity, error)
+
+	// SavePolicy creates or replaces the policy of policy.CredentialType
+	SavePolicy(ctx context.Context, policy *models.CredentialPolicyEntity) error
+}
+
+// TicketRepository hands out ticket numbers, a sequence per credential type
+// starting at 1. Ticket numbers locate a credential in its
+// status lists, so a number must never be handed out twice, including across
+// restarts and concurrent callers.
+// Equivalent to Java's TicketRepository
+type TicketRepository interface {
+	// TakeTicket atomically increments and returns the sequence of a credential type
+	TakeTicket(ctx context.Context, credentialType string) (*models.Ticket, error)
+}
+
+// OpaqueIDSeedRepository persists encrypted opaque_id_seeds: per holder key and
+// credential type, a sequence of epochs of which the latest unretired one is
+// current. A seed must survive restarts: a new seed gives the holder a new
+// pseudonym at every verifier (design §4.3). Retired epochs are kept for audit.
+// Holders are identified by a keyed hash of their UID, never the UID itself.
+type OpaqueIDSeedRepository interface {
+	// FindLatestSeed returns the highest epoch of a holder and credential type (ErrNotFound if absent)
+	FindLatestSeed(ctx context.Context, holderKey, credentialType string) (*models.OpaqueIDSeed, error)
+
+	// FindSeeds returns every epoch of a holder and credential type, oldest first
+	FindSeeds(ctx context.Context, holderKey, credentialType string) ([]models.OpaqueIDSeed, error)
+
+	// SaveSeed stores a new epoch (ErrDuplicate if the epoch already exists)
+	SaveSeed(ctx context.Context, seed *models.OpaqueIDSeed) error
+
+	// RetireSeed records seed.RetiredAt, RetireReason and RetiredBy on the
+	// stored epoch seed.Epoch. Returns ErrNotFound if the epoch does not exist
+	// and ErrConflict if it is already retired.
+	RetireSeed(ctx context.Context, seed *models.OpaqueIDSeed) error
+}
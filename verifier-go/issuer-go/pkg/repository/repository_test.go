@@ -0,0 +1,511 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+func newTestCredential(cid, nonce string, issuanceDate time.Time) *models.Credential {
+	return &models.Credential{
+		CID:                 cid,
+		CredentialType:      "TestCredential",
+		CredentialSubjectID: "did:example:holder",
+		IssuanceDate:        issuanceDate,
+		ExpirationDate:      issuanceDate.Add(24 * time.Hour),
+		Content:             "header.payload.signature",
+		TicketNumber:        1,
+		LastUpdateTime:      issuanceDate,
+		CredentialStatus:    models.CredentialStatusActive,
+		Nonce:               nonce,
+	}
+}
+
+// testCredentialRepository exercises the CredentialRepository contract
+func testCredentialRepository(t *testing.T, repo CredentialRepository) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	t.Run("SaveAndFindByCID", func(t *testing.T) {
+		// Given
+		credential := newTestCredential("cid-1", "nonce-1", now)
+
+		// When
+		if err := repo.Save(ctx, credential); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		found, err := repo.FindByCID(ctx, "cid-1")
+
+		// Then
+		if err != nil {
+			t.Fatalf("FindByCID failed: %v", err)
+		}
+		if found.Content != credential.Content || found.CredentialStatus != models.CredentialStatusActive {
+			t.Errorf("Expected stored credential, got %+v", found)
+		}
+		if !found.IssuanceDate.Equal(now) {
+			t.Errorf("Expected issuance date %v, got %v", now, found.IssuanceDate)
+		}
+	})
+
+	t.Run("SaveDuplicate", func(t *testing.T) {
+		err := repo.Save(ctx, newTestCredential("cid-1", "nonce-1", now))
+		if !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+	})
+
+	t.Run("FindByCIDNotFound", func(t *testing.T) {
+		_, err := repo.FindByCID(ctx, "missing")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("FindLatestByNonce", func(t *testing.T) {
+		// Given - two credentials share a nonce
+		if err := repo.Save(ctx, newTestCredential("cid-2", "shared", now)); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if err := repo.Save(ctx, newTestCredential("cid-3", "shared", now.Add(time.Second))); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		// When
+		found, err := repo.FindLatestByNonce(ctx, "shared")
+
+		// Then
+		if err != nil {
+			t.Fatalf("FindLatestByNonce failed: %v", err)
+		}
+		if found.CID != "cid-3" {
+			t.Errorf("Expected latest credential cid-3, got %s", found.CID)
+		}
+
+		if _, err := repo.FindLatestByNonce(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("TransitionStatus", func(t *testing.T) {
+		// Given
+		transition := &models.CredentialStatusHistory{
+			CID:        "cid-1",
+			FromStatus: models.CredentialStatusActive,
+			ToStatus:   models.CredentialStatusSuspended,
+			ReasonCode: models.StatusReasonHolderRequest,
+			Actor:      "admin",
+			Timestamp:  now.Add(time.Hour),
+		}
+
+		// When
+		if err := repo.TransitionStatus(ctx, transition); err != nil {
+			t.Fatalf("TransitionStatus failed: %v", err)
+		}
+
+		// Then
+		found, _ := repo.FindByCID(ctx, "cid-1")
+		if found.CredentialStatus != models.CredentialStatusSuspended {
+			t.Errorf("Expected status SUSPENDED, got %s", found.CredentialStatus)
+		}
+		if !found.LastUpdateTime.Equal(transition.Timestamp) {
+			t.Errorf("Expected last update time %v, got %v", transition.Timestamp, found.LastUpdateTime)
+		}
+
+		history, err := repo.FindStatusHistory(ctx, "cid-1")
+		if err != nil {
+			t.Fatalf("FindStatusHistory failed: %v", err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("Expected 1 history entry, got %d", len(history))
+		}
+		if history[0].ReasonCode != models.StatusReasonHolderRequest || history[0].Actor != "admin" ||
+			!history[0].Timestamp.Equal(transition.Timestamp) {
+			t.Errorf("Unexpected history entry: %+v", history[0])
+		}
+	})
+
+	t.Run("TransitionStatusConflict", func(t *testing.T) {
+		// Given - cid-1 is SUSPENDED, not ACTIVE
+		transition := &models.CredentialStatusHistory{
+			CID:        "cid-1",
+			FromStatus: models.CredentialStatusActive,
+			ToStatus:   models.CredentialStatusRevoked,
+			Timestamp:  now,
+		}
+
+		// When
+		err := repo.TransitionStatus(ctx, transition)
+
+		// Then
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+		history, _ := repo.FindStatusHistory(ctx, "cid-1")
+		if len(history) != 1 {
+			t.Errorf("Expected history to be unchanged, got %d entries", len(history))
+		}
+	})
+
+	t.Run("TransitionStatusNotFound", func(t *testing.T) {
+		transition := &models.CredentialStatusHistory{CID: "missing", Timestamp: now}
+		if err := repo.TransitionStatus(ctx, transition); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if _, err := repo.FindStatusHistory(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("EmptyStatusHistory", func(t *testing.T) {
+		history, err := repo.FindStatusHistory(ctx, "cid-2")
+		if err != nil || len(history) != 0 {
+			t.Errorf("Expected empty history, got %v (%v)", history, err)
+		}
+	})
+}
+
+func TestMemoryCredentialRepository(t *testing.T) {
+	testCredentialRepository(t, NewMemoryCredentialRepository())
+}
+
+func TestMemoryCredentialRepository_ReturnsCopies(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := NewMemoryCredentialRepository()
+	repo.Save(ctx, newTestCredential("cid-1", "nonce-1", time.Now()))
+
+	// When - mutate the returned value
+	found, _ := repo.FindByCID(ctx, "cid-1")
+	found.CredentialStatus = models.CredentialStatusRevoked
+
+	// Then - stored value is unchanged
+	again, _ := repo.FindByCID(ctx, "cid-1")
+	if again.CredentialStatus != models.CredentialStatusActive {
+		t.Errorf("Expected stored status ACTIVE, got %s", again.CredentialStatus)
+	}
+}
+
+// testStatusListRepository exercises the StatusListRepository contract
+func testStatusListRepository(t *testing.T, repo StatusListRepository) {
+	ctx := context.Background()
+	statusList := &models.StatusList{
+		CredentialType: "TestCredential",
+		GroupName:      "r0",
+		StatusListType: models.StatusListTypeRevocation,
+		EncodedList:    "uH4sI",
+		Content:        "header.payload.signature",
+		Version:        1,
+		LastUpdateTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("FindNotFound", func(t *testing.T) {
+		if _, err := repo.FindStatusList(ctx, "TestCredential", "r0"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		if err := repo.SaveStatusList(ctx, statusList); err != nil {
+			t.Fatalf("SaveStatusList failed: %v", err)
+		}
+
+		found, err := repo.FindStatusList(ctx, "TestCredential", "r0")
+		if err != nil {
+			t.Fatalf("FindStatusList failed: %v", err)
+		}
+		if *found != *statusList {
+			t.Errorf("Expected %+v, got %+v", statusList, found)
+		}
+
+		if err := repo.SaveStatusList(ctx, statusList); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+	})
+
+	t.Run("UpdateChecksVersion", func(t *testing.T) {
+		// Given
+		updated := *statusList
+		updated.Version = 2
+		updated.Content = "updated"
+
+		// When
+		if err := repo.SaveStatusList(ctx, &updated); err != nil {
+			t.Fatalf("SaveStatusList failed: %v", err)
+		}
+
+		// Then - a writer still holding version 1 conflicts
+		if err := repo.SaveStatusList(ctx, &updated); !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+
+		found, _ := repo.FindStatusList(ctx, "TestCredential", "r0")
+		if found.Version != 2 || found.Content != "updated" {
+			t.Errorf("Expected version 2, got %+v", found)
+		}
+	})
+}
+
+func TestMemoryStatusListRepository(t *testing.T) {
+	testStatusListRepository(t, NewMemoryStatusListRepository())
+}
+
+// testCredentialPolicyRepository exercises the CredentialPolicyRepository contract
+func testCredentialPolicyRepository(t *testing.T, repo CredentialPolicyRepository) {
+	ctx := context.Background()
+	policy := &models.CredentialPolicyEntity{
+		CredentialType:       "TestCredential",
+		IssuerIdentifier:     "did:example:issuer",
+		VCSchema:             `{"type":"object"}`,
+		SelectiveDisclosure:  `{"selective_disclosure":{"name":{"always_disclosed":true}}}`,
+		IssuanceDateDuration: 7,
+		IssuanceDateTimeUnit: models.TimeUnitDay,
+		ExpirationDuration:   1,
+		ExpirationTimeUnit:   models.TimeUnitYear,
+		SeedRotation:         models.SeedRotationPeriodic,
+		SeedValidityDuration: 1,
+		SeedValidityTimeUnit: models.TimeUnitYear,
+		PairwiseEnabled:      true,
+	}
+
+	t.Run("FindNotFound", func(t *testing.T) {
+		if _, err := repo.FindPolicy(ctx, "TestCredential"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("SaveAndFind", func(t *testing.T) {
+		if err := repo.SavePolicy(ctx, policy); err != nil {
+			t.Fatalf("SavePolicy failed: %v", err)
+		}
+
+		found, err := repo.FindPolicy(ctx, "TestCredential")
+		if err != nil {
+			t.Fatalf("FindPolicy failed: %v", err)
+		}
+		if *found != *policy {
+			t.Errorf("Expected %+v, got %+v", policy, found)
+		}
+	})
+
+	t.Run("SaveReplaces", func(t *testing.T) {
+		// Given
+		updated := *policy
+		updated.ExpirationDuration = 6
+		updated.ExpirationTimeUnit = models.TimeUnitMonth
+
+		// When
+		if err := repo.SavePolicy(ctx, &updated); err != nil {
+			t.Fatalf("SavePolicy failed: %v", err)
+		}
+
+		// Then
+		found, _ := repo.FindPolicy(ctx, "TestCredential")
+		if *found != updated {
+			t.Errorf("Expected %+v, got %+v", updated, found)
+		}
+	})
+}
+
+func TestMemoryCredentialPolicyRepository(t *testing.T) {
+	testCredentialPolicyRepository(t, NewMemoryCredentialPolicyRepository())
+}
+
+// testTicketRepository exercises the TicketRepository contract
+func testTicketRepository(t *testing.T, repo TicketRepository) {
+	ctx := context.Background()
+
+	t.Run("SequencePerCredentialType", func(t *testing.T) {
+		for _, expected := range []struct {
+			credentialType string
+			ticketNumber   int
+		}{
+			{"TestCredential", 1},
+			{"TestCredential", 2},
+			{"OtherCredential", 1},
+			{"TestCredential", 3},
+		} {
+			ticket, err := repo.TakeTicket(ctx, expected.credentialType)
+			if err != nil {
+				t.Fatalf("TakeTicket failed: %v", err)
+			}
+			if ticket.CredentialType != expected.credentialType || ticket.TicketNumber != expected.ticketNumber {
+				t.Errorf("Expected %s #%d, got %s #%d", expected.credentialType, expected.ticketNumber, ticket.CredentialType, ticket.TicketNumber)
+			}
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		// Given
+		const takers = 50
+		tickets := make(chan int, takers)
+		var wg sync.WaitGroup
+
+		// When
+		for i := 0; i < takers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticket, err := repo.TakeTicket(ctx, "ConcurrentCredential")
+				if err != nil {
+					t.Errorf("TakeTicket failed: %v", err)
+					return
+				}
+				tickets <- ticket.TicketNumber
+			}()
+		}
+		wg.Wait()
+		close(tickets)
+
+		// Then - every number from 1 to takers is handed out exactly once
+		seen := make(map[int]bool)
+		for ticketNumber := range tickets {
+			if seen[ticketNumber] {
+				t.Errorf("Ticket %d handed out twice", ticketNumber)
+			}
+			seen[ticketNumber] = true
+		}
+		for ticketNumber := 1; ticketNumber <= takers; ticketNumber++ {
+			if !seen[ticketNumber] {
+				t.Errorf("Ticket %d was never handed out", ticketNumber)
+			}
+		}
+	})
+}
+
+func TestMemoryTicketRepository(t *testing.T) {
+	testTicketRepository(t, NewMemoryTicketRepository())
+}
+
+// testOpaqueIDSeedRepository exercises the OpaqueIDSeedRepository contract
+func testOpaqueIDSeedRepository(t *testing.T, repo OpaqueIDSeedRepository) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+	validUntil := now.AddDate(1, 0, 0)
+
+	t.Run("FindNotFound", func(t *testing.T) {
+		if _, err := repo.FindLatestSeed(ctx, "holder-1", "TestCredential"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		seeds, err := repo.FindSeeds(ctx, "holder-1", "TestCredential")
+		if err != nil || len(seeds) != 0 {
+			t.Errorf("Expected no seeds, got %+v (%v)", seeds, err)
+		}
+	})
+
+	t.Run("SaveAndFind", func(t *testing.T) {
+		// Given
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "TestCredential", Epoch: 1, SeedCipher: "cipher-1", CreateTime: now, ValidUntil: &validUntil}
+
+		// When
+		if err := repo.SaveSeed(ctx, seed); err != nil {
+			t.Fatalf("SaveSeed failed: %v", err)
+		}
+		found, err := repo.FindLatestSeed(ctx, "holder-1", "TestCredential")
+
+		// Then
+		if err != nil {
+			t.Fatalf("FindLatestSeed failed: %v", err)
+		}
+		if found.Epoch != 1 || found.SeedCipher != "cipher-1" || !found.CreateTime.Equal(now) {
+			t.Errorf("Expected stored seed, got %+v", found)
+		}
+		if found.ValidUntil == nil || !found.ValidUntil.Equal(validUntil) || found.RetiredAt != nil {
+			t.Errorf("Expected valid until %v and not retired, got %+v", validUntil, found)
+		}
+	})
+
+	t.Run("SaveDuplicateEpochKeepsFirst", func(t *testing.T) {
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "TestCredential", Epoch: 1, SeedCipher: "cipher-2", CreateTime: now}
+		if err := repo.SaveSeed(ctx, seed); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+
+		found, err := repo.FindLatestSeed(ctx, "holder-1", "TestCredential")
+		if err != nil || found.SeedCipher != "cipher-1" {
+			t.Errorf("Expected the first seed to be kept, got %+v (%v)", found, err)
+		}
+	})
+
+	t.Run("Retire", func(t *testing.T) {
+		// Given
+		retiredAt := now.Add(time.Hour)
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "TestCredential", Epoch: 1,
+			RetiredAt: &retiredAt, RetireReason: models.StatusReasonKeyCompromise, RetiredBy: "admin"}
+
+		// When
+		if err := repo.RetireSeed(ctx, seed); err != nil {
+			t.Fatalf("RetireSeed failed: %v", err)
+		}
+
+		// Then - the seed is kept with its retirement recorded
+		found, err := repo.FindLatestSeed(ctx, "holder-1", "TestCredential")
+		if err != nil {
+			t.Fatalf("FindLatestSeed failed: %v", err)
+		}
+		if found.RetiredAt == nil || !found.RetiredAt.Equal(retiredAt) || found.RetireReason != models.StatusReasonKeyCompromise || found.RetiredBy != "admin" {
+			t.Errorf("Expected retirement to be recorded, got %+v", found)
+		}
+		if found.SeedCipher != "cipher-1" {
+			t.Errorf("Expected the retired seed cipher to be kept, got %s", found.SeedCipher)
+		}
+	})
+
+	t.Run("RetireTwice", func(t *testing.T) {
+		retiredAt := now.Add(2 * time.Hour)
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "TestCredential", Epoch: 1, RetiredAt: &retiredAt}
+		if err := repo.RetireSeed(ctx, seed); !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+
+		seed.Epoch = 9
+		if err := repo.RetireSeed(ctx, seed); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("NextEpoch", func(t *testing.T) {
+		// Given
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "TestCredential", Epoch: 2, SeedCipher: "cipher-2", CreateTime: now}
+
+		// When
+		if err := repo.SaveSeed(ctx, seed); err != nil {
+			t.Fatalf("SaveSeed failed: %v", err)
+		}
+
+		// Then
+		found, err := repo.FindLatestSeed(ctx, "holder-1", "TestCredential")
+		if err != nil || found.Epoch != 2 || found.ValidUntil != nil || found.RetiredAt != nil {
+			t.Errorf("Expected epoch 2 without expiry, got %+v (%v)", found, err)
+		}
+
+		seeds, err := repo.FindSeeds(ctx, "holder-1", "TestCredential")
+		if err != nil {
+			t.Fatalf("FindSeeds failed: %v", err)
+		}
+		if len(seeds) != 2 || seeds[0].Epoch != 1 || seeds[1].Epoch != 2 {
+			t.Errorf("Expected epochs 1 and 2 in order, got %+v", seeds)
+		}
+	})
+
+	t.Run("SeedPerCredentialType", func(t *testing.T) {
+		seed := &models.OpaqueIDSeed{HolderKey: "holder-1", CredentialType: "OtherCredential", Epoch: 1, SeedCipher: "cipher-3", CreateTime: now}
+		if err := repo.SaveSeed(ctx, seed); err != nil {
+			t.Errorf("SaveSeed failed: %v", err)
+		}
+
+		found, err := repo.FindLatestSeed(ctx, "holder-1", "OtherCredential")
+		if err != nil || found.SeedCipher != "cipher-3" {
+			t.Errorf("Expected the other credential type's seed, got %+v (%v)", found, err)
+		}
+	})
+
+}
+
+func TestMemoryOpaqueIDSeedRepository(t *testing.T) {
+	testOpaqueIDSeedRepository(t, NewMemoryOpaqueIDSeedRepository())
+}
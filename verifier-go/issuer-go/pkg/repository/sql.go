@@ -0,0 +1,539 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+// SQLCredentialRepository is a CredentialRepository backed by database/sql.
+// The SQL targets SQLite through github.com/mattn/go-sqlite3 (registered as
+// "sqlite3", requires cgo), the driver the repository is tested against.
+type SQLCredentialRepository struct {
+	db *sql.DB
+}
+
+// NewSQLCredentialRepository creates a SQL credential repository and applies pending migrations
+func NewSQLCredentialRepository(ctx context.Context, db *sql.DB) (*SQLCredentialRepository, error) {
+	if err := Migrate(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return &SQLCredentialRepository{db: db}, nil
+}
+
+const credentialColumns = `cid, credential_type, credential_subject_id, issuance_date, expiration_date,
+	content, ticket_number, last_update_time, credential_status, nonce`
+
+// Save stores a newly issued credential
+func (r *SQLCredentialRepository) Save(ctx context.Context, credential *models.Credential) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO credential (`+credentialColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		credential.CID,
+		credential.CredentialType,
+		credential.CredentialSubjectID,
+		formatTime(credential.IssuanceDate),
+		formatTime(credential.ExpirationDate),
+		credential.Content,
+		credential.TicketNumber,
+		formatTime(credential.LastUpdateTime),
+		credential.CredentialStatus,
+		credential.Nonce,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to insert credential: %w", err)
+	}
+
+	return nil
+}
+
+// FindByCID returns the credential with the given CID
+func (r *SQLCredentialRepository) FindByCID(ctx context.Context, cid string) (*models.Credential, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+credentialColumns+` FROM credential WHERE cid = ?`, cid)
+	return scanCredential(row)
+}
+
+// FindLatestByNonce returns the most recently issued credential with the given nonce
+// Equivalent to Java's CredentialRepository.findTopByNonceOrderByIssuanceDateDesc()
+func (r *SQLCredentialRepository) FindLatestByNonce(ctx context.Context, nonce string) (*models.Credential, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+credentialColumns+` FROM credential WHERE nonce = ? ORDER BY issuance_date DESC LIMIT 1`, nonce)
+	return scanCredential(row)
+}
+
+// TransitionStatus moves a credential to a new status and records the transition
+// Equivalent to Java's CredentialRepository.updateStatusByCid(), guarded by the expected current status
+func (r *SQLCredentialRepository) TransitionStatus(ctx context.Context, transition *models.CredentialStatusHistory) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE credential SET credential_status = ?, last_update_time = ? WHERE cid = ? AND credential_status = ?`,
+		transition.ToStatus, formatTime(transition.Timestamp), transition.CID, transition.FromStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update credential status: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update credential status: %w", err)
+	}
+	if affected == 0 {
+		var count int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM credential WHERE cid = ?`, transition.CID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to query credential: %w", err)
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO credential_status_history (cid, from_status, to_status, reason_code, actor, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		transition.CID, transition.FromStatus, transition.ToStatus,
+		transition.ReasonCode, transition.Actor, formatTime(transition.Timestamp)); err != nil {
+		return fmt.Errorf("failed to insert status history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit status transition: %w", err)
+	}
+
+	return nil
+}
+
+// FindStatusHistory returns the status transitions of a credential, oldest first
+func (r *SQLCredentialRepository) FindStatusHistory(ctx context.Context, cid string) ([]models.CredentialStatusHistory, error) {
+	if _, err := r.FindByCID(ctx, cid); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT cid, from_status, to_status, reason_code, actor, changed_at
+		FROM credential_status_history WHERE cid = ? ORDER BY id`, cid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.CredentialStatusHistory{}
+	for rows.Next() {
+		var (
+			entry     models.CredentialStatusHistory
+			changedAt string
+		)
+		if err := rows.Scan(&entry.CID, &entry.FromStatus, &entry.ToStatus,
+			&entry.ReasonCode, &entry.Actor, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		if entry.Timestamp, err = parseTime(changedAt); err != nil {
+			return nil, fmt.Errorf("invalid changed_at: %w", err)
+		}
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+
+	return history, nil
+}
+
+// SQLStatusListRepository is a StatusListRepository backed by database/sql
+type SQLStatusListRepository struct {
+	db *sql.DB
+}
+
+// NewSQLStatusListRepository creates a SQL status list repository and applies pending migrations
+func NewSQLStatusListRepository(ctx context.Context, db *sql.DB) (*SQLStatusListRepository, error) {
+	if err := Migrate(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return &SQLStatusListRepository{db: db}, nil
+}
+
+// FindStatusList returns the latest status list of a credential type and group name
+// Equivalent to Java's StatusListRepository.queryLatestStatusList()
+func (r *SQLStatusListRepository) FindStatusList(ctx context.Context, credentialType, groupName string) (*models.StatusList, error) {
+	var (
+		statusList     models.StatusList
+		lastUpdateTime string
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT credential_type, group_name, status_list_type, encoded_list, content, version, last_update_time
+		FROM status_list WHERE credential_type = ? AND group_name = ?`, credentialType, groupName).Scan(
+		&statusList.CredentialType,
+		&statusList.GroupName,
+		&statusList.StatusListType,
+		&statusList.EncodedList,
+		&statusList.Content,
+		&statusList.Version,
+		&lastUpdateTime,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status list: %w", err)
+	}
+
+	if statusList.LastUpdateTime, err = parseTime(lastUpdateTime); err != nil {
+		return nil, fmt.Errorf("invalid last_update_time: %w", err)
+	}
+
+	return &statusList, nil
+}
+
+// SaveStatusList creates or replaces a status list, checking its version
+func (r *SQLStatusListRepository) SaveStatusList(ctx context.Context, statusList *models.StatusList) error {
+	if statusList.Version <= 1 {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT INTO status_list (credential_type, group_name, status_list_type, encoded_list, content, version, last_update_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			statusList.CredentialType, statusList.GroupName, statusList.StatusListType,
+			statusList.EncodedList, statusList.Content, statusList.Version, formatTime(statusList.LastUpdateTime))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrDuplicate
+			}
+			return fmt.Errorf("failed to insert status list: %w", err)
+		}
+		return nil
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE status_list SET encoded_list = ?, content = ?, version = ?, last_update_time = ?
+		WHERE credential_type = ? AND group_name = ? AND version = ?`,
+		statusList.EncodedList, statusList.Content, statusList.Version, formatTime(statusList.LastUpdateTime),
+		statusList.CredentialType, statusList.GroupName, statusList.Version-1)
+	if err != nil {
+		return fmt.Errorf("failed to update status list: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update status list: %w", err)
+	}
+	if affected == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// SQLCredentialPolicyRepository is a CredentialPolicyRepository backed by database/sql
+type SQLCredentialPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewSQLCredentialPolicyRepository creates a SQL policy repository and applies pending migrations
+func NewSQLCredentialPolicyRepository(ctx context.Context, db *sql.DB) (*SQLCredentialPolicyRepository, error) {
+	if err := Migrate(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return &SQLCredentialPolicyRepository{db: db}, nil
+}
+
+// FindPolicy returns the policy of a credential type
+// Equivalent to Java's CredentialPolicyRepository.findByCredentialType()
+func (r *SQLCredentialPolicyRepository) FindPolicy(ctx context.Context, credentialType string) (*models.CredentialPolicyEntity, error) {
+	var policy models.CredentialPolicyEntity
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT credential_type, issuer_identifier, issuer_metadata, vc_schema, vc_data_source,
+			issuance_date_duration, issuance_date_time_unit, expiration_duration, expiration_time_unit, func_switch,
+			selective_disclosure, seed_rotation, seed_validity_duration, seed_validity_time_unit, pairwise_enabled
+		FROM credential_policy WHERE credential_type = ?`, credentialType).Scan(
+		&policy.CredentialType,
+		&policy.IssuerIdentifier,
+		&policy.IssuerMetadata,
+		&policy.VCSchema,
+		&policy.VCDataSource,
+		&policy.IssuanceDateDuration,
+		&policy.IssuanceDateTimeUnit,
+		&policy.ExpirationDuration,
+		&policy.ExpirationTimeUnit,
+		&policy.FuncSwitch,
+		&policy.SelectiveDisclosure,
+		&policy.SeedRotation,
+		&policy.SeedValidityDuration,
+		&policy.SeedValidityTimeUnit,
+		&policy.PairwiseEnabled,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credential policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces the policy of policy.CredentialType
+func (r *SQLCredentialPolicyRepository) SavePolicy(ctx context.Context, policy *models.CredentialPolicyEntity) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO credential_policy (credential_type, issuer_identifier, issuer_metadata, vc_schema, vc_data_source,
+			issuance_date_duration, issuance_date_time_unit, expiration_duration, expiration_time_unit, func_switch,
+			selective_disclosure, seed_rotation, seed_validity_duration, seed_validity_time_unit, pairwise_enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (credential_type) DO UPDATE SET
+			issuer_identifier = excluded.issuer_identifier,
+			issuer_metadata = excluded.issuer_metadata,
+			vc_schema = excluded.vc_schema,
+			vc_data_source = excluded.vc_data_source,
+			issuance_date_duration = excluded.issuance_date_duration,
+			issuance_date_time_unit = excluded.issuance_date_time_unit,
+			expiration_duration = excluded.expiration_duration,
+			expiration_time_unit = excluded.expiration_time_unit,
+			func_switch = excluded.func_switch,
+			selective_disclosure = excluded.selective_disclosure,
+			seed_rotation = excluded.seed_rotation,
+			seed_validity_duration = excluded.seed_validity_duration,
+			seed_validity_time_unit = excluded.seed_validity_time_unit,
+			pairwise_enabled = excluded.pairwise_enabled`,
+		policy.CredentialType, policy.IssuerIdentifier, policy.IssuerMetadata, policy.VCSchema, policy.VCDataSource,
+		policy.IssuanceDateDuration, policy.IssuanceDateTimeUnit, policy.ExpirationDuration, policy.ExpirationTimeUnit, policy.FuncSwitch,
+		policy.SelectiveDisclosure, policy.SeedRotation, policy.SeedValidityDuration, policy.SeedValidityTimeUnit,
+		policy.PairwiseEnabled)
+	if err != nil {
+		return fmt.Errorf("failed to save credential policy: %w", err)
+	}
+
+	return nil
+}
+
+// SQLTicketRepository is a TicketRepository backed by database/sql
+type SQLTicketRepository struct {
+	db *sql.DB
+}
+
+// NewSQLTicketRepository creates a SQL ticket repository and applies pending migrations
+func NewSQLTicketRepository(ctx context.Context, db *sql.DB) (*SQLTicketRepository, error) {
+	if err := Migrate(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return &SQLTicketRepository{db: db}, nil
+}
+
+// TakeTicket increments and returns the sequence of a credential type in a
+// single upsert, so concurrent callers and processes never share a number
+// Equivalent to Java's TicketRepository.takeTicket()
+func (r *SQLTicketRepository) TakeTicket(ctx context.Context, credentialType string) (*models.Ticket, error) {
+	now := time.Now()
+
+	var ticketNumber int
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO ticket (credential_type, ticket_number, last_update_time) VALUES (?, 1, ?)
+		ON CONFLICT (credential_type) DO UPDATE SET
+			ticket_number = ticket.ticket_number + 1,
+			last_update_time = excluded.last_update_time
+		RETURNING ticket_number`,
+		credentialType, formatTime(now)).Scan(&ticketNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take ticket: %w", err)
+	}
+
+	return &models.Ticket{
+		CredentialType: credentialType,
+		TicketNumber:   ticketNumber,
+		LastUpdateTime: now,
+	}, nil
+}
+
+// SQLOpaqueIDSeedRepository is an OpaqueIDSeedRepository backed by database/sql
+type SQLOpaqueIDSeedRepository struct {
+	db *sql.DB
+}
+
+// NewSQLOpaqueIDSeedRepository creates a SQL seed repository and applies
+// pending migrations, rewriting stored seeds with keys
+func NewSQLOpaqueIDSeedRepository(ctx context.Context, db *sql.DB, keys SeedMigrationKeys) (*SQLOpaqueIDSeedRepository, error) {
+	if err := migrate(ctx, db, keys); err != nil {
+		return nil, err
+	}
+
+	return &SQLOpaqueIDSeedRepository{db: db}, nil
+}
+
+// seedColumns are the columns scanned by scanSeed
+const seedColumns = `holder_key, credential_type, epoch, seed_cipher, create_time, valid_until, retired_at, retire_reason, retired_by`
+
+// FindLatestSeed returns the highest epoch of a holder and credential type
+func (r *SQLOpaqueIDSeedRepository) FindLatestSeed(ctx context.Context, holderKey, credentialType string) (*models.OpaqueIDSeed, error) {
+	seed, err := scanSeed(r.db.QueryRowContext(ctx,
+		`SELECT `+seedColumns+` FROM opaque_id_seed WHERE holder_key = ? AND credential_type = ?
+		ORDER BY epoch DESC LIMIT 1`, holderKey, credentialType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query opaque ID seed: %w", err)
+	}
+
+	return seed, nil
+}
+
+// FindSeeds returns every epoch of a holder and credential type, oldest first
+func (r *SQLOpaqueIDSeedRepository) FindSeeds(ctx context.Context, holderKey, credentialType string) ([]models.OpaqueIDSeed, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+seedColumns+` FROM opaque_id_seed WHERE holder_key = ? AND credential_type = ?
+		ORDER BY epoch`, holderKey, credentialType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query opaque ID seeds: %w", err)
+	}
+	defer rows.Close()
+
+	seeds := []models.OpaqueIDSeed{}
+	for rows.Next() {
+		seed, err := scanSeed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query opaque ID seeds: %w", err)
+		}
+		seeds = append(seeds, *seed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query opaque ID seeds: %w", err)
+	}
+
+	return seeds, nil
+}
+
+// SaveSeed stores a new epoch. The primary key keeps the first seed when
+// processes race to create the same epoch.
+func (r *SQLOpaqueIDSeedRepository) SaveSeed(ctx context.Context, seed *models.OpaqueIDSeed) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO opaque_id_seed (holder_key, credential_type, epoch, seed_cipher, create_time, valid_until)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		seed.HolderKey, seed.CredentialType, seed.Epoch, seed.SeedCipher, formatTime(seed.CreateTime), formatOptionalTime(seed.ValidUntil))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to save opaque ID seed: %w", err)
+	}
+
+	return nil
+}
+
+// RetireSeed records the retirement of an epoch. The update only matches an
+// unretired epoch, so concurrent rotations retire it once.
+func (r *SQLOpaqueIDSeedRepository) RetireSeed(ctx context.Context, seed *models.OpaqueIDSeed) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE opaque_id_seed SET retired_at = ?, retire_reason = ?, retired_by = ?
+		WHERE holder_key = ? AND credential_type = ? AND epoch = ? AND retired_at = ''`,
+		formatOptionalTime(seed.RetiredAt), seed.RetireReason, seed.RetiredBy, seed.HolderKey, seed.CredentialType, seed.Epoch)
+	if err != nil {
+		return fmt.Errorf("failed to retire opaque ID seed: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retire opaque ID seed: %w", err)
+	}
+	if updated == 0 {
+		var count int
+		if err := r.db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM opaque_id_seed WHERE holder_key = ? AND credential_type = ? AND epoch = ?`,
+			seed.HolderKey, seed.CredentialType, seed.Epoch).Scan(&count); err != nil {
+			return fmt.Errorf("failed to retire opaque ID seed: %w", err)
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// scanSeed reads a single opaque_id_seed row
+func scanSeed(row interface{ Scan(...any) error }) (*models.OpaqueIDSeed, error) {
+	var (
+		seed                              models.OpaqueIDSeed
+		createTime, validUntil, retiredAt string
+	)
+
+	if err := row.Scan(&seed.HolderKey, &seed.CredentialType, &seed.Epoch, &seed.SeedCipher,
+		&createTime, &validUntil, &retiredAt, &seed.RetireReason, &seed.RetiredBy); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if seed.CreateTime, err = parseTime(createTime); err != nil {
+		return nil, fmt.Errorf("invalid create_time: %w", err)
+	}
+	if seed.ValidUntil, err = parseOptionalTime(validUntil); err != nil {
+		return nil, fmt.Errorf("invalid valid_until: %w", err)
+	}
+	if seed.RetiredAt, err = parseOptionalTime(retiredAt); err != nil {
+		return nil, fmt.Errorf("invalid retired_at: %w", err)
+	}
+
+	return &seed, nil
+}
+
+// scanCredential reads a single credential row
+func scanCredential(row *sql.Row) (*models.Credential, error) {
+	var (
+		credential                                   models.Credential
+		issuanceDate, expirationDate, lastUpdateTime string
+	)
+
+	err := row.Scan(
+		&credential.CID,
+		&credential.CredentialType,
+		&credential.CredentialSubjectID,
+		&issuanceDate,
+		&expirationDate,
+		&credential.Content,
+		&credential.TicketNumber,
+		&lastUpdateTime,
+		&credential.CredentialStatus,
+		&credential.Nonce,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credential: %w", err)
+	}
+
+	if credential.IssuanceDate, err = parseTime(issuanceDate); err != nil {
+		return nil, fmt.Errorf("invalid issuance_date: %w", err)
+	}
+	if credential.ExpirationDate, err = parseTime(expirationDate); err != nil {
+		return nil, fmt.Errorf("invalid expiration_date: %w", err)
+	}
+	if credential.LastUpdateTime, err = parseTime(lastUpdateTime); err != nil {
+		return nil, fmt.Errorf("invalid last_update_time: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// isUniqueViolation reports whether err is a primary key / unique constraint violation.
+// Matched on the message so the package does not depend on a specific driver.
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") ||
+		strings.Contains(msg, "PRIMARY KEY constraint failed")
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Failed to open database (github.com/mattn/go-sqlite3 requires CGO_ENABLED=1): %v", err)
+	}
+
+	return db
+}
+
+func TestSQLCredentialRepository(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+
+	repo, err := NewSQLCredentialRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	testCredentialRepository(t, repo)
+}
+
+func TestSQLCredentialRepository_PersistsAcrossReopen(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "issuer.db")
+
+	db := openTestDB(t, path)
+	repo, err := NewSQLCredentialRepository(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if err := repo.Save(ctx, newTestCredential("cid-1", "nonce-1", time.Now())); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	db.Close()
+
+	// When - reopen, which also re-runs migrations
+	reopened, err := NewSQLCredentialRepository(ctx, openTestDB(t, path))
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+
+	// Then
+	found, err := reopened.FindByCID(ctx, "cid-1")
+	if err != nil {
+		t.Fatalf("Expected credential after reopen, got %v", err)
+	}
+	if found.CredentialStatus != models.CredentialStatusActive {
+		t.Errorf("Expected status ACTIVE, got %s", found.CredentialStatus)
+	}
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+
+	// When
+	for i := 0; i < 2; i++ {
+		if err := Migrate(ctx, db); err != nil {
+			t.Fatalf("Migrate run %d failed: %v", i+1, err)
+		}
+	}
+
+	// Then
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count migrations: %v", err)
+	}
+	if count != len(migrations) {
+		t.Errorf("Expected %d applied migrations, got %d", len(migrations), count)
+	}
+}
+
+func TestSQLStatusListRepository(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+
+	repo, err := NewSQLStatusListRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	testStatusListRepository(t, repo)
+}
+
+func TestSQLCredentialPolicyRepository(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+
+	repo, err := NewSQLCredentialPolicyRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	testCredentialPolicyRepository(t, repo)
+}
+
+func TestSQLOpaqueIDSeedRepository(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+
+	repo, err := NewSQLOpaqueIDSeedRepository(context.Background(), db, SeedMigrationKeys{})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	testOpaqueIDSeedRepository(t, repo)
+}
+
+func TestSQLTicketRepository(t *testing.T) {
+	// Concurrent writers on separate connections wait for the SQLite write lock
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db")+"?_busy_timeout=5000")
+
+	repo, err := NewSQLTicketRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	testTicketRepository(t, repo)
+}
+
+func TestSQLTicketRepository_ContinuesAfterReopen(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "issuer.db")
+
+	db := openTestDB(t, path)
+	repo, err := NewSQLTicketRepository(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := repo.TakeTicket(ctx, "TestCredential"); err != nil {
+			t.Fatalf("TakeTicket failed: %v", err)
+		}
+	}
+	db.Close()
+
+	// When
+	reopened, err := NewSQLTicketRepository(ctx, openTestDB(t, path))
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	ticket, err := reopened.TakeTicket(ctx, "TestCredential")
+
+	// Then - numbers handed out before the restart are not reused
+	if err != nil {
+		t.Fatalf("TakeTicket failed: %v", err)
+	}
+	if ticket.TicketNumber != 3 {
+		t.Errorf("Expected ticket 3, got %d", ticket.TicketNumber)
+	}
+}
+
+// migrateTo applies the migrations up to version
+func migrateTo(t *testing.T, db *sql.DB, version int) {
+	t.Helper()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create schema_migrations: %v", err)
+	}
+	for _, m := range migrations {
+		if m.version > version {
+			break
+		}
+		if err := applyMigration(ctx, db, m, SeedMigrationKeys{}); err != nil {
+			t.Fatalf("Migration %d failed: %v", m.version, err)
+		}
+	}
+}
+
+func TestMigrate_SeedsBecomeEpochOne(t *testing.T) {
+	// Given - a database whose seeds were stored before seed epochs
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+	migrateTo(t, db, 7)
+	if _, err := db.ExecContext(ctx, `INSERT INTO opaque_id_seed (holder_uid, credential_type, seed_cipher, create_time) VALUES (?, ?, ?, ?)`,
+		"holder-1", "TestCredential", "cipher-1", formatTime(time.Now())); err != nil {
+		t.Fatalf("Failed to insert seed: %v", err)
+	}
+
+	// When - migrated without the key-encryption key
+	err := Migrate(ctx, db)
+
+	// Then - the seeds cannot be re-encrypted
+	if err == nil {
+		t.Fatal("Expected the migration to fail without the key-encryption key")
+	}
+
+	// When - migrated with it
+	repo, err := NewSQLOpaqueIDSeedRepository(ctx, db, SeedMigrationKeys{
+		BindEpoch: func(seedCipher, holderUID, credentialType string) (string, error) {
+			return seedCipher + "/" + holderUID + "/" + credentialType + "/1", nil
+		},
+		HolderKey: func(holderUID string) string { return "key-of-" + holderUID },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	// Then - the seed is an unretired epoch 1 seed bound to its epoch
+	seed, err := repo.FindLatestSeed(ctx, "key-of-holder-1", "TestCredential")
+	if err != nil {
+		t.Fatalf("FindLatestSeed failed: %v", err)
+	}
+	if seed.Epoch != 1 || seed.SeedCipher != "cipher-1/holder-1/TestCredential/1" || seed.ValidUntil != nil || seed.RetiredAt != nil {
+		t.Errorf("Expected an unretired epoch 1 seed, got %+v", seed)
+	}
+}
+
+func TestMigrate_SeedHolderKey(t *testing.T) {
+	// Given - a database whose seeds were stored under raw holder UIDs
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+	migrateTo(t, db, 8)
+	for _, seed := range []struct {
+		holderUID string
+		epoch     int
+	}{{"holder-1", 1}, {"holder-1", 2}, {"holder-2", 1}} {
+		if _, err := db.ExecContext(ctx, `INSERT INTO opaque_id_seed (holder_uid, credential_type, epoch, seed_cipher, create_time) VALUES (?, ?, ?, ?, ?)`,
+			seed.holderUID, "TestCredential", seed.epoch, "cipher-"+seed.holderUID, formatTime(time.Now())); err != nil {
+			t.Fatalf("Failed to insert seed: %v", err)
+		}
+	}
+
+	// When - migrated without the holder key pepper
+	err := Migrate(ctx, db)
+
+	// Then - the raw holder UIDs cannot be re-keyed
+	if err == nil {
+		t.Fatal("Expected the migration to fail without the holder key pepper")
+	}
+
+	// When - migrated with it
+	repo, err := NewSQLOpaqueIDSeedRepository(ctx, db, SeedMigrationKeys{
+		HolderKey: func(holderUID string) string { return "key-of-" + holderUID },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	// Then - every epoch moved to its holder key, and no raw holder UID is left
+	if seeds, err := repo.FindSeeds(ctx, "key-of-holder-1", "TestCredential"); err != nil || len(seeds) != 2 || seeds[1].SeedCipher != "cipher-holder-1" {
+		t.Errorf("Expected epochs 1 and 2 under the holder key, got %+v (%v)", seeds, err)
+	}
+	if _, err := repo.FindLatestSeed(ctx, "key-of-holder-2", "TestCredential"); err != nil {
+		t.Errorf("Expected the other holder's seed under its holder key, got %v", err)
+	}
+	var raw int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM opaque_id_seed WHERE holder_key NOT LIKE 'key-of-%'`).Scan(&raw); err != nil || raw != 0 {
+		t.Errorf("Expected no seed under a raw holder UID, got %d (%v)", raw, err)
+	}
+}
+
+func TestMigrate_ExistingPoliciesKeepPairwiseSeeds(t *testing.T) {
+	// Given - a policy stored before pairwise seeds became opt-in
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "issuer.db"))
+	migrateTo(t, db, 8)
+	if _, err := db.ExecContext(ctx, `INSERT INTO credential_policy (credential_type, expiration_duration, expiration_time_unit) VALUES (?, ?, ?)`,
+		"TestCredential", 1, "year"); err != nil {
+		t.Fatalf("Failed to insert policy: %v", err)
+	}
+
+	// When
+	repo, err := NewSQLCredentialPolicyRepository(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	// Then - the credential type still gets pairwise seeds
+	policy, err := repo.FindPolicy(ctx, "TestCredential")
+	if err != nil {
+		t.Fatalf("Failed to find policy: %v", err)
+	}
+	if !policy.PairwiseEnabled {
+		t.Error("Expected an existing policy to keep pairwise seeds enabled")
+	}
+}
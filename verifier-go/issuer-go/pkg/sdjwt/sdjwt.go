@@ -0,0 +1,256 @@
+package sdjwt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SD-JWT constants (RFC 9901)
+const (
+	HashAlgorithm = "sha-256"   // value of _sd_alg
+	JWTType       = "vc+sd-jwt" // typ header of an SD-JWT VC
+	Separator     = "~"
+
+	// Reserved claim names
+	DigestsClaim       = "_sd"
+	HashAlgorithmClaim = "_sd_alg"
+	ArrayElementClaim  = "..."
+
+	saltSize = 16 // 128-bit salts
+)
+
+// Disclosure is a salted claim (or array element) disclosed next to the SD-JWT
+// Equivalent to Java's com.authlete.sd.Disclosure
+type Disclosure struct {
+	Salt    string
+	Name    string // empty for an array element
+	Value   interface{}
+	Encoded string // base64url of the JSON array [salt, name, value] or [salt, value]
+}
+
+// NewDisclosure creates a disclosure of an object property with a random salt
+func NewDisclosure(name string, value interface{}) (*Disclosure, error) {
+	return newDisclosure([]interface{}{name, value}, name, value)
+}
+
+// NewArrayElementDisclosure creates a disclosure of an array element with a random salt
+func NewArrayElementDisclosure(value interface{}) (*Disclosure, error) {
+	return newDisclosure([]interface{}{value}, "", value)
+}
+
+func newDisclosure(content []interface{}, name string, value interface{}) (*Disclosure, error) {
+	b := make([]byte, saltSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	salt := base64.RawURLEncoding.EncodeToString(b)
+
+	encoded, err := marshal(append([]interface{}{salt}, content...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode disclosure: %w", err)
+	}
+
+	return &Disclosure{
+		Salt:    salt,
+		Name:    name,
+		Value:   value,
+		Encoded: base64.RawURLEncoding.EncodeToString(encoded),
+	}, nil
+}
+
+// Digest returns the base64url encoded SHA-256 digest of the disclosure
+func (d *Disclosure) Digest() string {
+	return Digest(d.Encoded)
+}
+
+// Digest returns the base64url encoded SHA-256 digest of an encoded disclosure or SD-JWT
+func Digest(encoded string) string {
+	sum := sha256.Sum256([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Format combines an issuer-signed JWT and its disclosures into an SD-JWT,
+// ex: <jwt>~<disclosure 1>~<disclosure 2>~
+func Format(issuerJWT string, disclosures []*Disclosure) string {
+	var sb strings.Builder
+	sb.WriteString(issuerJWT)
+	sb.WriteString(Separator)
+	for _, d := range disclosures {
+		sb.WriteString(d.Encoded)
+		sb.WriteString(Separator)
+	}
+	return sb.String()
+}
+
+// ClaimSetting configures the disclosure of a single claim
+type ClaimSetting struct {
+	// AlwaysDisclosed keeps the claim (or, for an array, its elements) in
+	// plain text instead of making it selectively disclosable
+	AlwaysDisclosed bool `json:"always_disclosed"`
+}
+
+// Config is the selective disclosure setting of a credential type, keyed by
+// claim path; nested properties are joined with ".", ex: "address.country".
+// Claims not listed are selectively disclosable.
+type Config struct {
+	SelectiveDisclosure map[string]ClaimSetting `json:"selective_disclosure"`
+}
+
+// ParseConfig parses a selective disclosure setting, ex:
+//
+//	{"selective_disclosure": {"name": {"always_disclosed": true}, "opaque_id_seed": {"always_disclosed": false}}}
+func ParseConfig(data string) (*Config, error) {
+	config := &Config{}
+	if err := json.Unmarshal([]byte(data), config); err != nil {
+		return nil, fmt.Errorf("invalid selective disclosure setting: %w", err)
+	}
+
+	for path := range config.SelectiveDisclosure {
+		for _, name := range strings.Split(path, ".") {
+			if name == "" || isReserved(name) {
+				return nil, fmt.Errorf("invalid claim path in selective disclosure setting: %q", path)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// AlwaysDisclosed reports whether the claim at path stays in plain text
+func (c *Config) AlwaysDisclosed(path string) bool {
+	if c == nil {
+		return false
+	}
+	return c.SelectiveDisclosure[path].AlwaysDisclosed
+}
+
+// Encode replaces the selectively disclosable claims of an object with
+// digests in "_sd" arrays (array elements with {"...": digest}), nested
+// objects and arrays included, and returns the disclosures. No decoy digests
+// are added.
+// Equivalent to Java's SDObjectEncoder.encode()
+func (c *Config) Encode(claims map[string]interface{}) (map[string]interface{}, []*Disclosure, error) {
+	// Normalize to JSON types so nested maps and slices are recognised
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var normalized map[string]interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	e := &encoder{config: c}
+	encoded, err := e.encodeObject(normalized, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, e.disclosures, nil
+}
+
+// encoder collects the disclosures of a single Encode call
+type encoder struct {
+	config      *Config
+	disclosures []*Disclosure
+}
+
+func (e *encoder) encodeObject(obj map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		if isReserved(name) {
+			return nil, fmt.Errorf("claim name %q is reserved", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]interface{}, len(obj))
+	var digests []string
+	for _, name := range names {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		value, err := e.encodeValue(obj[name], path)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.config.AlwaysDisclosed(path) {
+			result[name] = value
+			continue
+		}
+
+		d, err := NewDisclosure(name, value)
+		if err != nil {
+			return nil, err
+		}
+		e.disclosures = append(e.disclosures, d)
+		digests = append(digests, d.Digest())
+	}
+
+	if len(digests) > 0 {
+		sort.Strings(digests)
+		result[DigestsClaim] = digests
+	}
+	return result, nil
+}
+
+func (e *encoder) encodeArray(arr []interface{}, path string) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(arr))
+	for _, element := range arr {
+		value, err := e.encodeValue(element, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.config.AlwaysDisclosed(path) {
+			result = append(result, value)
+			continue
+		}
+
+		d, err := NewArrayElementDisclosure(value)
+		if err != nil {
+			return nil, err
+		}
+		e.disclosures = append(e.disclosures, d)
+		result = append(result, map[string]interface{}{ArrayElementClaim: d.Digest()})
+	}
+	return result, nil
+}
+
+func (e *encoder) encodeValue(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return e.encodeObject(v, path)
+	case []interface{}:
+		return e.encodeArray(v, path)
+	default:
+		return v, nil
+	}
+}
+
+// isReserved reports whether name cannot be used as a claim name
+func isReserved(name string) bool {
+	return name == DigestsClaim || name == HashAlgorithmClaim || name == ArrayElementClaim
+}
+
+// marshal encodes v as JSON without escaping HTML characters
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
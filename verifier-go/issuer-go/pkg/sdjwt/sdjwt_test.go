@@ -0,0 +1,224 @@
+package sdjwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+// decodeDisclosure decodes an encoded disclosure into its JSON array
+func decodeDisclosure(t *testing.T, encoded string) []interface{} {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode disclosure: %v", err)
+	}
+	var content []interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatalf("Failed to parse disclosure: %v", err)
+	}
+	return content
+}
+
+// disclosuresByDigest indexes disclosures by their digest
+func disclosuresByDigest(disclosures []*Disclosure) map[string]*Disclosure {
+	result := make(map[string]*Disclosure, len(disclosures))
+	for _, d := range disclosures {
+		result[d.Digest()] = d
+	}
+	return result
+}
+
+func TestDigest(t *testing.T) {
+	// Given - the family_name disclosure of the SD-JWT specification example
+	encoded := "WyI2cU1RdlJMNWhhaiIsICJmYW1pbHlfbmFtZSIsICJNw7ZiaXVzIl0"
+
+	// When
+	digest := Digest(encoded)
+
+	// Then
+	if digest != "uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY" {
+		t.Errorf("Expected specification digest, got %s", digest)
+	}
+}
+
+func TestNewDisclosure(t *testing.T) {
+	t.Run("Object property", func(t *testing.T) {
+		d, err := NewDisclosure("family_name", "Möbius")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		content := decodeDisclosure(t, d.Encoded)
+		if len(content) != 3 || content[0] != d.Salt || content[1] != "family_name" || content[2] != "Möbius" {
+			t.Errorf("Expected [salt, name, value], got %v", content)
+		}
+		if salt, err := base64.RawURLEncoding.DecodeString(d.Salt); err != nil || len(salt) != saltSize {
+			t.Errorf("Expected a %d byte base64url salt, got %q", saltSize, d.Salt)
+		}
+	})
+
+	t.Run("Array element", func(t *testing.T) {
+		d, err := NewArrayElementDisclosure("DE")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		content := decodeDisclosure(t, d.Encoded)
+		if len(content) != 2 || content[0] != d.Salt || content[1] != "DE" {
+			t.Errorf("Expected [salt, value], got %v", content)
+		}
+	})
+
+	t.Run("Salts are unique", func(t *testing.T) {
+		d1, _ := NewDisclosure("name", "value")
+		d2, _ := NewDisclosure("name", "value")
+		if d1.Salt == d2.Salt || d1.Digest() == d2.Digest() {
+			t.Error("Expected different salts and digests")
+		}
+	})
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		wantErr bool
+	}{
+		{"Valid", `{"selective_disclosure": {"name": {"always_disclosed": true}, "address.country": {}}}`, false},
+		{"Empty", `{}`, false},
+		{"Invalid JSON", `{"selective_disclosure":`, true},
+		{"Reserved claim name", `{"selective_disclosure": {"_sd": {"always_disclosed": true}}}`, true},
+		{"Reserved nested claim name", `{"selective_disclosure": {"address...": {}}}`, true},
+		{"Empty path segment", `{"selective_disclosure": {"address.": {}}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.setting)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// Given
+	config, err := ParseConfig(`{"selective_disclosure": {
+		"name": {"always_disclosed": true},
+		"address": {"always_disclosed": true},
+		"address.country": {"always_disclosed": true},
+		"tags": {"always_disclosed": true}
+	}}`)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	claims := map[string]interface{}{
+		"name":        "Alice",
+		"birth_date":  "1990-01-01",
+		"age":         34,
+		"address":     map[string]interface{}{"country": "TW", "city": "Taipei"},
+		"nationality": []string{"TW", "JP"},
+		"tags":        []interface{}{"a"},
+	}
+
+	// When
+	encoded, disclosures, err := config.Encode(claims)
+
+	// Then
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	byDigest := disclosuresByDigest(disclosures)
+
+	// Top level: name and address stay, birth_date, age and nationality are digests
+	if encoded["name"] != "Alice" {
+		t.Errorf("Expected always disclosed name, got %v", encoded["name"])
+	}
+	for _, name := range []string{"birth_date", "age", "nationality"} {
+		if _, ok := encoded[name]; ok {
+			t.Errorf("Expected %s to be selectively disclosable", name)
+		}
+	}
+	digests, _ := encoded[DigestsClaim].([]string)
+	if len(digests) != 3 {
+		t.Fatalf("Expected 3 top level digests, got %v", encoded[DigestsClaim])
+	}
+	disclosed := map[string]interface{}{}
+	for i, digest := range digests {
+		if i > 0 && digests[i-1] > digest {
+			t.Errorf("Expected sorted digests, got %v", digests)
+		}
+		d, ok := byDigest[digest]
+		if !ok {
+			t.Fatalf("Digest %s has no disclosure", digest)
+		}
+		disclosed[d.Name] = d.Value
+	}
+	if disclosed["birth_date"] != "1990-01-01" || disclosed["age"] != json.Number("34") {
+		t.Errorf("Unexpected disclosed values: %v", disclosed)
+	}
+
+	// Nested object: country stays, city is a digest
+	address, _ := encoded["address"].(map[string]interface{})
+	if address["country"] != "TW" || address["city"] != nil {
+		t.Errorf("Unexpected address: %v", address)
+	}
+	addressDigests, _ := address[DigestsClaim].([]string)
+	if len(addressDigests) != 1 || byDigest[addressDigests[0]] == nil || byDigest[addressDigests[0]].Name != "city" {
+		t.Errorf("Expected a city digest, got %v", address[DigestsClaim])
+	}
+
+	// Array disclosed as a whole: its elements are digests inside the disclosure
+	nationality, _ := disclosed["nationality"].([]interface{})
+	if len(nationality) != 2 {
+		t.Fatalf("Expected 2 nationality elements, got %v", disclosed["nationality"])
+	}
+	for i, expected := range []string{"TW", "JP"} {
+		element, _ := nationality[i].(map[string]interface{})
+		digest, _ := element[ArrayElementClaim].(string)
+		d := byDigest[digest]
+		if d == nil || d.Name != "" || d.Value != expected {
+			t.Errorf("Expected element %d to disclose %s, got %v", i, expected, nationality[i])
+		}
+	}
+
+	// Always disclosed array: elements stay in plain text
+	tags, _ := encoded["tags"].([]interface{})
+	if len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("Expected plain tags, got %v", encoded["tags"])
+	}
+
+	// birth_date, age, nationality + 2 elements, city
+	if len(disclosures) != 6 {
+		t.Errorf("Expected 6 disclosures, got %d", len(disclosures))
+	}
+}
+
+func TestEncode_ReservedClaimName(t *testing.T) {
+	config := &Config{}
+
+	for _, claims := range []map[string]interface{}{
+		{"_sd": "x"},
+		{"address": map[string]interface{}{"...": "x"}},
+		{"_sd_alg": "sha-256"},
+	} {
+		if _, _, err := config.Encode(claims); err == nil {
+			t.Errorf("Expected error for %v", claims)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	d1 := &Disclosure{Encoded: "d1"}
+	d2 := &Disclosure{Encoded: "d2"}
+
+	if got := Format("jwt", []*Disclosure{d1, d2}); got != "jwt~d1~d2~" {
+		t.Errorf("Expected jwt~d1~d2~, got %s", got)
+	}
+	if got := Format("jwt", nil); got != "jwt~" {
+		t.Errorf("Expected jwt~, got %s", got)
+	}
+}
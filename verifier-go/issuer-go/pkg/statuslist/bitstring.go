@@ -0,0 +1,107 @@
+package statuslist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Size is the number of entries in a status list (16 KB bitstring, the
+// minimum recommended by the Bitstring Status List specification for privacy)
+const Size = 16 * 1024 * 8
+
+// maxDecompressedSize bounds the decompressed bitstring to guard against gzip bombs
+const maxDecompressedSize = 16 * 1024 * 1024
+
+// multibaseBase64URL is the multibase prefix of base64url without padding
+const multibaseBase64URL = "u"
+
+// Bitstring is a Bitstring Status List. Index 0 is the most significant bit of the first byte.
+type Bitstring []byte
+
+// NewBitstring creates an all-zero bitstring with Size entries
+func NewBitstring() Bitstring {
+	return make(Bitstring, Size/8)
+}
+
+// Get returns the bit at the given index
+func (b Bitstring) Get(index int) (bool, error) {
+	if index < 0 || index >= len(b)*8 {
+		return false, fmt.Errorf("status list index %d out of range [0, %d)", index, len(b)*8)
+	}
+	return b[index/8]&(0x80>>(index%8)) != 0, nil
+}
+
+// Set sets or clears the bit at the given index
+func (b Bitstring) Set(index int, value bool) error {
+	if index < 0 || index >= len(b)*8 {
+		return fmt.Errorf("status list index %d out of range [0, %d)", index, len(b)*8)
+	}
+	if value {
+		b[index/8] |= 0x80 >> (index % 8)
+	} else {
+		b[index/8] &^= 0x80 >> (index % 8)
+	}
+	return nil
+}
+
+// Encode GZIP-compresses the bitstring and encodes it as a multibase base64url string
+func (b Bitstring) Encode() (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return "", fmt.Errorf("failed to compress bitstring: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress bitstring: %w", err)
+	}
+
+	return multibaseBase64URL + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decode decodes an encodedList value. Both the multibase base64url form and
+// the plain base64 form produced by the Java issuer are accepted.
+func Decode(encodedList string) (Bitstring, error) {
+	compressed, err := decodeBase64(encodedList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encoded list: %w", err)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress encoded list: %w", err)
+	}
+	defer r.Close()
+
+	bitstring, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress encoded list: %w", err)
+	}
+	if len(bitstring) > maxDecompressedSize {
+		return nil, fmt.Errorf("encoded list exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return Bitstring(bitstring), nil
+}
+
+// decodeBase64 decodes multibase base64url or plain (standard or URL-safe) base64
+func decodeBase64(s string) ([]byte, error) {
+	if strings.HasPrefix(s, multibaseBase64URL) {
+		if data, err := base64.RawURLEncoding.DecodeString(s[1:]); err == nil {
+			return data, nil
+		}
+	}
+
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		if data, err := enc.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("not a base64 string")
+}
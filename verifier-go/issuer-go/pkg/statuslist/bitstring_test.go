@@ -0,0 +1,115 @@
+package statuslist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBitstring_SetGet(t *testing.T) {
+	// Given
+	b := NewBitstring()
+
+	// When
+	if err := b.Set(0, true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := b.Set(9, true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Then - index 0 is the most significant bit of the first byte
+	if b[0] != 0x80 || b[1] != 0x40 {
+		t.Errorf("Expected bytes 0x80 0x40, got %#x %#x", b[0], b[1])
+	}
+
+	for index, expected := range map[int]bool{0: true, 1: false, 9: true, Size - 1: false} {
+		got, err := b.Get(index)
+		if err != nil || got != expected {
+			t.Errorf("Index %d: expected %v, got %v (%v)", index, expected, got, err)
+		}
+	}
+
+	b.Set(0, false)
+	if got, _ := b.Get(0); got {
+		t.Error("Expected index 0 to be cleared")
+	}
+}
+
+func TestBitstring_OutOfRange(t *testing.T) {
+	b := NewBitstring()
+
+	if err := b.Set(Size, true); err == nil {
+		t.Error("Expected error for index past the end")
+	}
+	if _, err := b.Get(-1); err == nil {
+		t.Error("Expected error for negative index")
+	}
+}
+
+func TestBitstring_EncodeDecode(t *testing.T) {
+	// Given
+	b := NewBitstring()
+	b.Set(42, true)
+
+	// When
+	encoded, err := b.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := Decode(encoded)
+
+	// Then
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "u") {
+		t.Errorf("Expected multibase base64url prefix, got %q", encoded[:1])
+	}
+	if !bytes.Equal(decoded, b) {
+		t.Error("Decoded bitstring does not match original")
+	}
+}
+
+func TestDecode_JavaEncoding(t *testing.T) {
+	// Given - gzip then standard base64, as produced by Java's ZipUtils.gzipCompressThenBase64()
+	b := NewBitstring()
+	b.Set(7, true)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// When
+	decoded, err := Decode(encoded)
+
+	// Then
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got, _ := decoded.Get(7); !got {
+		t.Error("Expected index 7 to be set")
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"Not base64", "u!!!"},
+		{"Not gzip", "u" + base64.RawURLEncoding.EncodeToString([]byte("plain"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.encoded); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
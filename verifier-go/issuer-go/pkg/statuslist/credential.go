@@ -0,0 +1,143 @@
+package statuslist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+)
+
+// Bitstring Status List constants
+const (
+	CredentialsContextV1 = "https://www.w3.org/2018/credentials/v1"
+	StatusContextV1      = "https://www.w3.org/ns/credentials/status/v1"
+	CredentialType       = "BitstringStatusListCredential"
+	SubjectType          = "BitstringStatusList"
+	EntryType            = "BitstringStatusListEntry"
+	credentialJWTType    = "JWT"
+)
+
+// Entry represents a credentialStatus entry pointing at a status list
+type Entry struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose"`
+	StatusListIndex      string `json:"statusListIndex"`
+	StatusListCredential string `json:"statusListCredential"`
+}
+
+// Claims represents the JWT claim set of a status list credential
+type Claims struct {
+	jwt.RegisteredClaims
+	VC Credential `json:"vc"`
+}
+
+// Credential represents the "vc" claim of a status list credential
+type Credential struct {
+	Context           []string `json:"@context"`
+	ID                string   `json:"id"`
+	Type              []string `json:"type"`
+	Issuer            string   `json:"issuer"`
+	IssuanceDate      string   `json:"issuanceDate"`
+	ExpirationDate    string   `json:"expirationDate"`
+	CredentialSubject Subject  `json:"credentialSubject"`
+}
+
+// Subject represents the credentialSubject of a status list credential
+type Subject struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	StatusPurpose string `json:"statusPurpose"`
+	EncodedList   string `json:"encodedList"`
+}
+
+// ErrUnknownStatusListType is returned for a status list type or group name tag other than revocation ("r") or suspension ("s")
+var ErrUnknownStatusListType = errors.New("unknown status list type")
+
+// statusListTags maps status list types to their group name tags
+// Equivalent to Java's Definition.StatusListType
+var statusListTags = map[string]string{
+	models.StatusListTypeRevocation: "r",
+	models.StatusListTypeSuspension: "s",
+}
+
+// GroupName builds the group name of a status list, ex: ("revocation", 0) -> "r0"
+func GroupName(statusListType string, gid int) (string, error) {
+	tag, ok := statusListTags[statusListType]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownStatusListType, statusListType)
+	}
+	return tag + strconv.Itoa(gid), nil
+}
+
+// ParseGroupName splits a group name into its status list type and group id, ex: "s3" -> ("suspension", 3)
+func ParseGroupName(groupName string) (statusListType string, gid int, err error) {
+	if groupName == "" {
+		return "", 0, fmt.Errorf("invalid group name: %q", groupName)
+	}
+
+	for listType, tag := range statusListTags {
+		if strings.EqualFold(groupName[:1], tag) {
+			statusListType = listType
+		}
+	}
+	if statusListType == "" {
+		return "", 0, fmt.Errorf("%w: group name %q", ErrUnknownStatusListType, groupName)
+	}
+
+	digits := groupName[1:]
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return "", 0, fmt.Errorf("invalid group id in group name: %q", groupName)
+	}
+	if gid, err = strconv.Atoi(digits); err != nil {
+		return "", 0, fmt.Errorf("invalid group id in group name: %q", groupName)
+	}
+
+	return statusListType, gid, nil
+}
+
+// Position calculates the status list group id and index from a ticket number (starting at 1)
+// Equivalent to Java's CredentialService.calculateFromTicketNumber()
+func Position(ticketNumber int) (gid int, statusListIndex int) {
+	tn := ticketNumber - 1
+	statusListIndex = tn % Size
+	gid = (tn - statusListIndex) / Size
+	return gid, statusListIndex
+}
+
+// buildClaims builds the status list credential payload and its JWT registered claims
+// Equivalent to Java's StatusListPrepareTask.start()
+func buildClaims(issuerDID, statusListID, statusListType, encodedList string, issuanceDate, expirationDate time.Time) *Claims {
+	issuanceDate = issuanceDate.UTC()
+	expirationDate = expirationDate.UTC()
+
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        statusListID,
+			Issuer:    issuerDID,
+			Subject:   statusListID,
+			IssuedAt:  jwt.NewNumericDate(issuanceDate),
+			NotBefore: jwt.NewNumericDate(issuanceDate),
+			ExpiresAt: jwt.NewNumericDate(expirationDate),
+		},
+		VC: Credential{
+			Context:        []string{CredentialsContextV1, StatusContextV1},
+			ID:             statusListID,
+			Type:           []string{"VerifiableCredential", CredentialType},
+			Issuer:         issuerDID,
+			IssuanceDate:   issuanceDate.Format(time.RFC3339),
+			ExpirationDate: expirationDate.Format(time.RFC3339),
+			CredentialSubject: Subject{
+				ID:            statusListID + "#list",
+				Type:          SubjectType,
+				StatusPurpose: statusListType,
+				EncodedList:   encodedList,
+			},
+		},
+	}
+}
@@ -0,0 +1,343 @@
+package statuslist
+
+import (
+	"context"
+	stdcrypto "crypto"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/internal/crypto"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+)
+
+// DefaultValidity is the validity of a signed status list credential.
+// Equivalent to Java's status list policy (effective duration: 1 day).
+const DefaultValidity = 24 * time.Hour
+
+// ContentType is the media type of a signed status list credential
+const ContentType = "application/jwt"
+
+// maxSaveAttempts bounds retries when a status list is updated concurrently
+// Equivalent to Java's @Retryable on StatusListService.operateStatusList()
+const maxSaveAttempts = 5
+
+// statusListTypes lists the status lists maintained for every group, in order
+var statusListTypes = []string{models.StatusListTypeRevocation, models.StatusListTypeSuspension}
+
+// Service manages Bitstring Status Lists per credential type and group name
+// Equivalent to Java's StatusListService
+type Service struct {
+	issuerDID   string
+	signingKey  stdcrypto.Signer
+	keyID       string
+	baseURL     string
+	validity    time.Duration
+	statusLists repository.StatusListRepository
+}
+
+// NewService creates a new status list service.
+// Lists are signed with signingKey and published under baseURL.
+func NewService(issuerDID string, signingKey stdcrypto.Signer, keyID, baseURL string, statusLists repository.StatusListRepository) *Service {
+	return &Service{
+		issuerDID:   issuerDID,
+		signingKey:  signingKey,
+		keyID:       keyID,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		validity:    DefaultValidity,
+		statusLists: statusLists,
+	}
+}
+
+// ListID builds the public ID of a status list credential
+// ex: http://localhost:8080/api/status-list/IdentityCredential/r0
+// Equivalent to Java's Uris.generateStatusListId()
+func (s *Service) ListID(credentialType, groupName string) string {
+	return fmt.Sprintf("%s/api/status-list/%s/%s", s.baseURL, credentialType, groupName)
+}
+
+// Allocate returns the revocation and suspension entries of the credential
+// holding the given ticket number, creating the group's status lists on first use
+func (s *Service) Allocate(ctx context.Context, credentialType string, ticketNumber int) ([]Entry, *errors.VCError) {
+	if credentialType == "" || ticketNumber < 1 {
+		return nil, errors.NewVCError(
+			errors.ErrSLInvalidStatusListOperationRequest,
+			"invalid status list allocation request",
+		)
+	}
+
+	gid, index := Position(ticketNumber)
+	entries := make([]Entry, 0, len(statusListTypes))
+
+	for _, statusListType := range statusListTypes {
+		groupName, _ := GroupName(statusListType, gid)
+
+		if _, vcErr := s.findOrCreate(ctx, credentialType, groupName, statusListType); vcErr != nil {
+			return nil, vcErr
+		}
+
+		listID := s.ListID(credentialType, groupName)
+		idx := strconv.Itoa(index)
+		entries = append(entries, Entry{
+			ID:                   listID + "#" + idx,
+			Type:                 EntryType,
+			StatusPurpose:        statusListType,
+			StatusListIndex:      idx,
+			StatusListCredential: listID,
+		})
+	}
+
+	return entries, nil
+}
+
+// Update flips the status list bits of the credential holding the given
+// ticket number to match its credential status:
+// REVOKED sets the revocation bit, SUSPENDED sets the suspension bit,
+// ACTIVE clears both.
+func (s *Service) Update(ctx context.Context, credentialType string, ticketNumber int, credentialStatus string) *errors.VCError {
+	if credentialType == "" || ticketNumber < 1 {
+		return errors.NewVCError(
+			errors.ErrSLInvalidStatusListOperationRequest,
+			"invalid status list operation request",
+		)
+	}
+
+	var revoked, suspended *bool
+	switch credentialStatus {
+	case models.CredentialStatusActive:
+		revoked, suspended = boolPtr(false), boolPtr(false)
+	case models.CredentialStatusSuspended:
+		revoked, suspended = boolPtr(false), boolPtr(true)
+	case models.CredentialStatusRevoked:
+		// the suspension bit is left as is; revocation takes precedence
+		revoked = boolPtr(true)
+	default:
+		return errors.NewVCError(
+			errors.ErrSLInvalidStatusListOperationRequest,
+			fmt.Sprintf("invalid credential status for status list operation: %s", credentialStatus),
+		)
+	}
+
+	gid, index := Position(ticketNumber)
+	bits := map[string]*bool{
+		models.StatusListTypeRevocation: revoked,
+		models.StatusListTypeSuspension: suspended,
+	}
+	for _, statusListType := range statusListTypes {
+		value := bits[statusListType]
+		if value == nil {
+			continue
+		}
+		groupName, _ := GroupName(statusListType, gid)
+		if vcErr := s.setBit(ctx, credentialType, groupName, statusListType, index, *value); vcErr != nil {
+			return vcErr
+		}
+	}
+
+	return nil
+}
+
+// Get returns the latest signed status list credential, renewing it first if
+// more than half of its validity has elapsed
+// Equivalent to Java's PublicInfoService.getStatusList()
+func (s *Service) Get(ctx context.Context, credentialType, groupName string) (*models.StatusListResponse, *errors.VCError) {
+	// Validate request
+	if credentialType == "" {
+		return nil, errors.NewVCError(errors.ErrInfoInvalidCredentialType, "invalid credential type")
+	}
+	if groupName == "" {
+		return nil, errors.NewVCError(errors.ErrInfoInvalidGroupName, "invalid status list group name")
+	}
+
+	statusListType, gid, err := ParseGroupName(groupName)
+	if stderrors.Is(err, ErrUnknownStatusListType) {
+		return nil, errors.NewVCError(errors.ErrSLInputStatusListTypeError, "status list type error")
+	}
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrInfoInvalidGroupName, "invalid status list group name")
+	}
+	groupName, _ = GroupName(statusListType, gid)
+
+	for attempt := 1; ; attempt++ {
+		statusList, err := s.statusLists.FindStatusList(ctx, credentialType, groupName)
+		if stderrors.Is(err, repository.ErrNotFound) {
+			return nil, errors.NewVCError(errors.ErrInfoStatusListNotFound, "status list not found")
+		}
+		if err != nil {
+			return nil, errors.NewVCError(errors.ErrSLQueryStatusListError, "fail to query status list")
+		}
+
+		if time.Since(statusList.LastUpdateTime) > s.validity/2 {
+			// Renew the signature over the unchanged bitstring
+			renewed, vcErr := s.publish(ctx, statusList, statusList.EncodedList)
+			if vcErr == errConflict && attempt < maxSaveAttempts {
+				continue
+			}
+			if vcErr == errConflict {
+				return nil, errors.NewVCError(errors.ErrSLRetryError, "no record available, over maximum retry times")
+			}
+			if vcErr != nil {
+				return nil, vcErr
+			}
+			statusList = renewed
+		}
+
+		return &models.StatusListResponse{
+			GroupName:   statusList.GroupName,
+			StatusList:  statusList.Content,
+			ContentType: ContentType,
+		}, nil
+	}
+}
+
+// errConflict signals that a status list changed concurrently and the operation should be retried
+var errConflict = errors.NewVCError(errors.ErrSLRetryError, "status list changed concurrently")
+
+// findOrCreate returns a status list, creating an all-zero list if none exists
+func (s *Service) findOrCreate(ctx context.Context, credentialType, groupName, statusListType string) (*models.StatusList, *errors.VCError) {
+	for attempt := 1; attempt <= maxSaveAttempts; attempt++ {
+		statusList, err := s.statusLists.FindStatusList(ctx, credentialType, groupName)
+		if err == nil {
+			return statusList, nil
+		}
+		if !stderrors.Is(err, repository.ErrNotFound) {
+			return nil, errors.NewVCError(errors.ErrSLQueryStatusListError, "fail to query status list")
+		}
+
+		encodedList, err := NewBitstring().Encode()
+		if err != nil {
+			return nil, errors.NewVCError(errors.ErrSLGenerateStatusListError, fmt.Sprintf("fail to generate status list: %v", err))
+		}
+
+		created, vcErr := s.publish(ctx, &models.StatusList{
+			CredentialType: credentialType,
+			GroupName:      groupName,
+			StatusListType: statusListType,
+		}, encodedList)
+		if vcErr == errConflict {
+			// Created concurrently; read it back
+			continue
+		}
+		if vcErr != nil {
+			return nil, vcErr
+		}
+		return created, nil
+	}
+
+	return nil, errors.NewVCError(errors.ErrSLRetryError, "no record available, over maximum retry times")
+}
+
+// setBit sets one bit of a status list and republishes it, retrying on concurrent updates
+// Equivalent to Java's StatusListService.operateStatusList()
+func (s *Service) setBit(ctx context.Context, credentialType, groupName, statusListType string, index int, value bool) *errors.VCError {
+	for attempt := 1; attempt <= maxSaveAttempts; attempt++ {
+		statusList, vcErr := s.findOrCreate(ctx, credentialType, groupName, statusListType)
+		if vcErr != nil {
+			return vcErr
+		}
+
+		bitstring, err := Decode(statusList.EncodedList)
+		if err != nil {
+			return errors.NewVCError(errors.ErrSLPrepareStatusListError, fmt.Sprintf("fail to prepare status list: %v", err))
+		}
+
+		current, err := bitstring.Get(index)
+		if err != nil {
+			return errors.NewVCError(errors.ErrSLInvalidStatusListOperationRequest, err.Error())
+		}
+		if current == value {
+			return nil
+		}
+		bitstring.Set(index, value)
+
+		encodedList, err := bitstring.Encode()
+		if err != nil {
+			return errors.NewVCError(errors.ErrSLPrepareStatusListError, fmt.Sprintf("fail to prepare status list: %v", err))
+		}
+
+		_, vcErr = s.publish(ctx, statusList, encodedList)
+		if vcErr == errConflict {
+			continue
+		}
+		return vcErr
+	}
+
+	return errors.NewVCError(errors.ErrSLRetryError, "no record available, over maximum retry times")
+}
+
+// publish signs encodedList into a new status list credential, verifies it
+// and stores it as the next version of statusList. Returns errConflict if
+// the stored version changed in the meantime.
+func (s *Service) publish(ctx context.Context, statusList *models.StatusList, encodedList string) (*models.StatusList, *errors.VCError) {
+	if s.signingKey == nil {
+		return nil, errors.NewVCError(errors.ErrSLSignStatusListError, "status list signing key is not set")
+	}
+
+	// step 1: prepare status list
+	now := time.Now()
+	listID := s.ListID(statusList.CredentialType, statusList.GroupName)
+	claims := buildClaims(s.issuerDID, listID, statusList.StatusListType, encodedList, now, now.Add(s.validity))
+
+	// step 2: sign status list
+	content, err := crypto.SignJWT(claims, s.signingKey, s.keyID, credentialJWTType)
+	if err != nil {
+		return nil, errors.NewVCError(errors.ErrSLSignStatusListError, "fail to sign status list")
+	}
+
+	// step 3: validate status list
+	if err := s.verify(content, encodedList); err != nil {
+		return nil, errors.NewVCError(errors.ErrSLVerifyStatusListError, fmt.Sprintf("fail to verify status list: %v", err))
+	}
+
+	// step 4: store new status list
+	next := *statusList
+	next.EncodedList = encodedList
+	next.Content = content
+	next.LastUpdateTime = now
+	next.Version = statusList.Version + 1
+
+	err = s.statusLists.SaveStatusList(ctx, &next)
+	switch {
+	case err == nil:
+		return &next, nil
+	case stderrors.Is(err, repository.ErrConflict), stderrors.Is(err, repository.ErrDuplicate):
+		return nil, errConflict
+	case next.Version == 1:
+		return nil, errors.NewVCError(errors.ErrDBInsertError, "fail to store status list")
+	default:
+		return nil, errors.NewVCError(errors.ErrDBUpdateError, "fail to store status list")
+	}
+}
+
+// verify checks the signature of a freshly signed status list and that it carries encodedList
+// Equivalent to Java's StatusListValidateTask
+func (s *Service) verify(content, encodedList string) error {
+	method, err := crypto.SigningMethod(s.signingKey)
+	if err != nil {
+		return err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(content, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.signingKey.Public(), nil
+	}, jwt.WithValidMethods([]string{method.Alg()}))
+	if err != nil {
+		return err
+	}
+
+	if claims.VC.CredentialSubject.EncodedList != encodedList {
+		return fmt.Errorf("encoded list mismatch")
+	}
+
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,313 @@
+package statuslist
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+)
+
+func newTestService(t *testing.T, repo repository.StatusListRepository) (*Service, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	return NewService("did:example:issuer", key, "did:example:issuer#key-1", "https://issuer.example/", repo), key
+}
+
+// parseStatusList verifies a served status list credential and decodes its bitstring
+func parseStatusList(t *testing.T, content string, key *ecdsa.PrivateKey) (*Claims, Bitstring) {
+	t.Helper()
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(content, claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}); err != nil {
+		t.Fatalf("Failed to verify status list: %v", err)
+	}
+
+	bitstring, err := Decode(claims.VC.CredentialSubject.EncodedList)
+	if err != nil {
+		t.Fatalf("Failed to decode status list: %v", err)
+	}
+
+	return claims, bitstring
+}
+
+func TestGroupName(t *testing.T) {
+	tests := []struct {
+		groupName      string
+		statusListType string
+		gid            int
+		wantErr        bool
+	}{
+		{"r0", models.StatusListTypeRevocation, 0, false},
+		{"s12", models.StatusListTypeSuspension, 12, false},
+		{"R3", models.StatusListTypeRevocation, 3, false},
+		{"r", "", 0, true},
+		{"r-1", "", 0, true},
+		{"r+1", "", 0, true},
+		{"x0", "", 0, true},
+		{"", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupName, func(t *testing.T) {
+			statusListType, gid, err := ParseGroupName(tt.groupName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if statusListType != tt.statusListType || gid != tt.gid {
+				t.Errorf("Expected (%s, %d), got (%s, %d)", tt.statusListType, tt.gid, statusListType, gid)
+			}
+		})
+	}
+
+	if name, _ := GroupName(models.StatusListTypeSuspension, 7); name != "s7" {
+		t.Errorf("Expected s7, got %s", name)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		ticketNumber int
+		gid          int
+		index        int
+	}{
+		{1, 0, 0},
+		{2, 0, 1},
+		{Size, 0, Size - 1},
+		{Size + 1, 1, 0},
+	}
+
+	for _, tt := range tests {
+		gid, index := Position(tt.ticketNumber)
+		if gid != tt.gid || index != tt.index {
+			t.Errorf("Ticket %d: expected (%d, %d), got (%d, %d)", tt.ticketNumber, tt.gid, tt.index, gid, index)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repo := repository.NewMemoryStatusListRepository()
+	service, key := newTestService(t, repo)
+
+	// When
+	entries, vcErr := service.Allocate(ctx, "TestCredential", Size+3)
+
+	// Then
+	if vcErr != nil {
+		t.Fatalf("Allocate failed: %v", vcErr)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	expected := []Entry{
+		{
+			ID:                   "https://issuer.example/api/status-list/TestCredential/r1#2",
+			Type:                 EntryType,
+			StatusPurpose:        models.StatusListTypeRevocation,
+			StatusListIndex:      "2",
+			StatusListCredential: "https://issuer.example/api/status-list/TestCredential/r1",
+		},
+		{
+			ID:                   "https://issuer.example/api/status-list/TestCredential/s1#2",
+			Type:                 EntryType,
+			StatusPurpose:        models.StatusListTypeSuspension,
+			StatusListIndex:      "2",
+			StatusListCredential: "https://issuer.example/api/status-list/TestCredential/s1",
+		},
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, expected[i], entries[i])
+		}
+	}
+
+	// Both lists of the group are published
+	for _, groupName := range []string{"r1", "s1"} {
+		statusList, err := repo.FindStatusList(ctx, "TestCredential", groupName)
+		if err != nil {
+			t.Fatalf("Expected status list %s, got %v", groupName, err)
+		}
+		claims, bitstring := parseStatusList(t, statusList.Content, key)
+		if len(bitstring) != Size/8 {
+			t.Errorf("Expected %d byte bitstring, got %d", Size/8, len(bitstring))
+		}
+		expectedSubjectID := "https://issuer.example/api/status-list/TestCredential/" + groupName + "#list"
+		if claims.VC.CredentialSubject.ID != expectedSubjectID {
+			t.Errorf("Expected subject id %s, got %s", expectedSubjectID, claims.VC.CredentialSubject.ID)
+		}
+	}
+}
+
+func TestUpdate_FlipsBits(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service, key := newTestService(t, repository.NewMemoryStatusListRepository())
+	if _, vcErr := service.Allocate(ctx, "TestCredential", 5); vcErr != nil {
+		t.Fatalf("Allocate failed: %v", vcErr)
+	}
+
+	steps := []struct {
+		status    string
+		revoked   bool
+		suspended bool
+	}{
+		{models.CredentialStatusSuspended, false, true},
+		{models.CredentialStatusActive, false, false},
+		{models.CredentialStatusRevoked, true, false},
+	}
+
+	for _, step := range steps {
+		// When
+		if vcErr := service.Update(ctx, "TestCredential", 5, step.status); vcErr != nil {
+			t.Fatalf("Update to %s failed: %v", step.status, vcErr)
+		}
+
+		// Then - served lists reflect the new status at index 4
+		for groupName, expected := range map[string]bool{"r0": step.revoked, "s0": step.suspended} {
+			response, vcErr := service.Get(ctx, "TestCredential", groupName)
+			if vcErr != nil {
+				t.Fatalf("Get failed: %v", vcErr)
+			}
+			_, bitstring := parseStatusList(t, response.StatusList, key)
+			if got, _ := bitstring.Get(4); got != expected {
+				t.Errorf("After %s, list %s: expected bit %v, got %v", step.status, groupName, expected, got)
+			}
+			if got, _ := bitstring.Get(3); got {
+				t.Errorf("After %s, list %s: neighbouring bit was flipped", step.status, groupName)
+			}
+		}
+	}
+}
+
+func TestUpdate_InvalidRequest(t *testing.T) {
+	service, _ := newTestService(t, repository.NewMemoryStatusListRepository())
+
+	tests := []struct {
+		name         string
+		ticketNumber int
+		status       string
+	}{
+		{"Invalid ticket", 0, models.CredentialStatusRevoked},
+		{"Invalid status", 1, "EXPIRED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcErr := service.Update(context.Background(), "TestCredential", tt.ticketNumber, tt.status)
+			if vcErr == nil || vcErr.Code != errors.ErrSLInvalidStatusListOperationRequest {
+				t.Errorf("Expected error code %d, got %v", errors.ErrSLInvalidStatusListOperationRequest, vcErr)
+			}
+		})
+	}
+}
+
+func TestGet_Errors(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService(t, repository.NewMemoryStatusListRepository())
+
+	tests := []struct {
+		name           string
+		credentialType string
+		groupName      string
+		expected       int
+	}{
+		{"Missing credential type", "", "r0", errors.ErrInfoInvalidCredentialType},
+		{"Missing group name", "TestCredential", "", errors.ErrInfoInvalidGroupName},
+		{"Invalid group id", "TestCredential", "rx", errors.ErrInfoInvalidGroupName},
+		{"Unknown status list type", "TestCredential", "x0", errors.ErrSLInputStatusListTypeError},
+		{"Not found", "TestCredential", "r0", errors.ErrInfoStatusListNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, vcErr := service.Get(ctx, tt.credentialType, tt.groupName)
+			if vcErr == nil || vcErr.Code != tt.expected {
+				t.Errorf("Expected error code %d, got %v", tt.expected, vcErr)
+			}
+		})
+	}
+}
+
+func TestGet_RenewsStaleList(t *testing.T) {
+	// Given - a list published more than half its validity ago
+	ctx := context.Background()
+	repo := repository.NewMemoryStatusListRepository()
+	service, key := newTestService(t, repo)
+	service.Allocate(ctx, "TestCredential", 1)
+
+	stale, _ := repo.FindStatusList(ctx, "TestCredential", "r0")
+	stale.LastUpdateTime = time.Now().Add(-DefaultValidity/2 - time.Minute)
+	stale.Version++
+	repo.SaveStatusList(ctx, stale)
+
+	// When
+	response, vcErr := service.Get(ctx, "TestCredential", "r0")
+
+	// Then
+	if vcErr != nil {
+		t.Fatalf("Get failed: %v", vcErr)
+	}
+	claims, _ := parseStatusList(t, response.StatusList, key)
+	if time.Until(claims.ExpiresAt.Time) < DefaultValidity-time.Minute {
+		t.Errorf("Expected renewed expiry, got %v", claims.ExpiresAt.Time)
+	}
+
+	renewed, _ := repo.FindStatusList(ctx, "TestCredential", "r0")
+	if renewed.Version != stale.Version+1 {
+		t.Errorf("Expected version %d, got %d", stale.Version+1, renewed.Version)
+	}
+}
+
+func TestPublish_MissingSigningKey(t *testing.T) {
+	service := NewService("did:example:issuer", nil, "", "https://issuer.example", repository.NewMemoryStatusListRepository())
+
+	_, vcErr := service.Allocate(context.Background(), "TestCredential", 1)
+
+	if vcErr == nil || vcErr.Code != errors.ErrSLSignStatusListError {
+		t.Errorf("Expected error code %d, got %v", errors.ErrSLSignStatusListError, vcErr)
+	}
+}
+
+// conflictingRepository reports a concurrent modification on every update
+type conflictingRepository struct {
+	*repository.MemoryStatusListRepository
+}
+
+func (r conflictingRepository) SaveStatusList(ctx context.Context, statusList *models.StatusList) error {
+	if statusList.Version > 1 {
+		return repository.ErrConflict
+	}
+	return r.MemoryStatusListRepository.SaveStatusList(ctx, statusList)
+}
+
+func TestUpdate_RetryExhausted(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	service, _ := newTestService(t, conflictingRepository{repository.NewMemoryStatusListRepository()})
+	service.Allocate(ctx, "TestCredential", 1)
+
+	// When
+	vcErr := service.Update(ctx, "TestCredential", 1, models.CredentialStatusRevoked)
+
+	// Then
+	if vcErr == nil || vcErr.Code != errors.ErrSLRetryError {
+		t.Errorf("Expected error code %d, got %v", errors.ErrSLRetryError, vcErr)
+	}
+}
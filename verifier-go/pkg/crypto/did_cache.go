@@ -0,0 +1,285 @@
+package crypto
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DIDCacheConfig configures the DID resolution cache of a DIDResolver
+type DIDCacheConfig struct {
+	// MaxEntries bounds the cached DIDs; the least recently used are evicted
+	MaxEntries int
+	// DefaultTTL applies when a resolver sends no Cache-Control max-age
+	DefaultTTL time.Duration
+	// MaxTTL caps the max-age of a Cache-Control header
+	MaxTTL time.Duration
+	// NegativeTTL is how long a failed resolution is remembered; 0 disables
+	// negative caching
+	NegativeTTL time.Duration
+	// StaleWhileRevalidate is how long an expired DID document is still
+	// served while it is refreshed in the background, unless a Cache-Control
+	// stale-while-revalidate directive says otherwise
+	StaleWhileRevalidate time.Duration
+}
+
+// DefaultDIDCacheConfig returns the cache configuration of NewDIDResolver
+func DefaultDIDCacheConfig() DIDCacheConfig {
+	return DIDCacheConfig{
+		MaxEntries:           1000,
+		DefaultTTL:           30 * time.Minute,
+		MaxTTL:               24 * time.Hour,
+		NegativeTTL:          30 * time.Second,
+		StaleWhileRevalidate: 5 * time.Minute,
+	}
+}
+
+// DIDCacheStats counts the lookups of a DID resolution cache
+type DIDCacheStats struct {
+	Hits      uint64 `json:"hits"`      // Served from the cache, including stale documents and cached failures
+	Misses    uint64 `json:"misses"`    // Resolved, or joined a resolution in flight
+	Errors    uint64 `json:"errors"`    // Resolutions that failed
+	Evictions uint64 `json:"evictions"` // Entries evicted to stay within MaxEntries
+	Entries   int    `json:"entries"`
+}
+
+// errResolutionAborted is reported to the callers waiting on a resolution
+// whose method resolver panicked
+var errResolutionAborted = errors.New("DID resolution aborted")
+
+// cachePolicy is the caching allowed by a resolver's HTTP response
+type cachePolicy struct {
+	hasMaxAge            bool // Cache-Control max-age, no-cache or no-store was sent
+	maxAge               time.Duration
+	hasStale             bool // Cache-Control stale-while-revalidate was sent
+	staleWhileRevalidate time.Duration
+}
+
+// parseCacheControl reads the max-age and stale-while-revalidate directives
+// of a Cache-Control header. no-store and no-cache disable caching.
+func parseCacheControl(header http.Header) cachePolicy {
+	var policy cachePolicy
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return cachePolicy{hasMaxAge: true, hasStale: true}
+		case "max-age":
+			if err == nil && seconds >= 0 && !policy.hasMaxAge {
+				policy.hasMaxAge, policy.maxAge = true, time.Duration(seconds)*time.Second
+			}
+		case "stale-while-revalidate":
+			if err == nil && seconds >= 0 {
+				policy.hasStale, policy.staleWhileRevalidate = true, time.Duration(seconds)*time.Second
+			}
+		}
+	}
+	return policy
+}
+
+// didCache is a size-bounded LRU cache of DID resolution results. Concurrent
+// resolutions of a DID are coalesced into one call of the method resolver.
+type didCache struct {
+	config DIDCacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *didCacheEntry, most recently used first
+	lru     *list.List
+	calls   map[string]*didCall // resolutions in flight
+	stats   DIDCacheStats
+}
+
+type didCacheEntry struct {
+	did        string
+	result     *DIDResolutionResult
+	err        error
+	expiresAt  time.Time
+	staleUntil time.Time // a successful result is served until then while refreshing
+	retryAt    time.Time // a failed refresh is not retried before then
+}
+
+type didCall struct {
+	done   chan struct{}
+	result *DIDResolutionResult
+	err    error
+}
+
+func newDIDCache(config DIDCacheConfig) *didCache {
+	return &didCache{
+		config:  config,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		calls:   make(map[string]*didCall),
+	}
+}
+
+// resolve returns the cached resolution of did, resolving it with resolve if
+// it is missing or expired. An expired result within its stale window is
+// returned at once and refreshed in the background.
+func (c *didCache) resolve(did string, resolve func(did string) (*DIDResolutionResult, error)) (*DIDResolutionResult, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	if elem, ok := c.entries[did]; ok {
+		entry := elem.Value.(*didCacheEntry)
+		switch {
+		case now.Before(entry.expiresAt):
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return entry.result, entry.err
+		case entry.err == nil && now.Before(entry.staleUntil):
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			if _, inFlight := c.calls[did]; !inFlight && !now.Before(entry.retryAt) {
+				call := c.startCall(did)
+				go c.run(did, call, resolve)
+			}
+			c.mu.Unlock()
+			return entry.result, nil
+		}
+	}
+
+	c.stats.Misses++
+	call, inFlight := c.calls[did]
+	if !inFlight {
+		call = c.startCall(did)
+	}
+	c.mu.Unlock()
+
+	if !inFlight {
+		c.run(did, call, resolve)
+	}
+	<-call.done
+	return call.result, call.err
+}
+
+// startCall registers a resolution of did in flight; c.mu must be held
+func (c *didCache) startCall(did string) *didCall {
+	call := &didCall{done: make(chan struct{}), err: errResolutionAborted}
+	c.calls[did] = call
+	return call
+}
+
+// run resolves did, stores the outcome and releases the callers waiting on call
+func (c *didCache) run(did string, call *didCall, resolve func(did string) (*DIDResolutionResult, error)) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, did)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	result, err := resolve(did)
+	call.result, call.err = result, err
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.stats.Errors++
+		c.storeError(did, err)
+		return
+	}
+	c.storeResult(did, result)
+}
+
+// storeResult caches a successful resolution for the TTL of its Cache-Control
+// header, or the default TTL. A zero TTL, ex: max-age=0, is not cached; c.mu
+// must be held
+func (c *didCache) storeResult(did string, result *DIDResolutionResult) {
+	ttl, stale := c.config.DefaultTTL, c.config.StaleWhileRevalidate
+	if result.cachePolicy.hasMaxAge {
+		ttl = result.cachePolicy.maxAge
+	}
+	if result.cachePolicy.hasStale {
+		stale = result.cachePolicy.staleWhileRevalidate
+	}
+	if c.config.MaxTTL > 0 && ttl > c.config.MaxTTL {
+		ttl = c.config.MaxTTL
+	}
+	if ttl <= 0 {
+		c.remove(did)
+		return
+	}
+
+	now := c.now()
+	c.store(&didCacheEntry{
+		did:        did,
+		result:     result,
+		expiresAt:  now.Add(ttl),
+		staleUntil: now.Add(ttl + stale),
+	})
+}
+
+// storeError caches a failed resolution for NegativeTTL. A stale result whose
+// refresh failed is kept, and not refreshed again before NegativeTTL passes;
+// c.mu must be held
+func (c *didCache) storeError(did string, err error) {
+	now := c.now()
+	if elem, ok := c.entries[did]; ok {
+		if entry := elem.Value.(*didCacheEntry); entry.err == nil && now.Before(entry.staleUntil) {
+			entry.retryAt = now.Add(c.config.NegativeTTL)
+			return
+		}
+	}
+	if c.config.NegativeTTL <= 0 {
+		c.remove(did)
+		return
+	}
+
+	c.store(&didCacheEntry{
+		did:       did,
+		err:       err,
+		expiresAt: now.Add(c.config.NegativeTTL),
+	})
+}
+
+// store adds or replaces an entry, evicting the least recently used entries
+// beyond MaxEntries; c.mu must be held
+func (c *didCache) store(entry *didCacheEntry) {
+	if elem, ok := c.entries[entry.did]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.did] = c.lru.PushFront(entry)
+	for c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*didCacheEntry).did)
+		c.stats.Evictions++
+	}
+}
+
+// remove drops the entry of did; c.mu must be held
+func (c *didCache) remove(did string) {
+	if elem, ok := c.entries[did]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, did)
+	}
+}
+
+// clear drops every entry, keeping the counters
+func (c *didCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// snapshot returns the counters and the number of entries
+func (c *didCache) snapshot() DIDCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
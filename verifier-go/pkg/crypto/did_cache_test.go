@@ -0,0 +1,279 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testDIDCache returns a DID cache on a clock the test advances
+func testDIDCache(config DIDCacheConfig) (*didCache, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newDIDCache(config)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+// countingResolve resolves every DID to an empty document, counting the calls
+func countingResolve(calls *atomic.Int32, policy cachePolicy) func(did string) (*DIDResolutionResult, error) {
+	return func(did string) (*DIDResolutionResult, error) {
+		calls.Add(1)
+		result := documentResult(&DIDDocument{ID: did})
+		result.cachePolicy = policy
+		return result, nil
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		header string
+		want   cachePolicy
+	}{
+		{"", cachePolicy{}},
+		{"max-age=600", cachePolicy{hasMaxAge: true, maxAge: 10 * time.Minute}},
+		{"public, max-age=60, stale-while-revalidate=30", cachePolicy{hasMaxAge: true, maxAge: time.Minute, hasStale: true, staleWhileRevalidate: 30 * time.Second}},
+		{"Max-Age=\"120\"", cachePolicy{hasMaxAge: true, maxAge: 2 * time.Minute}},
+		{"max-age=-1", cachePolicy{}},
+		{"max-age=600, no-store", cachePolicy{hasMaxAge: true, hasStale: true}},
+		{"no-cache", cachePolicy{hasMaxAge: true, hasStale: true}},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Cache-Control", tt.header)
+		if got := parseCacheControl(header); got != tt.want {
+			t.Errorf("Expected %+v for %q, got %+v", tt.want, tt.header, got)
+		}
+	}
+}
+
+func TestDIDCache_TTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy cachePolicy
+		ttl    time.Duration
+	}{
+		{"default", cachePolicy{}, 30 * time.Minute},
+		{"max-age", cachePolicy{hasMaxAge: true, maxAge: time.Minute}, time.Minute},
+		{"capped max-age", cachePolicy{hasMaxAge: true, maxAge: 48 * time.Hour}, 24 * time.Hour},
+		{"no-store", cachePolicy{hasMaxAge: true, hasStale: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			config := DefaultDIDCacheConfig()
+			config.StaleWhileRevalidate = 0
+			cache, now := testDIDCache(config)
+			var calls atomic.Int32
+			resolve := countingResolve(&calls, tt.policy)
+
+			// When - resolved, then again just before and at the TTL
+			cache.resolve("did:web:example.com", resolve)
+			*now = now.Add(tt.ttl - time.Second)
+			cache.resolve("did:web:example.com", resolve)
+			beforeExpiry := calls.Load()
+			*now = now.Add(time.Second)
+			cache.resolve("did:web:example.com", resolve)
+
+			// Then
+			if tt.ttl > 0 && (beforeExpiry != 1 || calls.Load() != 2) {
+				t.Errorf("Expected a refetch only after %v, got %d then %d calls", tt.ttl, beforeExpiry, calls.Load())
+			}
+			if tt.ttl == 0 && calls.Load() != 3 {
+				t.Errorf("Expected no caching, got %d calls", calls.Load())
+			}
+		})
+	}
+}
+
+func TestDIDCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	// Given - room for two DIDs
+	config := DefaultDIDCacheConfig()
+	config.MaxEntries = 2
+	cache, _ := testDIDCache(config)
+	var calls atomic.Int32
+	resolve := countingResolve(&calls, cachePolicy{})
+
+	// When - a is used again before c is added
+	for _, did := range []string{"did:web:a", "did:web:b", "did:web:a", "did:web:c"} {
+		cache.resolve(did, resolve)
+	}
+
+	// Then - b was evicted, a and c are still cached
+	cache.resolve("did:web:a", resolve)
+	cache.resolve("did:web:c", resolve)
+	if calls.Load() != 3 {
+		t.Errorf("Expected a and c to be cached, got %d calls", calls.Load())
+	}
+	cache.resolve("did:web:b", resolve)
+	stats := cache.snapshot()
+	if calls.Load() != 4 || stats.Entries != 2 || stats.Evictions != 2 {
+		t.Errorf("Expected b to be evicted, got %d calls and %+v", calls.Load(), stats)
+	}
+}
+
+func TestDIDCache_CoalescesConcurrentResolutions(t *testing.T) {
+	// Given - a slow resolver
+	cache, _ := testDIDCache(DefaultDIDCacheConfig())
+	var calls atomic.Int32
+	release := make(chan struct{})
+	resolve := func(did string) (*DIDResolutionResult, error) {
+		calls.Add(1)
+		<-release
+		return documentResult(&DIDDocument{ID: did}), nil
+	}
+
+	// When - a burst of presentations from a new issuer
+	var wg sync.WaitGroup
+	results := make([]*DIDResolutionResult, 50)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.resolve("did:web:example.com", resolve)
+		}(i)
+	}
+	for cache.snapshot().Misses+cache.snapshot().Hits < uint64(len(results)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	// Then - the document was fetched once and shared
+	if calls.Load() != 1 {
+		t.Errorf("Expected one resolution, got %d", calls.Load())
+	}
+	for _, result := range results {
+		if result == nil || result != results[0] {
+			t.Fatal("Expected every caller to get the same result")
+		}
+	}
+}
+
+func TestDIDCache_NegativeCaching(t *testing.T) {
+	// Given - a broken issuer
+	cache, now := testDIDCache(DefaultDIDCacheConfig())
+	var calls atomic.Int32
+	resolve := func(did string) (*DIDResolutionResult, error) {
+		calls.Add(1)
+		return nil, errors.New("connection refused")
+	}
+
+	// When
+	_, err1 := cache.resolve("did:web:broken.example.com", resolve)
+	_, err2 := cache.resolve("did:web:broken.example.com", resolve)
+
+	// Then - the failure is remembered for NegativeTTL
+	if err1 == nil || err2 == nil || calls.Load() != 1 {
+		t.Errorf("Expected one failed resolution, got %d calls (%v, %v)", calls.Load(), err1, err2)
+	}
+	*now = now.Add(30 * time.Second)
+	if _, err := cache.resolve("did:web:broken.example.com", resolve); err == nil || calls.Load() != 2 {
+		t.Errorf("Expected a retry after NegativeTTL, got %d calls", calls.Load())
+	}
+	if stats := cache.snapshot(); stats.Hits != 1 || stats.Misses != 2 || stats.Errors != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestDIDCache_StaleWhileRevalidate(t *testing.T) {
+	// Given - a document cached for a minute, served stale for 5 more
+	cache, now := testDIDCache(DefaultDIDCacheConfig())
+	var calls atomic.Int32
+	var fail atomic.Bool
+	refreshed := make(chan struct{}, 1)
+	resolve := func(did string) (*DIDResolutionResult, error) {
+		defer func() { refreshed <- struct{}{} }()
+		if calls.Add(1); fail.Load() {
+			return nil, errors.New("registry unavailable")
+		}
+		result := documentResult(&DIDDocument{ID: did})
+		result.cachePolicy = cachePolicy{hasMaxAge: true, maxAge: time.Minute}
+		return result, nil
+	}
+	first, _ := cache.resolve("did:web:example.com", resolve)
+	<-refreshed
+
+	// When - expired, and the refresh fails
+	fail.Store(true)
+	*now = now.Add(2 * time.Minute)
+	stale, err := cache.resolve("did:web:example.com", resolve)
+	<-refreshed
+
+	// Then - the stale document is served at once, and kept after the failed refresh
+	if err != nil || stale != first {
+		t.Fatalf("Expected the stale document, got %v", err)
+	}
+	for cache.snapshot().Errors != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if again, err := cache.resolve("did:web:example.com", resolve); err != nil || again != first || calls.Load() != 2 {
+		t.Errorf("Expected the stale document without another refresh, got %d calls (%v)", calls.Load(), err)
+	}
+
+	// When - the registry recovers after NegativeTTL
+	fail.Store(false)
+	*now = now.Add(30 * time.Second)
+	cache.resolve("did:web:example.com", resolve)
+	<-refreshed
+
+	// Then - the refreshed document is served once stored
+	var fresh *DIDResolutionResult
+	for fresh == nil || fresh == first {
+		fresh, _ = cache.resolve("did:web:example.com", resolve)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected one successful refresh, got %d calls", calls.Load())
+	}
+
+	// Past the stale window the document is fetched synchronously
+	*now = now.Add(time.Minute + 5*time.Minute)
+	cache.resolve("did:web:example.com", resolve)
+	if calls.Load() != 4 {
+		t.Errorf("Expected a synchronous fetch past the stale window, got %d calls", calls.Load())
+	}
+}
+
+func TestDIDResolver_WebDIDCacheControl(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for cacheControl, fetches := range map[string]int32{"": 1, "max-age=300": 1, "no-store": 3} {
+		t.Run(cacheControl, func(t *testing.T) {
+			// Given
+			var did string
+			var calls atomic.Int32
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if cacheControl != "" {
+					w.Header().Set("Cache-Control", cacheControl)
+				}
+				json.NewEncoder(w).Encode(testECDIDDocument(did, &key.PublicKey))
+			}))
+			defer server.Close()
+			did = "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1)
+			resolver := NewDIDResolverWithHTTPClient(server.Client())
+
+			// When
+			for i := 0; i < 3; i++ {
+				if _, err := resolver.ResolveVerificationMethod(did, "key-1", AssertionMethod); err != nil {
+					t.Fatalf("ResolveVerificationMethod failed: %v", err)
+				}
+			}
+
+			// Then
+			if calls.Load() != fetches {
+				t.Errorf("Expected %d fetches, got %d", fetches, calls.Load())
+			}
+		})
+	}
+}
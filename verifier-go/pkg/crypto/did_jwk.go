@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JsonWebKeyContext is the JSON-LD context of JsonWebKey2020 verification methods
+const JsonWebKeyContext = "https://w3id.org/security/suites/jws-2020/v1"
+
+// JWKDIDDocument synthesizes the DID document of a did:jwk, ex:
+// did:jwk:eyJrdHkiOiJFQyIs... (https://github.com/quartzjer/did-jwk). The
+// document has a single JsonWebKey2020 verification method, <did>#0, used for
+// authentication and assertions unless the key's "use" is "enc". A DID URL is
+// accepted if its fragment is that verification method.
+func JWKDIDDocument(didURL string) (*DIDDocument, error) {
+	did, fragment, _ := strings.Cut(didURL, "#")
+	encoded, ok := strings.CutPrefix(did, "did:jwk:")
+	if !ok || encoded == "" {
+		return nil, fmt.Errorf("invalid did:jwk format: %s", didURL)
+	}
+	if fragment != "" && fragment != "0" {
+		return nil, fmt.Errorf("verification method not found: %s", didURL)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid did:jwk %s: JWK is not base64url: %w", did, err)
+	}
+	var jwk JWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("invalid did:jwk %s: %w", did, err)
+	}
+	if jwk.D != "" {
+		return nil, fmt.Errorf("invalid did:jwk %s: JWK contains private key material", did)
+	}
+
+	vmID := did + "#0"
+	didDoc := &DIDDocument{
+		Context: []string{"https://www.w3.org/ns/did/v1", JsonWebKeyContext},
+		ID:      did,
+		VerificationMethod: []VerificationMethod{{
+			ID:           vmID,
+			Type:         "JsonWebKey2020",
+			Controller:   did,
+			PublicKeyJwk: &jwk,
+		}},
+	}
+	if jwk.Use != "enc" {
+		didDoc.Authentication = []interface{}{vmID}
+		didDoc.AssertionMethod = []interface{}{vmID}
+	}
+	if jwk.Use != "sig" {
+		didDoc.KeyAgreement = []interface{}{vmID}
+	}
+
+	return didDoc, nil
+}
@@ -0,0 +1,201 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// encodeTestJWKDID encodes a public JWK as a did:jwk
+func encodeTestJWKDID(t *testing.T, jwk map[string]interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatalf("Failed to marshal JWK: %v", err)
+	}
+	return "did:jwk:" + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// testPublicJWK returns the public JWK of a test key
+func testPublicJWK(t *testing.T, publicKey interface{}) map[string]interface{} {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]interface{}{"kty": "EC", "crv": k.Curve.Params().Name, "x": b64(k.X.FillBytes(make([]byte, size))), "y": b64(k.Y.FillBytes(make([]byte, size)))}
+	case ed25519.PublicKey:
+		return map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": b64(k)}
+	case *rsa.PublicKey:
+		return map[string]interface{}{"kty": "RSA", "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	}
+	t.Fatalf("Unsupported key type %T", publicKey)
+	return nil
+}
+
+func TestJWKDIDDocument_Structure(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk := testPublicJWK(t, &key.PublicKey)
+
+	tests := []struct {
+		name         string
+		use          string
+		signing      bool
+		keyAgreement bool
+	}{
+		{"no use", "", true, true},
+		{"sig", "sig", true, false},
+		{"enc", "enc", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			withUse := map[string]interface{}{}
+			for k, v := range jwk {
+				withUse[k] = v
+			}
+			if tt.use != "" {
+				withUse["use"] = tt.use
+			}
+			did := encodeTestJWKDID(t, withUse)
+
+			// When
+			didDoc, err := JWKDIDDocument(did)
+			if err != nil {
+				t.Fatalf("JWKDIDDocument failed: %v", err)
+			}
+
+			// Then - one JsonWebKey2020 verification method, #0
+			vmID := did + "#0"
+			if didDoc.ID != did || len(didDoc.VerificationMethod) != 1 || didDoc.VerificationMethod[0].ID != vmID {
+				t.Fatalf("Unexpected DID document: %+v", didDoc)
+			}
+			vm := didDoc.VerificationMethod[0]
+			if vm.Type != "JsonWebKey2020" || vm.Controller != did || vm.PublicKeyJwk == nil || vm.PublicKeyJwk.X != jwk["x"] {
+				t.Errorf("Unexpected verification method: %+v", vm)
+			}
+			if signing := len(didDoc.Authentication) == 1 && len(didDoc.AssertionMethod) == 1; signing != tt.signing {
+				t.Errorf("Expected signing relationships %v, got %v / %v", tt.signing, didDoc.Authentication, didDoc.AssertionMethod)
+			}
+			if keyAgreement := len(didDoc.KeyAgreement) == 1; keyAgreement != tt.keyAgreement {
+				t.Errorf("Expected key agreement %v, got %v", tt.keyAgreement, didDoc.KeyAgreement)
+			}
+		})
+	}
+}
+
+func TestJWKDIDDocument_Invalid(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	did := encodeTestJWKDID(t, testPublicJWK(t, &key.PublicKey))
+
+	withPrivate := testPublicJWK(t, &key.PublicKey)
+	withPrivate["d"] = base64.RawURLEncoding.EncodeToString(key.D.Bytes())
+
+	for _, didURL := range []string{
+		"did:jwk:",
+		"did:jwk:not base64",
+		"did:jwk:" + base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		encodeTestJWKDID(t, withPrivate),
+		did + "#1",
+	} {
+		if _, err := JWKDIDDocument(didURL); err == nil {
+			t.Errorf("Expected an error for %s", didURL)
+		}
+	}
+
+	// The verification method's DID URL resolves
+	if _, err := JWKDIDDocument(did + "#0"); err != nil {
+		t.Errorf("Expected the verification method DID URL to resolve, got %v", err)
+	}
+}
+
+func TestResolveKey_JWKDIDRejectsNonSigningKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encJWK := testPublicJWK(t, &key.PublicKey)
+	encJWK["use"] = "enc"
+
+	for name, jwk := range map[string]map[string]interface{}{
+		"X25519":  {"kty": "OKP", "crv": "X25519", "x": base64.RawURLEncoding.EncodeToString(make([]byte, 32))},
+		"use enc": encJWK,
+		"RSA-1024": func() map[string]interface{} {
+			rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+			return testPublicJWK(t, &rsaKey.PublicKey)
+		}(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// When
+			_, err := NewDIDResolver().ResolveKey(encodeTestJWKDID(t, jwk))
+
+			// Then
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestValidate_JWKDIDSigners(t *testing.T) {
+	ed25519Public, ed25519Private, _ := ed25519.GenerateKey(rand.Reader)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secp256k1Key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name       string
+		publicKey  interface{}
+		privateKey interface{}
+	}{
+		{"EC P-256", &p256Key.PublicKey, p256Key},
+		{"EC secp256k1", &secp256k1Key.PublicKey, secp256k1Key},
+		{"OKP Ed25519", ed25519Public, ed25519Private},
+		{"RSA", &rsaKey.PublicKey, rsaKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given - a credential and a presentation signed by did:jwk controllers
+			did := encodeTestJWKDID(t, testPublicJWK(t, tt.publicKey))
+			validator := NewJWTValidator(NewDIDResolver())
+			now := time.Now()
+
+			vcJWT, err := SignVC(&VCClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Issuer: did, Subject: did, ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+				VC:               CredentialSubject{Type: []string{"VerifiableCredential"}},
+			}, tt.privateKey, did+"#0")
+			if err != nil {
+				t.Fatalf("SignVC failed: %v", err)
+			}
+			vpJWT, err := SignVP(&VPClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: did, ID: "nonce-1", Audience: jwt.ClaimStrings{"verifier"}, ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+				VP:               PresentationSubject{Type: []string{"VerifiablePresentation"}, VerifiableCredential: []string{vcJWT}},
+			}, tt.privateKey, did+"#0")
+			if err != nil {
+				t.Fatalf("SignVP failed: %v", err)
+			}
+
+			// When
+			vcClaims, vcErr := validator.ValidateVC(vcJWT)
+			vpClaims, vpErr := validator.ValidateVP(vpJWT, "nonce-1", "verifier")
+
+			// Then
+			if vcErr != nil || vcClaims.Issuer != did {
+				t.Errorf("Expected the VC to validate, got %v", vcErr)
+			}
+			if vpErr != nil || vpClaims.Subject != did {
+				t.Errorf("Expected the VP to validate, got %v", vpErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Multicodec codes of the public key types accepted in did:key and
+// publicKeyMultibase values (https://github.com/multiformats/multicodec)
+const (
+	multicodecEd25519Pub   = 0xed
+	multicodecX25519Pub    = 0xec
+	multicodecSecp256k1Pub = 0xe7
+	multicodecP256Pub      = 0x1200
+	multicodecP384Pub      = 0x1201
+	multicodecP521Pub      = 0x1202
+)
+
+// MultikeyContext is the JSON-LD context of Multikey verification methods
+const MultikeyContext = "https://w3id.org/security/multikey/v1"
+
+// base58btcAlphabet is the Bitcoin base58 alphabet
+const base58btcAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// KeyDIDDocument synthesizes the DID document of a did:key, ex:
+// did:key:zDnae... The document has a single Multikey verification method,
+// <did>#<multibase value>, used for authentication and assertions. A DID URL
+// is accepted if its fragment is that verification method.
+func KeyDIDDocument(didURL string) (*DIDDocument, error) {
+	did, fragment, _ := strings.Cut(didURL, "#")
+	multibase, ok := strings.CutPrefix(did, "did:key:")
+	if !ok || multibase == "" || strings.ContainsAny(multibase, ":/?") {
+		return nil, fmt.Errorf("invalid did:key format: %s", didURL)
+	}
+	if fragment != "" && fragment != multibase {
+		return nil, fmt.Errorf("verification method not found: %s", didURL)
+	}
+
+	// Reject keys that cannot verify signatures before building the document
+	if _, err := decodeMultikey(multibase); err != nil {
+		return nil, fmt.Errorf("invalid did:key %s: %w", did, err)
+	}
+
+	vmID := did + "#" + multibase
+	return &DIDDocument{
+		Context: []string{"https://www.w3.org/ns/did/v1", MultikeyContext},
+		ID:      did,
+		VerificationMethod: []VerificationMethod{{
+			ID:                 vmID,
+			Type:               "Multikey",
+			Controller:         did,
+			PublicKeyMultibase: multibase,
+		}},
+		Authentication:  []interface{}{vmID},
+		AssertionMethod: []interface{}{vmID},
+	}, nil
+}
+
+// decodeMultikey decodes a multibase, multicodec prefixed public key:
+// Ed25519, or a compressed P-256, P-384, P-521 or secp256k1 point
+func decodeMultikey(multibase string) (interface{}, error) {
+	data, err := decodeMultibase(multibase)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid multicodec prefix")
+	}
+	key := data[n:]
+
+	switch codec {
+	case multicodecEd25519Pub:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(key))
+		}
+		return ed25519.PublicKey(key), nil
+	case multicodecP256Pub:
+		return decompressNISTPoint(elliptic.P256(), key)
+	case multicodecP384Pub:
+		return decompressNISTPoint(elliptic.P384(), key)
+	case multicodecP521Pub:
+		return decompressNISTPoint(elliptic.P521(), key)
+	case multicodecSecp256k1Pub:
+		if len(key) != secp256k1.PubKeyBytesLenCompressed {
+			return nil, fmt.Errorf("invalid compressed secp256k1 point")
+		}
+		return parseSecp256k1Point(key)
+	case multicodecX25519Pub:
+		return nil, fmt.Errorf("X25519 is a key agreement key and cannot verify signatures")
+	default:
+		return nil, fmt.Errorf("unsupported multicodec key type: 0x%x", codec)
+	}
+}
+
+// decompressNISTPoint decodes a SEC 1 compressed point on a NIST curve
+func decompressNISTPoint(curve elliptic.Curve, data []byte) (interface{}, error) {
+	x, y := elliptic.UnmarshalCompressed(curve, data)
+	if x == nil {
+		return nil, fmt.Errorf("invalid compressed %s point", curve.Params().Name)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// decodeMultibase decodes a multibase value. Only base58btc ('z'), the
+// encoding of did:key and Multikey, is supported.
+func decodeMultibase(value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("empty multibase value")
+	}
+	if value[0] != 'z' {
+		return nil, fmt.Errorf("unsupported multibase encoding: %q", value[0])
+	}
+	return decodeBase58(value[1:])
+}
+
+// decodeBase58 decodes a Bitcoin alphabet base58 string
+func decodeBase58(value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("empty base58 value")
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range value {
+		digit := strings.IndexRune(base58btcAlphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	zeros := len(value) - len(strings.TrimLeft(value, "1"))
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
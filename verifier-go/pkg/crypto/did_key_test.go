@@ -0,0 +1,238 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// encodeTestKeyDID encodes a public key as a did:key
+func encodeTestKeyDID(t *testing.T, publicKey interface{}) string {
+	t.Helper()
+
+	var codec uint64
+	var key []byte
+	switch k := publicKey.(type) {
+	case ed25519.PublicKey:
+		codec, key = multicodecEd25519Pub, k
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			codec = multicodecP256Pub
+		case elliptic.P384():
+			codec = multicodecP384Pub
+		case Secp256k1():
+			codec = multicodecSecp256k1Pub
+		}
+		key = make([]byte, 1+(k.Curve.Params().BitSize+7)/8)
+		key[0] = byte(2 + k.Y.Bit(0))
+		k.X.FillBytes(key[1:])
+	default:
+		t.Fatalf("Unsupported key type %T", publicKey)
+	}
+
+	data := binary.AppendUvarint(nil, codec)
+	return "did:key:z" + encodeTestBase58(append(data, key...))
+}
+
+// encodeTestBase58 encodes bytes in the Bitcoin base58 alphabet
+func encodeTestBase58(data []byte) string {
+	var encoded []byte
+	n := new(big.Int).SetBytes(data)
+	radix, digit := big.NewInt(58), new(big.Int)
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, digit)
+		encoded = append([]byte{base58btcAlphabet[digit.Int64()]}, encoded...)
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append([]byte{'1'}, encoded...)
+	}
+	return string(encoded)
+}
+
+func TestSecp256k1_KnownAnswer(t *testing.T) {
+	curve := Secp256k1()
+	params := curve.Params()
+
+	if !curve.IsOnCurve(params.Gx, params.Gy) {
+		t.Fatal("Expected the generator on the curve")
+	}
+
+	// 2G
+	x, y := curve.ScalarBaseMult([]byte{2})
+	if x.Text(16) != "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5" ||
+		y.Text(16) != "1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a" {
+		t.Errorf("Unexpected 2G: %x, %x", x, y)
+	}
+
+	// nG is the point at infinity
+	if x, y := curve.ScalarBaseMult(params.N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("Expected the point at infinity, got %x, %x", x, y)
+	}
+}
+
+func TestKeyDIDDocument_SpecVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		did   string
+		curve string
+	}{
+		{"Ed25519", "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK", ""},
+		{"P-256", "did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169", "P-256"},
+		{"P-384", "did:key:z82Lm1MpAkeJcix9K8TMiLd5NMAhnwkjjCBeWHXyu3U4oT2MVJJKXkcVBgjGhnLBn2Kaau9", "P-384"},
+		{"secp256k1", "did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme", "secp256k1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			didDoc, err := KeyDIDDocument(tt.did)
+			if err != nil {
+				t.Fatalf("KeyDIDDocument failed: %v", err)
+			}
+
+			// Then - one Multikey verification method named after the key
+			vmID := tt.did + "#" + strings.TrimPrefix(tt.did, "did:key:")
+			if didDoc.ID != tt.did || len(didDoc.VerificationMethod) != 1 || didDoc.VerificationMethod[0].ID != vmID {
+				t.Fatalf("Unexpected DID document: %+v", didDoc)
+			}
+			if didDoc.VerificationMethod[0].Type != "Multikey" || didDoc.VerificationMethod[0].Controller != tt.did {
+				t.Errorf("Unexpected verification method: %+v", didDoc.VerificationMethod[0])
+			}
+			if len(didDoc.Authentication) != 1 || didDoc.Authentication[0] != vmID || len(didDoc.AssertionMethod) != 1 || didDoc.AssertionMethod[0] != vmID {
+				t.Errorf("Expected %s for authentication and assertions, got %v / %v", vmID, didDoc.Authentication, didDoc.AssertionMethod)
+			}
+
+			key, err := NewDIDResolver().ResolveKey(tt.did)
+			if err != nil {
+				t.Fatalf("ResolveKey failed: %v", err)
+			}
+			switch k := key.(type) {
+			case ed25519.PublicKey:
+				if tt.curve != "" {
+					t.Errorf("Expected a %s key, got Ed25519", tt.curve)
+				}
+			case *ecdsa.PublicKey:
+				if k.Curve.Params().Name != tt.curve || !k.Curve.IsOnCurve(k.X, k.Y) {
+					t.Errorf("Expected a %s point, got %s", tt.curve, k.Curve.Params().Name)
+				}
+			default:
+				t.Errorf("Unexpected key type %T", key)
+			}
+		})
+	}
+}
+
+func TestKeyDIDDocument_Invalid(t *testing.T) {
+	for _, did := range []string{
+		"did:key:",
+		"did:key:6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",        // not multibase
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2do0",       // not base58
+		"did:key:z6LSeu9HkTHSfLLeUs2nnzUSNedgDUevfNQgQjQC23ZCit6F",       // X25519
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK#key-1", // other fragment
+		"did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169x",     // truncated point
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK:other", // extra segment
+	} {
+		if _, err := KeyDIDDocument(did); err == nil {
+			t.Errorf("Expected an error for %s", did)
+		}
+	}
+
+	// The verification method's DID URL resolves
+	did := "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	if _, err := KeyDIDDocument(did + "#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"); err != nil {
+		t.Errorf("Expected the verification method DID URL to resolve, got %v", err)
+	}
+}
+
+func TestValidate_KeyDIDSigners(t *testing.T) {
+	ed25519Public, ed25519Private, _ := ed25519.GenerateKey(rand.Reader)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	secp256k1Key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+
+	tests := []struct {
+		name       string
+		publicKey  interface{}
+		privateKey interface{}
+		alg        string
+	}{
+		{"Ed25519", ed25519Public, ed25519Private, "EdDSA"},
+		{"P-256", &p256Key.PublicKey, p256Key, "ES256"},
+		{"P-384", &p384Key.PublicKey, p384Key, "ES384"},
+		{"secp256k1", &secp256k1Key.PublicKey, secp256k1Key, "ES256K"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given - a credential and a presentation signed by did:key controllers
+			did := encodeTestKeyDID(t, tt.publicKey)
+			kid := did + "#" + strings.TrimPrefix(did, "did:key:")
+			validator := NewJWTValidator(NewDIDResolver())
+			now := time.Now()
+
+			vcJWT, err := SignVC(&VCClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Issuer: did, Subject: did, ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+				VC:               CredentialSubject{Type: []string{"VerifiableCredential"}},
+			}, tt.privateKey, kid)
+			if err != nil {
+				t.Fatalf("SignVC failed: %v", err)
+			}
+			vpJWT, err := SignVP(&VPClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: did, ID: "nonce-1", Audience: jwt.ClaimStrings{"verifier"}, ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+				VP:               PresentationSubject{Type: []string{"VerifiablePresentation"}, VerifiableCredential: []string{vcJWT}},
+			}, tt.privateKey, kid)
+			if err != nil {
+				t.Fatalf("SignVP failed: %v", err)
+			}
+
+			// When
+			vcClaims, vcErr := validator.ValidateVC(vcJWT)
+			vpClaims, vpErr := validator.ValidateVP(vpJWT, "nonce-1", "verifier")
+
+			// Then
+			if vcErr != nil || vcClaims.Issuer != did {
+				t.Errorf("Expected the VC to validate, got %v", vcErr)
+			}
+			if vpErr != nil || vpClaims.Subject != did {
+				t.Errorf("Expected the VP to validate, got %v", vpErr)
+			}
+			token, _, _ := new(jwt.Parser).ParseUnverified(vpJWT, &VPClaims{})
+			if token.Method.Alg() != tt.alg {
+				t.Errorf("Expected alg %s, got %s", tt.alg, token.Method.Alg())
+			}
+		})
+	}
+}
+
+func TestValidateVP_KeyDIDAlgorithmMismatch(t *testing.T) {
+	// Given - a secp256k1 holder signing with the P-256 algorithm
+	key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	did := encodeTestKeyDID(t, &key.PublicKey)
+	token := jwt.NewWithClaims(&jwt.SigningMethodECDSA{Name: "ES256", Hash: SigningMethodES256K.Hash, KeySize: 32, CurveBits: 256}, &VPClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: did, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	vpJWT, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign VP: %v", err)
+	}
+
+	// When
+	_, err = NewJWTValidator(NewDIDResolver()).ValidateVP(vpJWT, "", "")
+
+	// Then
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected an algorithm mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DIDMethodResolver resolves the DIDs of a DID method to their DID documents
+// and metadata. A deactivated DID resolves with DIDDocumentMetadata.Deactivated
+// set. Resolvers are registered on a DIDResolver with RegisterMethod.
+type DIDMethodResolver interface {
+	Resolve(did string) (*DIDResolutionResult, error)
+}
+
+// DIDMethodResolverFunc adapts a function to a DIDMethodResolver
+type DIDMethodResolverFunc func(did string) (*DIDResolutionResult, error)
+
+// Resolve calls f(did)
+func (f DIDMethodResolverFunc) Resolve(did string) (*DIDResolutionResult, error) {
+	return f(did)
+}
+
+// didMethod returns the method name of a DID, ex: "web" for did:web:example.com
+func didMethod(did string) (string, error) {
+	rest, ok := strings.CutPrefix(did, "did:")
+	method, id, found := strings.Cut(rest, ":")
+	if !ok || !found || method == "" || id == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDID, did)
+	}
+	for _, c := range method {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return "", fmt.Errorf("%w: invalid method name %q", ErrInvalidDID, method)
+		}
+	}
+	return method, nil
+}
+
+// didResolutionAccept asks a resolver for a DID resolution result, falling
+// back to a bare DID document
+const didResolutionAccept = `application/ld+json;profile="https://w3id.org/did-resolution", application/did+ld+json, application/did+json, application/json`
+
+// UniversalResolver resolves DIDs of any method through an HTTP endpoint
+// compatible with the DIF Universal Resolver, ex: a TWDIW DID registry
+// gateway: GET <baseURL>/1.0/identifiers/<did>
+type UniversalResolver struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewUniversalResolver creates a Universal Resolver driver with a 10 second timeout
+func NewUniversalResolver(baseURL string) *UniversalResolver {
+	return NewUniversalResolverWithHTTPClient(baseURL, &http.Client{
+		Timeout: 10 * time.Second,
+	})
+}
+
+// NewUniversalResolverWithHTTPClient creates a Universal Resolver driver using client
+func NewUniversalResolverWithHTTPClient(baseURL string, client *http.Client) *UniversalResolver {
+	return &UniversalResolver{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: client,
+	}
+}
+
+// Resolve fetches the DID resolution result of did. The endpoint may answer
+// with a resolution result or with the document itself; either way the
+// document must name did as its id. A deactivated DID may be answered with
+// 410 Gone. The result is cached for the max-age of the response's
+// Cache-Control header.
+func (u *UniversalResolver) Resolve(did string) (*DIDResolutionResult, error) {
+	req, err := http.NewRequest(http.MethodGet, u.baseURL+"/1.0/identifiers/"+url.PathEscape(did), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid universal resolver URL: %w", err)
+	}
+	req.Header.Set("Accept", didResolutionAccept)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", did, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDIDDocumentSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", did, err)
+	}
+
+	var result DIDResolutionResult
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusGone:
+		// The body may carry the last document and its metadata
+		_ = json.Unmarshal(data, &result)
+		result.DIDDocumentMetadata.Deactivated = true
+		result.cachePolicy = parseCacheControl(resp.Header)
+		return &result, nil
+	case http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: %s rejected by the universal resolver", ErrInvalidDID, did)
+	default:
+		return nil, fmt.Errorf("failed to resolve %s: status %d", did, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse DID resolution result: %w", err)
+	}
+	switch result.DIDResolutionMetadata.Error {
+	case "":
+	case "invalidDid":
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDID, did)
+	default:
+		return nil, fmt.Errorf("failed to resolve %s: %s", did, result.DIDResolutionMetadata.Error)
+	}
+	if result.DIDDocument == nil {
+		// A bare DID document
+		result = DIDResolutionResult{DIDDocument: &DIDDocument{}}
+		if err := json.Unmarshal(data, result.DIDDocument); err != nil {
+			return nil, fmt.Errorf("failed to parse DID document: %w", err)
+		}
+		result.DIDResolutionMetadata.ContentType = resp.Header.Get("Content-Type")
+	}
+	result.cachePolicy = parseCacheControl(resp.Header)
+	if result.DIDDocument.ID != did {
+		return nil, fmt.Errorf("DID document id %q does not match %s", result.DIDDocument.ID, did)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,234 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testECDIDDocument returns a DID document with one P-256 JsonWebKey2020 assertion method
+func testECDIDDocument(did string, key *ecdsa.PublicKey) *DIDDocument {
+	return &DIDDocument{
+		Context: []string{"https://www.w3.org/ns/did/v1"},
+		ID:      did,
+		VerificationMethod: []VerificationMethod{{
+			ID:           did + "#key-1",
+			Type:         "JsonWebKey2020",
+			Controller:   did,
+			PublicKeyJwk: &JWK{Kty: "EC", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))), Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))},
+		}},
+		AssertionMethod: []interface{}{did + "#key-1"},
+	}
+}
+
+func TestUniversalResolver_Resolve(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	did := "did:twdiw:0x1234abcd"
+
+	tests := []struct {
+		name string
+		body func() interface{}
+	}{
+		{"resolution result", func() interface{} {
+			return map[string]interface{}{
+				"@context":              "https://w3id.org/did-resolution/v1",
+				"didDocument":           testECDIDDocument(did, &key.PublicKey),
+				"didResolutionMetadata": map[string]interface{}{"contentType": "application/did+ld+json"},
+				"didDocumentMetadata":   map[string]interface{}{},
+			}
+		}},
+		{"bare DID document", func() interface{} {
+			return testECDIDDocument(did, &key.PublicKey)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given - a stand-in Universal Resolver registered for did:twdiw
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/1.0/identifiers/"+did || !strings.Contains(r.Header.Get("Accept"), "did-resolution") {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(tt.body())
+			}))
+			defer server.Close()
+
+			resolver := NewDIDResolver()
+			resolver.RegisterMethod("twdiw", NewUniversalResolverWithHTTPClient(server.URL+"/", server.Client()))
+
+			// When
+			resolved, err := resolver.ResolveVerificationMethod(did, "key-1", AssertionMethod)
+
+			// Then
+			if err != nil {
+				t.Fatalf("ResolveVerificationMethod failed: %v", err)
+			}
+			if ecKey, ok := resolved.(*ecdsa.PublicKey); !ok || !ecKey.Equal(&key.PublicKey) {
+				t.Errorf("Expected the registry's key, got %v", resolved)
+			}
+		})
+	}
+}
+
+func TestUniversalResolver_Errors(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"not found": func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		},
+		"other DID": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"didDocument": testECDIDDocument("did:twdiw:other", &key.PublicKey)})
+		},
+		"not JSON": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html></html>"))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			// When
+			_, err := NewUniversalResolverWithHTTPClient(server.URL, server.Client()).Resolve("did:twdiw:0x1234abcd")
+
+			// Then
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestDIDResolver_MethodRegistry(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	documents := DIDMethodResolverFunc(func(did string) (*DIDResolutionResult, error) {
+		return documentResult(testECDIDDocument(did, &key.PublicKey)), nil
+	})
+
+	// Given - no resolver for did:ebsi
+	resolver := NewDIDResolver()
+	if _, err := resolver.ResolveKey("did:ebsi:z123"); err == nil || !strings.Contains(err.Error(), "unsupported DID method") {
+		t.Errorf("Expected an unsupported DID method, got %v", err)
+	}
+
+	// When - a default method resolver is set
+	resolver.SetDefaultMethodResolver(documents)
+
+	// Then
+	if resolved, err := resolver.ResolveKey("did:ebsi:z123"); err != nil || !resolved.(*ecdsa.PublicKey).Equal(&key.PublicKey) {
+		t.Errorf("Expected the default resolver's key, got %v", err)
+	}
+
+	// When - a built-in method is replaced
+	resolver.RegisterMethod("web", documents)
+
+	// Then
+	if _, err := resolver.ResolveKey("did:web:example.com"); err != nil {
+		t.Errorf("Expected the registered did:web resolver to be used, got %v", err)
+	}
+
+	// Malformed DIDs never reach a method resolver
+	for _, did := range []string{"did:", "did:ebsi", "did:EBSI:z123", "urn:ebsi:z123"} {
+		if _, err := resolver.ResolveKey(did); err == nil {
+			t.Errorf("Expected an error for %s", did)
+		}
+	}
+}
+
+func TestDIDResolver_ResolutionMetadata(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	did := "did:twdiw:0x1234abcd"
+
+	tests := []struct {
+		name        string
+		status      int
+		body        interface{}
+		deactivated bool
+	}{
+		{"active", http.StatusOK, map[string]interface{}{
+			"didDocument":         testECDIDDocument(did, &key.PublicKey),
+			"didDocumentMetadata": map[string]interface{}{"updated": "2025-06-01T00:00:00Z", "versionId": "3"},
+		}, false},
+		{"deactivated in metadata", http.StatusOK, map[string]interface{}{
+			"didDocument":         testECDIDDocument(did, &key.PublicKey),
+			"didDocumentMetadata": map[string]interface{}{"deactivated": true},
+		}, true},
+		{"410 Gone", http.StatusGone, map[string]interface{}{
+			"didDocumentMetadata": map[string]interface{}{"deactivated": true},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(tt.body)
+			}))
+			defer server.Close()
+			resolver := NewDIDResolver()
+			resolver.RegisterMethod("twdiw", NewUniversalResolverWithHTTPClient(server.URL, server.Client()))
+
+			// When
+			result, err := resolver.Resolve(did)
+			_, keyErr := resolver.ResolveKey(did)
+
+			// Then - resolution succeeds, but a deactivated DID has no usable key
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if result.DIDDocumentMetadata.Deactivated != tt.deactivated {
+				t.Errorf("Expected deactivated %v, got %+v", tt.deactivated, result.DIDDocumentMetadata)
+			}
+			if tt.deactivated && !errors.Is(keyErr, ErrDIDDeactivated) {
+				t.Errorf("Expected ErrDIDDeactivated, got %v", keyErr)
+			}
+			if !tt.deactivated && (keyErr != nil || result.DIDDocumentMetadata.VersionID != "3" || result.DIDDocumentMetadata.Updated == "") {
+				t.Errorf("Expected the document and its metadata, got %+v (%v)", result.DIDDocumentMetadata, keyErr)
+			}
+		})
+	}
+}
+
+func TestDIDResolver_DeactivatedWebDID(t *testing.T) {
+	// Given - a did:web whose document is gone
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+	did := "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1)
+
+	// When
+	_, err := NewDIDResolverWithHTTPClient(server.Client()).ResolveKey(did)
+
+	// Then
+	if !errors.Is(err, ErrDIDDeactivated) {
+		t.Errorf("Expected ErrDIDDeactivated, got %v", err)
+	}
+}
+
+func TestDIDResolver_InvalidDID(t *testing.T) {
+	resolver := NewDIDResolver()
+
+	for _, did := range []string{
+		"issuer",
+		"did:",
+		"did:Web:example.com",
+		"did:web:example.com%2Fevil",
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2do0",
+		"did:jwk:not-a-jwk",
+	} {
+		if _, err := resolver.ResolveKey(did); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("Expected ErrInvalidDID for %s, got %v", did, err)
+		}
+	}
+}
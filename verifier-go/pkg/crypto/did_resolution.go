@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Resolution errors wrapped by DIDResolver
+var (
+	// ErrInvalidDID reports a DID that does not conform to its method's syntax
+	ErrInvalidDID = errors.New("invalid DID")
+	// ErrDIDDeactivated reports a DID whose controller deactivated it
+	ErrDIDDeactivated = errors.New("DID is deactivated")
+)
+
+// DIDResolutionResult is the result of resolving a DID
+// (https://w3c.github.io/did-resolution/#did-resolution-result)
+type DIDResolutionResult struct {
+	DIDDocument           *DIDDocument          `json:"didDocument"`
+	DIDResolutionMetadata DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   DIDDocumentMetadata   `json:"didDocumentMetadata"`
+
+	// cachePolicy is the Cache-Control of the HTTP response the result came from
+	cachePolicy cachePolicy
+}
+
+// DIDResolutionMetadata describes the resolution process
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"` // ex: invalidDid, notFound
+}
+
+// DIDDocumentMetadata describes the DID document, ex: whether it was deactivated
+type DIDDocumentMetadata struct {
+	Created     string `json:"created,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+	Deactivated bool   `json:"deactivated,omitempty"`
+	VersionID   string `json:"versionId,omitempty"`
+}
+
+// didDocumentContentType is the media type of the DID documents resolvers produce
+const didDocumentContentType = "application/did+json"
+
+// documentResult wraps a DID document resolved without metadata
+func documentResult(didDoc *DIDDocument) *DIDResolutionResult {
+	return &DIDResolutionResult{
+		DIDDocument:           didDoc,
+		DIDResolutionMetadata: DIDResolutionMetadata{ContentType: didDocumentContentType},
+	}
+}
+
+// intrinsicDocument adapts a method whose DID documents are derived from the
+// DID itself (did:key, did:jwk): any failure means the DID is invalid
+func intrinsicDocument(document func(did string) (*DIDDocument, error)) DIDMethodResolver {
+	return DIDMethodResolverFunc(func(did string) (*DIDResolutionResult, error) {
+		didDoc, err := document(did)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidDID, err)
+		}
+		return documentResult(didDoc), nil
+	})
+}
@@ -2,13 +2,15 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/x509"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/big"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +23,7 @@ type DIDDocument struct {
 	VerificationMethod []VerificationMethod     `json:"verificationMethod"`
 	Authentication     []interface{}            `json:"authentication,omitempty"`
 	AssertionMethod    []interface{}            `json:"assertionMethod,omitempty"`
+	KeyAgreement       []interface{}            `json:"keyAgreement,omitempty"`
 	Service            []Service                `json:"service,omitempty"`
 }
 
@@ -36,10 +39,13 @@ type VerificationMethod struct {
 
 // JWK represents a JSON Web Key
 type JWK struct {
-	Kty string `json:"kty"` // Key Type
-	Crv string `json:"crv"` // Curve
-	X   string `json:"x"`   // X coordinate
-	Y   string `json:"y"`   // Y coordinate
+	Kty string `json:"kty"`           // Key Type: EC, OKP or RSA
+	Crv string `json:"crv,omitempty"` // Curve (EC, OKP)
+	X   string `json:"x,omitempty"`   // X coordinate (EC) or public key (OKP)
+	Y   string `json:"y,omitempty"`   // Y coordinate (EC)
+	N   string `json:"n,omitempty"`   // Modulus (RSA)
+	E   string `json:"e,omitempty"`   // Exponent (RSA)
+	D   string `json:"d,omitempty"`   // Private key; never accepted
 	Use string `json:"use,omitempty"`
 	Kid string `json:"kid,omitempty"`
 }
@@ -51,88 +57,172 @@ type Service struct {
 	ServiceEndpoint string `json:"serviceEndpoint"`
 }
 
+// Verification relationships a key may be authorized for (DID Core §5.3)
+const (
+	// AssertionMethod authorizes a verification method to issue credentials
+	AssertionMethod = "assertionMethod"
+	// Authentication authorizes a verification method to sign presentations
+	Authentication = "authentication"
+)
+
+// ErrNoMatchedPublicKey reports a kid naming no verification method of the
+// DID, or a method lacking the required verification relationship
+var ErrNoMatchedPublicKey = errors.New("no matched public key")
+
 // DIDResolver resolves DIDs to public keys
 type DIDResolver struct {
-	// Cache for resolved keys
-	cache map[string]cachedKey
+	// Cache of DID resolution results
+	cache *didCache
 	mu    sync.RWMutex
 
 	// HTTP client for remote resolution
 	httpClient *http.Client
 
+	// DID method resolvers by method name, and the resolver of other methods
+	methods       map[string]DIDMethodResolver
+	defaultMethod DIDMethodResolver
+
 	// Local key store for testing
 	localKeys map[string]interface{}
 }
 
-type cachedKey struct {
-	key       interface{}
-	expiresAt time.Time
-}
+// maxDIDDocumentSize bounds the did:web documents read from the network
+const maxDIDDocumentSize = 1 << 20
 
-// NewDIDResolver creates a new DID resolver
+// NewDIDResolver creates a new DID resolver with a 10 second HTTP timeout
 func NewDIDResolver() *DIDResolver {
-	return &DIDResolver{
-		cache: make(map[string]cachedKey),
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		localKeys: make(map[string]interface{}),
+	return NewDIDResolverWithHTTPClient(&http.Client{
+		Timeout: 10 * time.Second,
+	})
+}
+
+// NewDIDResolverWithHTTPClient creates a DID resolver fetching did:web
+// documents with client, ex: one trusting a test server's certificate
+func NewDIDResolverWithHTTPClient(client *http.Client) *DIDResolver {
+	r := &DIDResolver{
+		cache:      newDIDCache(DefaultDIDCacheConfig()),
+		httpClient: client,
+		methods:    make(map[string]DIDMethodResolver),
+		localKeys:  make(map[string]interface{}),
 	}
+	r.methods["web"] = DIDMethodResolverFunc(r.resolveWebDID)
+	r.methods["key"] = intrinsicDocument(KeyDIDDocument)
+	r.methods["jwk"] = intrinsicDocument(r.resolveJWKDID)
+	return r
 }
 
-// ResolveKey resolves a DID to its public key
-func (r *DIDResolver) ResolveKey(did string) (interface{}, error) {
-	// Check cache first
-	r.mu.RLock()
-	if cached, ok := r.cache[did]; ok && time.Now().Before(cached.expiresAt) {
-		r.mu.RUnlock()
-		return cached.key, nil
+// RegisterMethod registers the resolver of a DID method, ex: "twdiw", replacing
+// any built-in one (web, key, jwk)
+func (r *DIDResolver) RegisterMethod(method string, resolver DIDMethodResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methods[method] = resolver
+}
+
+// SetDefaultMethodResolver sets the resolver of DID methods without a
+// registered resolver, ex: a UniversalResolver
+func (r *DIDResolver) SetDefaultMethodResolver(resolver DIDMethodResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultMethod = resolver
+}
+
+// ResolveKey resolves a DID to its public key. A DID URL, ex:
+// did:web:example.com#key-2, resolves to the key of that verification method.
+func (r *DIDResolver) ResolveKey(didURL string) (interface{}, error) {
+	did, fragment, _ := strings.Cut(didURL, "#")
+	return r.ResolveVerificationMethod(did, fragment, "")
+}
+
+// ResolveVerificationMethod resolves the key of the DID's verification method
+// named by kid: a DID URL (did:web:example.com#key-1), a relative reference
+// (#key-1) or a bare fragment (key-1). An empty kid selects the first method.
+// A non-empty relationship, ex: AssertionMethod, restricts the choice to the
+// methods authorized for it. Keys that do not match are reported with
+// ErrNoMatchedPublicKey. Local and did:example keys have no DID document, so
+// only the DID of kid is checked for them.
+func (r *DIDResolver) ResolveVerificationMethod(did, kid, relationship string) (interface{}, error) {
+	if _, err := didMethod(did); err != nil {
+		return nil, err
+	}
+	vmID, err := verificationMethodID(did, kid)
+	if err != nil {
+		return nil, err
 	}
-	r.mu.RUnlock()
 
-	// Check local keys (for testing) - also cache these
+	// Check local keys (for testing)
 	r.mu.RLock()
-	if key, ok := r.localKeys[did]; ok {
-		r.mu.RUnlock()
-		// Cache the local key
-		r.mu.Lock()
-		r.cache[did] = cachedKey{
-			key:       key,
-			expiresAt: time.Now().Add(30 * time.Minute),
-		}
-		r.mu.Unlock()
+	key, ok := r.localKeys[did]
+	r.mu.RUnlock()
+	if ok {
 		return key, nil
 	}
-	r.mu.RUnlock()
 
 	// Resolve based on DID method
-	var key interface{}
-	var err error
-
-	if strings.HasPrefix(did, "did:web:") {
-		key, err = r.resolveWebDID(did)
-	} else if strings.HasPrefix(did, "did:key:") {
-		key, err = r.resolveKeyDID(did)
-	} else if strings.HasPrefix(did, "did:example:") {
+	if strings.HasPrefix(did, "did:example:") {
 		// For testing - use a default key
-		key, err = r.resolveExampleDID(did)
-	} else {
-		return nil, fmt.Errorf("unsupported DID method: %s", did)
+		return r.resolveExampleDID(did)
+	}
+	return r.resolvePublicKey(did, vmID, relationship)
+}
+
+// verificationMethodID returns the DID URL named by kid ("" if kid does not
+// name a verification method)
+func verificationMethodID(did, kid string) (string, error) {
+	switch {
+	case kid == "" || kid == did:
+		return "", nil
+	case strings.HasPrefix(kid, "#"):
+		return did + kid, nil
+	case strings.HasPrefix(kid, "did:"):
+		if kidDID, _, _ := strings.Cut(kid, "#"); kidDID != did {
+			return "", fmt.Errorf("%w: kid %s does not belong to %s", ErrNoMatchedPublicKey, kid, did)
+		}
+		return kid, nil
+	default:
+		return did + "#" + kid, nil
 	}
+}
 
+// Resolve resolves a DID to its DID document and metadata with the resolver
+// of its method. Malformed DIDs are reported with ErrInvalidDID; a deactivated
+// DID resolves with DIDDocumentMetadata.Deactivated set. Results are cached
+// and shared between callers, which must not modify them.
+func (r *DIDResolver) Resolve(did string) (*DIDResolutionResult, error) {
+	method, err := didMethod(did)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the resolved key (30 minutes)
-	r.mu.Lock()
-	r.cache[did] = cachedKey{
-		key:       key,
-		expiresAt: time.Now().Add(30 * time.Minute),
+	r.mu.RLock()
+	resolver, ok := r.methods[method]
+	if !ok {
+		resolver = r.defaultMethod
+	}
+	cache := r.cache
+	r.mu.RUnlock()
+	if resolver == nil {
+		return nil, fmt.Errorf("unsupported DID method: %s", did)
 	}
-	r.mu.Unlock()
 
-	return key, nil
+	return cache.resolve(did, resolver.Resolve)
+}
+
+// resolvePublicKey resolves a DID and selects a key of its document, failing
+// with ErrDIDDeactivated if the DID was deactivated
+func (r *DIDResolver) resolvePublicKey(did, vmID, relationship string) (interface{}, error) {
+	result, err := r.Resolve(did)
+	if err != nil {
+		return nil, err
+	}
+	if result.DIDDocumentMetadata.Deactivated {
+		return nil, fmt.Errorf("%w: %s", ErrDIDDeactivated, did)
+	}
+	if result.DIDDocument == nil {
+		return nil, fmt.Errorf("no DID document found for %s", did)
+	}
+
+	return r.selectPublicKey(result.DIDDocument, vmID, relationship)
 }
 
 // RegisterLocalKey registers a local key for testing
@@ -142,41 +232,101 @@ func (r *DIDResolver) RegisterLocalKey(did string, publicKey interface{}) {
 	r.localKeys[did] = publicKey
 }
 
-// resolveWebDID resolves a did:web DID
-func (r *DIDResolver) resolveWebDID(did string) (interface{}, error) {
-	// Convert did:web:example.com to https://example.com/.well-known/did.json
-	didParts := strings.Split(did, ":")
-	if len(didParts) < 3 {
-		return nil, fmt.Errorf("invalid did:web format")
+// resolveWebDID fetches the DID document of a did:web. The document must be
+// served over HTTPS and name the requested DID as its id; 410 Gone means the
+// DID was deactivated. The Cache-Control header sets how long it is cached.
+func (r *DIDResolver) resolveWebDID(did string) (*DIDResolutionResult, error) {
+	documentURL, err := webDIDDocumentURL(did)
+	if err != nil {
+		return nil, err
 	}
 
-	domain := strings.Join(didParts[2:], ":")
-	url := fmt.Sprintf("https://%s/.well-known/did.json", domain)
-
 	// Fetch DID document
-	resp, err := r.httpClient.Get(url)
+	req, err := http.NewRequest(http.MethodGet, documentURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid did:web %s: %w", did, err)
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+
+	resp, err := r.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch DID document: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusGone {
+		return &DIDResolutionResult{
+			DIDDocumentMetadata: DIDDocumentMetadata{Deactivated: true},
+			cachePolicy:         parseCacheControl(resp.Header),
+		}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to fetch DID document: status %d", resp.StatusCode)
 	}
 
 	var didDoc DIDDocument
-	if err := json.NewDecoder(resp.Body).Decode(&didDoc); err != nil {
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDIDDocumentSize)).Decode(&didDoc); err != nil {
 		return nil, fmt.Errorf("failed to parse DID document: %w", err)
 	}
+	if didDoc.ID != did {
+		return nil, fmt.Errorf("DID document id %q does not match %s", didDoc.ID, did)
+	}
+
+	result := documentResult(&didDoc)
+	result.cachePolicy = parseCacheControl(resp.Header)
+	return result, nil
+}
+
+// webDIDDocumentURL maps a did:web to the URL of its DID document
+// (https://w3c-ccg.github.io/did-method-web/#read-resolve):
+//
+//	did:web:example.com                -> https://example.com/.well-known/did.json
+//	did:web:example.com:issuers:moda   -> https://example.com/issuers/moda/did.json
+//	did:web:localhost%3A8443           -> https://localhost:8443/.well-known/did.json
+func webDIDDocumentURL(did string) (string, error) {
+	id, ok := strings.CutPrefix(did, "did:web:")
+	if !ok || id == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDID, did)
+	}
+
+	segments := strings.Split(id, ":")
+
+	// The domain may only carry a percent-encoded port
+	host, err := url.PathUnescape(segments[0])
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidDID, did, err)
+	}
+	parsed, err := url.Parse("https://" + host)
+	if err != nil || parsed.Host != host || parsed.Hostname() == "" || parsed.User != nil {
+		return "", fmt.Errorf("%w: %s: invalid domain %q", ErrInvalidDID, did, host)
+	}
+
+	path := "/.well-known"
+	if len(segments) > 1 {
+		path = ""
+		for _, segment := range segments[1:] {
+			decoded, err := url.PathUnescape(segment)
+			if err != nil || decoded == "" || decoded == "." || decoded == ".." || strings.Contains(decoded, "/") {
+				return "", fmt.Errorf("%w: %s: invalid path segment %q", ErrInvalidDID, did, segment)
+			}
+			path += "/" + url.PathEscape(decoded)
+		}
+	}
 
-	return r.extractPublicKey(&didDoc)
+	return "https://" + host + path + "/did.json", nil
 }
 
-// resolveKeyDID resolves a did:key DID
-func (r *DIDResolver) resolveKeyDID(did string) (interface{}, error) {
-	// did:key uses multibase encoding
-	// For now, return error as full implementation requires multicodec
-	return nil, fmt.Errorf("did:key resolution not yet implemented")
+// resolveJWKDID synthesizes the DID document of a did:jwk, which must hold a signing key
+func (r *DIDResolver) resolveJWKDID(did string) (*DIDDocument, error) {
+	didDoc, err := JWKDIDDocument(did)
+	if err != nil {
+		return nil, err
+	}
+	if len(didDoc.AssertionMethod) == 0 {
+		return nil, fmt.Errorf("invalid did:jwk %s: key is not a signing key", did)
+	}
+
+	return didDoc, nil
 }
 
 // resolveExampleDID resolves a did:example DID (for testing)
@@ -194,15 +344,108 @@ func (r *DIDResolver) resolveExampleDID(did string) (interface{}, error) {
 	}, nil
 }
 
-// extractPublicKey extracts the public key from a DID document
+// extractPublicKey extracts the public key of the first verification method of a DID document
 func (r *DIDResolver) extractPublicKey(didDoc *DIDDocument) (interface{}, error) {
-	if len(didDoc.VerificationMethod) == 0 {
+	return r.selectPublicKey(didDoc, "", "")
+}
+
+// selectPublicKey extracts the public key of the verification method vmID, or
+// of the first method if vmID is empty, among those authorized for
+// relationship (all methods if relationship is empty)
+func (r *DIDResolver) selectPublicKey(didDoc *DIDDocument, vmID, relationship string) (interface{}, error) {
+	methods, err := didDoc.verificationMethods(relationship)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		if relationship != "" {
+			return nil, fmt.Errorf("%w: %s has no %s verification method", ErrNoMatchedPublicKey, didDoc.ID, relationship)
+		}
 		return nil, fmt.Errorf("no verification methods found")
 	}
 
-	// Use the first verification method
-	vm := didDoc.VerificationMethod[0]
+	if vmID == "" {
+		return r.publicKey(&methods[0])
+	}
+	for i := range methods {
+		if methods[i].ID == vmID {
+			return r.publicKey(&methods[i])
+		}
+	}
+
+	// A kid may also be the RFC 7638 thumbprint of a method's JWK
+	_, fragment, _ := strings.Cut(vmID, "#")
+	for i := range methods {
+		if methods[i].PublicKeyJwk == nil {
+			continue
+		}
+		if thumbprint, err := JWKThumbprint(methods[i].PublicKeyJwk); err == nil && thumbprint == fragment {
+			return r.publicKey(&methods[i])
+		}
+	}
+	if relationship != "" {
+		return nil, fmt.Errorf("%w: %s is not a %s verification method", ErrNoMatchedPublicKey, vmID, relationship)
+	}
+	return nil, fmt.Errorf("%w: verification method %s not found", ErrNoMatchedPublicKey, vmID)
+}
+
+// verificationMethods returns the verification methods authorized for
+// relationship (all of them if relationship is empty), with absolute IDs.
+// Relationships may reference a method by ID or embed it.
+func (d *DIDDocument) verificationMethods(relationship string) ([]VerificationMethod, error) {
+	var methods []VerificationMethod
+	for _, vm := range d.VerificationMethod {
+		vm.ID = d.absoluteID(vm.ID)
+		methods = append(methods, vm)
+	}
+
+	var refs []interface{}
+	switch relationship {
+	case "":
+		return methods, nil
+	case AssertionMethod:
+		refs = d.AssertionMethod
+	case Authentication:
+		refs = d.Authentication
+	default:
+		return nil, fmt.Errorf("unsupported verification relationship: %s", relationship)
+	}
+
+	var authorized []VerificationMethod
+	for _, ref := range refs {
+		switch ref := ref.(type) {
+		case string:
+			for _, vm := range methods {
+				if vm.ID == d.absoluteID(ref) {
+					authorized = append(authorized, vm)
+				}
+			}
+		case map[string]interface{}:
+			data, err := json.Marshal(ref)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s verification method: %w", relationship, err)
+			}
+			var vm VerificationMethod
+			if err := json.Unmarshal(data, &vm); err != nil {
+				return nil, fmt.Errorf("invalid %s verification method: %w", relationship, err)
+			}
+			vm.ID = d.absoluteID(vm.ID)
+			authorized = append(authorized, vm)
+		}
+	}
+	return authorized, nil
+}
+
+// absoluteID resolves a relative DID URL (#key-1) against the document's DID
+func (d *DIDDocument) absoluteID(id string) string {
+	if strings.HasPrefix(id, "#") {
+		return d.ID + id
+	}
+	return id
+}
 
+// publicKey extracts the public key of a verification method
+func (r *DIDResolver) publicKey(vm *VerificationMethod) (interface{}, error) {
 	// Try JWK format first
 	if vm.PublicKeyJwk != nil {
 		return r.jwkToPublicKey(vm.PublicKeyJwk)
@@ -210,12 +453,12 @@ func (r *DIDResolver) extractPublicKey(didDoc *DIDDocument) (interface{}, error)
 
 	// Try multibase format
 	if vm.PublicKeyMultibase != "" {
-		return r.multibaseToPublicKey(vm.PublicKeyMultibase)
+		return r.multibaseToPublicKey(vm.Type, vm.PublicKeyMultibase)
 	}
 
 	// Try base58 format
 	if vm.PublicKeyBase58 != "" {
-		return r.base58ToPublicKey(vm.PublicKeyBase58)
+		return r.base58ToPublicKey(vm.Type, vm.PublicKeyBase58)
 	}
 
 	return nil, fmt.Errorf("no supported public key format found")
@@ -223,61 +466,99 @@ func (r *DIDResolver) extractPublicKey(didDoc *DIDDocument) (interface{}, error)
 
 // jwkToPublicKey converts a JWK to a public key
 func (r *DIDResolver) jwkToPublicKey(jwk *JWK) (interface{}, error) {
-	if jwk.Kty != "EC" {
-		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	return publicKeyFromJWK(jwk)
+}
+
+// Verification method types with publicKeyMultibase or publicKeyBase58 keys
+const (
+	vmTypeMultikey                          = "Multikey"
+	vmTypeEd25519VerificationKey2018        = "Ed25519VerificationKey2018"
+	vmTypeEd25519VerificationKey2020        = "Ed25519VerificationKey2020"
+	vmTypeEcdsaSecp256k1VerificationKey2019 = "EcdsaSecp256k1VerificationKey2019"
+)
+
+// multibaseToPublicKey converts a publicKeyMultibase value to a public key.
+// Multikey and Ed25519VerificationKey2020 values are multicodec prefixed
+// (a compressed point for EC keys); an Ed25519VerificationKey2018 value may
+// also be the raw key.
+func (r *DIDResolver) multibaseToPublicKey(vmType, multibase string) (interface{}, error) {
+	switch vmType {
+	case vmTypeEd25519VerificationKey2018:
+		data, err := decodeMultibase(multibase)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == ed25519.PublicKeySize {
+			return ed25519.PublicKey(data), nil
+		}
+		return requireEd25519(decodeMultikey(multibase))
+	case vmTypeEd25519VerificationKey2020:
+		return requireEd25519(decodeMultikey(multibase))
+	case vmTypeEcdsaSecp256k1VerificationKey2019:
+		key, err := decodeMultikey(multibase)
+		if ecKey, ok := key.(*ecdsa.PublicKey); err == nil && (!ok || ecKey.Curve != Secp256k1()) {
+			return nil, fmt.Errorf("%s requires a secp256k1 key", vmType)
+		}
+		return key, err
+	default:
+		// Multikey, and other suites keeping the key type in the multicodec prefix
+		return decodeMultikey(multibase)
 	}
+}
 
-	// Decode X and Y coordinates
-	xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
+// base58ToPublicKey converts a publicKeyBase58 value, which carries no
+// multicodec prefix, to a public key of the verification method's type:
+// a raw Ed25519 key, or a compressed or uncompressed secp256k1 point
+func (r *DIDResolver) base58ToPublicKey(vmType, base58 string) (interface{}, error) {
+	data, err := decodeBase58(base58)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode X coordinate: %w", err)
+		return nil, err
 	}
 
-	yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode Y coordinate: %w", err)
-	}
-
-	// Determine curve
-	var curve elliptic.Curve
-	switch jwk.Crv {
-	case "P-256":
-		curve = elliptic.P256()
-	case "P-384":
-		curve = elliptic.P384()
-	case "P-521":
-		curve = elliptic.P521()
+	switch vmType {
+	case vmTypeEd25519VerificationKey2018, vmTypeEd25519VerificationKey2020:
+		if len(data) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(data))
+		}
+		return ed25519.PublicKey(data), nil
+	case vmTypeEcdsaSecp256k1VerificationKey2019:
+		return parseSecp256k1Point(data)
 	default:
-		return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		return nil, fmt.Errorf("unsupported verification method type for publicKeyBase58: %s", vmType)
 	}
+}
 
-	// Create public key
-	pubKey := &ecdsa.PublicKey{
-		Curve: curve,
-		X:     new(big.Int).SetBytes(xBytes),
-		Y:     new(big.Int).SetBytes(yBytes),
+// requireEd25519 passes on a decoded Ed25519 key and rejects other keys
+func requireEd25519(key interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
 	}
-
-	return pubKey, nil
+	if _, ok := key.(ed25519.PublicKey); !ok {
+		return nil, fmt.Errorf("expected an Ed25519 key, got %T", key)
+	}
+	return key, nil
 }
 
-// multibaseToPublicKey converts multibase-encoded key to public key
-func (r *DIDResolver) multibaseToPublicKey(multibase string) (interface{}, error) {
-	// Full implementation requires multicodec library
-	return nil, fmt.Errorf("multibase decoding not yet implemented")
+// SetCacheConfig replaces the DID resolution cache with an empty one
+// configured by config
+func (r *DIDResolver) SetCacheConfig(config DIDCacheConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = newDIDCache(config)
 }
 
-// base58ToPublicKey converts base58-encoded key to public key
-func (r *DIDResolver) base58ToPublicKey(base58 string) (interface{}, error) {
-	// Full implementation requires base58 library
-	return nil, fmt.Errorf("base58 decoding not yet implemented")
+// CacheStats returns the hit, miss and error counters of the DID resolution cache
+func (r *DIDResolver) CacheStats() DIDCacheStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cache.snapshot()
 }
 
 // ClearCache clears the DID resolution cache
 func (r *DIDResolver) ClearCache() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.cache = make(map[string]cachedKey)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.cache.clear()
 }
 
 // parseDERPublicKey parses a DER-encoded public key
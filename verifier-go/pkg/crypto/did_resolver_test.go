@@ -2,13 +2,20 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func TestDIDResolver_RegisterAndResolveLocalKey(t *testing.T) {
@@ -41,42 +48,43 @@ func TestDIDResolver_RegisterAndResolveLocalKey(t *testing.T) {
 }
 
 func TestDIDResolver_CacheExpiration(t *testing.T) {
-	resolver := NewDIDResolver()
-
 	// Generate test key
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		t.Fatalf("Failed to generate key: %v", err)
 	}
 
-	did := "did:example:test123"
-	resolver.RegisterLocalKey(did, &privateKey.PublicKey)
+	var did string
+	var fetches atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(testECDIDDocument(did, &privateKey.PublicKey))
+	}))
+	defer server.Close()
+	did = "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1)
+	resolver := NewDIDResolverWithHTTPClient(server.Client())
 
 	// First resolution - should cache
-	_, err = resolver.ResolveKey(did)
-	if err != nil {
-		t.Fatalf("Failed to resolve key: %v", err)
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.ResolveKey(did); err != nil {
+			t.Fatalf("Failed to resolve key: %v", err)
+		}
 	}
 
-	// Check cache has the key
-	resolver.mu.RLock()
-	_, inCache := resolver.cache[did]
-	resolver.mu.RUnlock()
-
-	if !inCache {
-		t.Error("Key should be in cache after first resolution")
+	// Check cache has the document
+	if fetches.Load() != 1 || resolver.CacheStats().Entries != 1 {
+		t.Errorf("Expected one fetch and one cached DID, got %d fetches and %+v", fetches.Load(), resolver.CacheStats())
 	}
 
 	// Clear cache
 	resolver.ClearCache()
 
 	// Check cache is empty
-	resolver.mu.RLock()
-	_, stillInCache := resolver.cache[did]
-	resolver.mu.RUnlock()
-
-	if stillInCache {
-		t.Error("Key should not be in cache after clearing")
+	if resolver.CacheStats().Entries != 0 {
+		t.Error("Document should not be in cache after clearing")
+	}
+	if _, err := resolver.ResolveKey(did); err != nil || fetches.Load() != 2 {
+		t.Errorf("Expected the document to be fetched again, got %d fetches (%v)", fetches.Load(), err)
 	}
 }
 
@@ -104,37 +112,34 @@ func TestDIDResolver_WebDID(t *testing.T) {
 		Y:   base64.RawURLEncoding.EncodeToString(yPadded),
 	}
 
-	// Create mock DID document
-	didDoc := &DIDDocument{
-		Context: []string{"https://www.w3.org/ns/did/v1"},
-		ID:      "did:web:example.com",
-		VerificationMethod: []VerificationMethod{
-			{
-				ID:           "did:web:example.com#key-1",
-				Type:         "JsonWebKey2020",
-				Controller:   "did:web:example.com",
-				PublicKeyJwk: jwk,
-			},
-		},
-	}
-
-	// Create mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/.well-known/did.json" {
+	// Create mock server serving the DID document of did:web:127.0.0.1%3A<port>:issuers:moda
+	var did string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/issuers/moda/did.json" {
 			http.NotFound(w, r)
 			return
 		}
-		json.NewEncoder(w).Encode(didDoc)
+		json.NewEncoder(w).Encode(&DIDDocument{
+			Context: []string{"https://www.w3.org/ns/did/v1"},
+			ID:      did,
+			VerificationMethod: []VerificationMethod{
+				{
+					ID:           did + "#key-1",
+					Type:         "JsonWebKey2020",
+					Controller:   did,
+					PublicKeyJwk: jwk,
+				},
+			},
+		})
 	}))
 	defer server.Close()
+	did = "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1) + ":issuers:moda"
 
-	// Override the resolver's HTTP client to use test server
-	resolver := NewDIDResolver()
-
-	// Since we can't easily override the URL construction, we'll test the JWK conversion directly
-	resolvedKey, err := resolver.jwkToPublicKey(jwk)
+	// Resolve through the test server's client
+	resolver := NewDIDResolverWithHTTPClient(server.Client())
+	resolvedKey, err := resolver.ResolveKey(did)
 	if err != nil {
-		t.Fatalf("Failed to convert JWK to public key: %v", err)
+		t.Fatalf("Failed to resolve did:web: %v", err)
 	}
 
 	ecKey, ok := resolvedKey.(*ecdsa.PublicKey)
@@ -147,6 +152,65 @@ func TestDIDResolver_WebDID(t *testing.T) {
 	}
 }
 
+func TestDIDResolver_WebDIDMismatchedID(t *testing.T) {
+	// Given - a server answering with another DID's document
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&DIDDocument{
+			ID: "did:web:attacker.example",
+			VerificationMethod: []VerificationMethod{{
+				ID:           "did:web:attacker.example#key-1",
+				Type:         "JsonWebKey2020",
+				PublicKeyJwk: &JWK{Kty: "EC", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))), Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))},
+			}},
+		})
+	}))
+	defer server.Close()
+	did := "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1)
+
+	// When
+	_, err := NewDIDResolverWithHTTPClient(server.Client()).ResolveKey(did)
+
+	// Then
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected an id mismatch, got %v", err)
+	}
+}
+
+func TestWebDIDDocumentURL(t *testing.T) {
+	tests := []struct {
+		did  string
+		want string
+	}{
+		{"did:web:w3c-ccg.github.io", "https://w3c-ccg.github.io/.well-known/did.json"},
+		{"did:web:w3c-ccg.github.io:user:alice", "https://w3c-ccg.github.io/user/alice/did.json"},
+		{"did:web:example.com%3A3000:user:alice", "https://example.com:3000/user/alice/did.json"},
+		{"did:web:example.com:issuers:moda", "https://example.com/issuers/moda/did.json"},
+		{"did:web:localhost%3A8443", "https://localhost:8443/.well-known/did.json"},
+	}
+
+	for _, tt := range tests {
+		got, err := webDIDDocumentURL(tt.did)
+		if err != nil || got != tt.want {
+			t.Errorf("Expected %s for %s, got %s (%v)", tt.want, tt.did, got, err)
+		}
+	}
+
+	for _, did := range []string{
+		"did:web:",
+		"did:web:example.com%2Fevil",
+		"did:web:user%40example.com",
+		"did:web:example.com%3Aport",
+		"did:web:example.com::alice",
+		"did:web:example.com:..:alice",
+		"did:web:example.com:a%2Fb",
+	} {
+		if got, err := webDIDDocumentURL(did); err == nil {
+			t.Errorf("Expected an error for %s, got %s", did, got)
+		}
+	}
+}
+
 func TestJWKToPublicKey_P256(t *testing.T) {
 	resolver := NewDIDResolver()
 
@@ -243,7 +307,7 @@ func TestJWKToPublicKey_UnsupportedKeyType(t *testing.T) {
 	resolver := NewDIDResolver()
 
 	jwk := &JWK{
-		Kty: "RSA", // Unsupported in current implementation
+		Kty: "oct", // Symmetric keys cannot be published
 		Crv: "P-256",
 		X:   "test",
 		Y:   "test",
@@ -260,7 +324,7 @@ func TestJWKToPublicKey_UnsupportedCurve(t *testing.T) {
 
 	jwk := &JWK{
 		Kty: "EC",
-		Crv: "secp256k1", // Unsupported curve
+		Crv: "brainpoolP256r1", // Unsupported curve
 		X:   "test",
 		Y:   "test",
 	}
@@ -315,3 +379,169 @@ func TestResolveExampleDID(t *testing.T) {
 		t.Error("Same DID should resolve to same key")
 	}
 }
+
+// newRotatingIssuer serves a did:web document with key-1 for assertions,
+// key-2 for authentication only, and an embedded rotated assertion key-3
+func newRotatingIssuer(t *testing.T) (did string, keys map[string]*ecdsa.PrivateKey, resolver *DIDResolver) {
+	t.Helper()
+
+	keys = map[string]*ecdsa.PrivateKey{}
+	jwks := map[string]*JWK{}
+	for _, name := range []string{"key-1", "key-2", "key-3"} {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		keys[name] = key
+		jwks[name] = &JWK{Kty: "EC", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))), Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))}
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&DIDDocument{
+			ID: did,
+			VerificationMethod: []VerificationMethod{
+				{ID: "#key-1", Type: "JsonWebKey2020", Controller: did, PublicKeyJwk: jwks["key-1"]},
+				{ID: did + "#key-2", Type: "JsonWebKey2020", Controller: did, PublicKeyJwk: jwks["key-2"]},
+			},
+			AssertionMethod: []interface{}{
+				"#key-1",
+				map[string]interface{}{"id": did + "#key-3", "type": "JsonWebKey2020", "controller": did, "publicKeyJwk": jwks["key-3"]},
+			},
+			Authentication: []interface{}{did + "#key-2"},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	did = "did:web:" + strings.Replace(strings.TrimPrefix(server.URL, "https://"), ":", "%3A", 1)
+	return did, keys, NewDIDResolverWithHTTPClient(server.Client())
+}
+
+func TestResolveVerificationMethod(t *testing.T) {
+	did, keys, resolver := newRotatingIssuer(t)
+	key1Thumbprint, err := JWKThumbprint(&JWK{Kty: "EC", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(keys["key-1"].X.FillBytes(make([]byte, 32))), Y: base64.RawURLEncoding.EncodeToString(keys["key-1"].Y.FillBytes(make([]byte, 32)))})
+	if err != nil {
+		t.Fatalf("JWKThumbprint failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		kid          string
+		relationship string
+		want         string // expected key; "" if no key matches
+	}{
+		{"DID URL", did + "#key-1", AssertionMethod, "key-1"},
+		{"relative reference", "#key-1", AssertionMethod, "key-1"},
+		{"bare fragment", "key-2", Authentication, "key-2"},
+		{"embedded method", did + "#key-3", AssertionMethod, "key-3"},
+		{"JWK thumbprint", did + "#" + key1Thumbprint, AssertionMethod, "key-1"},
+		{"thumbprint of an authentication key", key1Thumbprint, Authentication, ""},
+		{"no kid", "", AssertionMethod, "key-1"},
+		{"any relationship", "key-2", "", "key-2"},
+		{"authentication key for assertions", did + "#key-2", AssertionMethod, ""},
+		{"assertion key for authentication", did + "#key-1", Authentication, ""},
+		{"unknown key", did + "#key-4", AssertionMethod, ""},
+		{"another DID", "did:web:attacker.example#key-1", AssertionMethod, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			key, err := resolver.ResolveVerificationMethod(did, tt.kid, tt.relationship)
+
+			// Then
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoMatchedPublicKey) {
+					t.Errorf("Expected ErrNoMatchedPublicKey, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVerificationMethod failed: %v", err)
+			}
+			if ecKey, ok := key.(*ecdsa.PublicKey); !ok || !ecKey.Equal(&keys[tt.want].PublicKey) {
+				t.Errorf("Expected %s", tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVC_SelectsKeyByKid(t *testing.T) {
+	// Given - an issuer that rotated to an embedded assertion key
+	did, keys, resolver := newRotatingIssuer(t)
+	validator := NewJWTValidator(resolver)
+	claims := &VCClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: did, Subject: "did:example:holder", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		VC:               CredentialSubject{Type: []string{"VerifiableCredential"}},
+	}
+
+	// When - signed with the rotated key and with the authentication-only key
+	rotated, _ := SignVC(claims, keys["key-3"], did+"#key-3")
+	_, rotatedErr := validator.ValidateVC(rotated)
+	authOnly, _ := SignVC(claims, keys["key-2"], did+"#key-2")
+	_, authOnlyErr := validator.ValidateVC(authOnly)
+
+	// Then
+	if rotatedErr != nil {
+		t.Errorf("Expected the rotated key to validate, got %v", rotatedErr)
+	}
+	if !errors.Is(authOnlyErr, ErrNoMatchedPublicKey) {
+		t.Errorf("Expected ErrNoMatchedPublicKey for an authentication key, got %v", authOnlyErr)
+	}
+}
+
+func TestExtractPublicKey_VerificationMethodTypes(t *testing.T) {
+	ed25519Public, _, _ := ed25519.GenerateKey(rand.Reader)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secp256k1Key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+
+	ed25519Multikey := strings.TrimPrefix(encodeTestKeyDID(t, ed25519Public), "did:key:")
+	p256Multikey := strings.TrimPrefix(encodeTestKeyDID(t, &p256Key.PublicKey), "did:key:")
+	secp256k1Multikey := strings.TrimPrefix(encodeTestKeyDID(t, &secp256k1Key.PublicKey), "did:key:")
+	secp256k1Uncompressed := append([]byte{4}, append(secp256k1Key.X.FillBytes(make([]byte, 32)), secp256k1Key.Y.FillBytes(make([]byte, 32))...)...)
+	secp256k1Compressed := append([]byte{byte(2 + secp256k1Key.Y.Bit(0))}, secp256k1Key.X.FillBytes(make([]byte, 32))...)
+	// (1, 1) is off the curve: 1^2 != 1^3 + 7
+	secp256k1OffCurve := make([]byte, 65)
+	secp256k1OffCurve[0], secp256k1OffCurve[32], secp256k1OffCurve[64] = 4, 1, 1
+
+	tests := []struct {
+		name string
+		vm   VerificationMethod
+		want interface{} // nil if the method is invalid
+	}{
+		{"Multikey Ed25519", VerificationMethod{Type: "Multikey", PublicKeyMultibase: ed25519Multikey}, ed25519Public},
+		{"Multikey P-256", VerificationMethod{Type: "Multikey", PublicKeyMultibase: p256Multikey}, &p256Key.PublicKey},
+		{"Ed25519VerificationKey2020", VerificationMethod{Type: "Ed25519VerificationKey2020", PublicKeyMultibase: ed25519Multikey}, ed25519Public},
+		{"Ed25519VerificationKey2018 base58", VerificationMethod{Type: "Ed25519VerificationKey2018", PublicKeyBase58: encodeTestBase58(ed25519Public)}, ed25519Public},
+		{"Ed25519VerificationKey2018 raw multibase", VerificationMethod{Type: "Ed25519VerificationKey2018", PublicKeyMultibase: "z" + encodeTestBase58(ed25519Public)}, ed25519Public},
+		{"EcdsaSecp256k1VerificationKey2019 compressed", VerificationMethod{Type: "EcdsaSecp256k1VerificationKey2019", PublicKeyBase58: encodeTestBase58(secp256k1Compressed)}, &secp256k1Key.PublicKey},
+		{"EcdsaSecp256k1VerificationKey2019 uncompressed", VerificationMethod{Type: "EcdsaSecp256k1VerificationKey2019", PublicKeyBase58: encodeTestBase58(secp256k1Uncompressed)}, &secp256k1Key.PublicKey},
+		{"EcdsaSecp256k1VerificationKey2019 multibase", VerificationMethod{Type: "EcdsaSecp256k1VerificationKey2019", PublicKeyMultibase: secp256k1Multikey}, &secp256k1Key.PublicKey},
+		{"Ed25519VerificationKey2020 with a P-256 key", VerificationMethod{Type: "Ed25519VerificationKey2020", PublicKeyMultibase: p256Multikey}, nil},
+		{"EcdsaSecp256k1VerificationKey2019 with an Ed25519 key", VerificationMethod{Type: "EcdsaSecp256k1VerificationKey2019", PublicKeyMultibase: ed25519Multikey}, nil},
+		{"Multikey as base58", VerificationMethod{Type: "Multikey", PublicKeyBase58: encodeTestBase58(ed25519Public)}, nil},
+		{"short Ed25519 base58", VerificationMethod{Type: "Ed25519VerificationKey2018", PublicKeyBase58: encodeTestBase58(ed25519Public[:31])}, nil},
+		{"secp256k1 off curve", VerificationMethod{Type: "EcdsaSecp256k1VerificationKey2019", PublicKeyBase58: encodeTestBase58(secp256k1OffCurve)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			tt.vm.ID = "did:example:test#key-1"
+			didDoc := &DIDDocument{ID: "did:example:test", VerificationMethod: []VerificationMethod{tt.vm}}
+
+			// When
+			key, err := NewDIDResolver().extractPublicKey(didDoc)
+
+			// Then
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("Expected an error, got %T", key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractPublicKey failed: %v", err)
+			}
+			if equal, ok := key.(publicKeyEqualer); !ok || !equal.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signature verification
+const minRSAKeyBits = 2048
+
+// ParseJWK converts a JSON Web Key object, ex: the holder key in an SD-JWT's
+// cnf claim, to a public key
+func ParseJWK(jwk map[string]interface{}) (interface{}, error) {
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK: %w", err)
+	}
+
+	var key JWK
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("invalid JWK: %w", err)
+	}
+
+	return publicKeyFromJWK(&key)
+}
+
+// publicKeyFromJWK converts a public JWK to a signature verification key:
+// *ecdsa.PublicKey (EC: P-256, P-384, P-521, secp256k1), ed25519.PublicKey
+// (OKP) or *rsa.PublicKey (RSA)
+func publicKeyFromJWK(jwk *JWK) (interface{}, error) {
+	if jwk.D != "" {
+		return nil, fmt.Errorf("invalid JWK: contains private key material")
+	}
+
+	switch jwk.Kty {
+	case "EC":
+		return ecPublicKeyFromJWK(jwk)
+	case "OKP":
+		return okpPublicKeyFromJWK(jwk)
+	case "RSA":
+		return rsaPublicKeyFromJWK(jwk)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// ecPublicKeyFromJWK converts an EC JWK to an ECDSA public key
+func ecPublicKeyFromJWK(jwk *JWK) (interface{}, error) {
+	// Decode X and Y coordinates
+	xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode X coordinate: %w", err)
+	}
+
+	yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Y coordinate: %w", err)
+	}
+
+	// Determine curve
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	case "secp256k1":
+		if len(xBytes) != 32 || len(yBytes) != 32 {
+			return nil, fmt.Errorf("invalid public key: coordinates of curve %s must be 32 bytes", jwk.Crv)
+		}
+		return parseSecp256k1Point(append(append([]byte{4}, xBytes...), yBytes...))
+	default:
+		return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+	}
+
+	// Create public key
+	pubKey := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}
+	if !curve.IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, fmt.Errorf("invalid public key: point is not on curve %s", jwk.Crv)
+	}
+
+	return pubKey, nil
+}
+
+// okpPublicKeyFromJWK converts an OKP JWK (RFC 8037) to an Ed25519 public key.
+// X25519 keys are for key agreement and cannot verify signatures.
+func okpPublicKeyFromJWK(jwk *JWK) (interface{}, error) {
+	switch jwk.Crv {
+	case "Ed25519":
+	case "X25519":
+		return nil, fmt.Errorf("X25519 is a key agreement key and cannot verify signatures")
+	default:
+		return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Ed25519 public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(key))
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// rsaPublicKeyFromJWK converts an RSA JWK to an RSA public key of at least minRSAKeyBits
+func rsaPublicKeyFromJWK(jwk *JWK) (interface{}, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(nBytes) == 0 {
+		return nil, fmt.Errorf("failed to decode RSA modulus")
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(eBytes) == 0 || len(eBytes) > 4 {
+		return nil, fmt.Errorf("failed to decode RSA exponent")
+	}
+
+	n := new(big.Int).SetBytes(nBytes)
+	if n.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA modulus must be at least %d bits, got %d", minRSAKeyBits, n.BitLen())
+	}
+	e := new(big.Int).SetBytes(eBytes).Int64()
+	if e < 3 || e%2 == 0 {
+		return nil, fmt.Errorf("invalid RSA exponent: %d", e)
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e)}, nil
+}
+
+// JWKThumbprint computes the RFC 7638 SHA-256 thumbprint of a public JWK: the
+// base64url digest of its required members, in lexicographic order, as
+// compact JSON. Keys may be matched by thumbprint whatever their kid.
+func JWKThumbprint(jwk *JWK) (string, error) {
+	var members interface{}
+	switch jwk.Kty {
+	case "EC":
+		if jwk.Crv == "" || jwk.X == "" || jwk.Y == "" {
+			return "", fmt.Errorf("EC JWK requires crv, x and y")
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	case "OKP":
+		if jwk.Crv == "" || jwk.X == "" {
+			return "", fmt.Errorf("OKP JWK requires crv and x")
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	case "RSA":
+		if jwk.E == "" || jwk.N == "" {
+			return "", fmt.Errorf("RSA JWK requires e and n")
+		}
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWK thumbprint input: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
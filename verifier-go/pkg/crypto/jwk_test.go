@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+)
+
+// publicKeyEqualer is implemented by the standard library's public keys
+type publicKeyEqualer interface {
+	Equal(stdcrypto.PublicKey) bool
+}
+
+func TestParseJWK_KeyTypes(t *testing.T) {
+	ed25519Public, _, _ := ed25519.GenerateKey(rand.Reader)
+	secp256k1Key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name      string
+		publicKey interface{}
+	}{
+		{"OKP Ed25519", ed25519Public},
+		{"EC secp256k1", &secp256k1Key.PublicKey},
+		{"RSA", &rsaKey.PublicKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			key, err := ParseJWK(testPublicJWK(t, tt.publicKey))
+			if err != nil {
+				t.Fatalf("ParseJWK failed: %v", err)
+			}
+
+			// Then
+			if !key.(interface {
+				Equal(stdcrypto.PublicKey) bool
+			}).Equal(tt.publicKey) {
+				t.Errorf("Expected %v, got %v", tt.publicKey, key)
+			}
+		})
+	}
+}
+
+func TestParseJWK_Invalid(t *testing.T) {
+	secp256k1Key, _ := ecdsa.GenerateKey(Secp256k1(), rand.Reader)
+	offCurve := testPublicJWK(t, &secp256k1Key.PublicKey)
+	offCurve["y"] = offCurve["x"]
+	withPrivate := testPublicJWK(t, &secp256k1Key.PublicKey)
+	withPrivate["d"] = base64.RawURLEncoding.EncodeToString(secp256k1Key.D.Bytes())
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	weakRSA, _ := rsa.GenerateKey(rand.Reader, 1024)
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	for name, jwk := range map[string]map[string]interface{}{
+		"X25519":              {"kty": "OKP", "crv": "X25519", "x": b64(make([]byte, 32))},
+		"short Ed25519":       {"kty": "OKP", "crv": "Ed25519", "x": b64(make([]byte, 31))},
+		"RSA without n":       {"kty": "RSA", "e": "AQAB"},
+		"RSA 1024":            testPublicJWK(t, &weakRSA.PublicKey),
+		"RSA even exponent":   {"kty": "RSA", "n": testPublicJWK(t, &rsaKey.PublicKey)["n"], "e": b64([]byte{2})},
+		"secp256k1 off curve": offCurve,
+		"private key":         withPrivate,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseJWK(jwk); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 §3.1
+	jwk := &JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Kid: "2011-04-29",
+		Use: "sig",
+	}
+
+	thumbprint, err := JWKThumbprint(jwk)
+	if err != nil {
+		t.Fatalf("JWKThumbprint failed: %v", err)
+	}
+	if thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expected the RFC 7638 thumbprint, got %s", thumbprint)
+	}
+
+	// RFC 8037 Appendix A.3
+	okp := &JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	if thumbprint, _ := JWKThumbprint(okp); thumbprint != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("Expected the RFC 8037 thumbprint, got %s", thumbprint)
+	}
+
+	if _, err := JWKThumbprint(&JWK{Kty: "EC", Crv: "P-256", X: "x"}); err == nil {
+		t.Error("Expected an error for an EC JWK without y")
+	}
+}
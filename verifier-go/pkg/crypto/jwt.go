@@ -1,8 +1,10 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
@@ -19,6 +21,10 @@ import (
 type VCClaims struct {
 	jwt.RegisteredClaims
 	VC CredentialSubject `json:"vc"`
+
+	// OpaqueIDSeedEpoch is the epoch of the opaque_id_seed the credential
+	// carries (0 if the issuer does not version seeds)
+	OpaqueIDSeedEpoch int `json:"opaque_id_seed_epoch,omitempty"`
 }
 
 // VPClaims represents the claims in a Verifiable Presentation JWT
@@ -35,7 +41,7 @@ type CredentialSubject struct {
 	Issuer            string                 `json:"issuer,omitempty"`
 	IssuanceDate      string                 `json:"issuanceDate,omitempty"`
 	ExpirationDate    string                 `json:"expirationDate,omitempty"`
-	CredentialStatus  *CredentialStatus      `json:"credentialStatus,omitempty"`
+	CredentialStatus  CredentialStatuses     `json:"credentialStatus,omitempty"`
 }
 
 // PresentationSubject represents the presentation in a VP
@@ -44,13 +50,39 @@ type PresentationSubject struct {
 	Type                 []string `json:"type"`
 	VerifiableCredential []string `json:"verifiableCredential"`
 	Holder               string   `json:"holder,omitempty"`
+	PairwiseSub          string   `json:"pairwise_sub,omitempty"`
 }
 
 // CredentialStatus represents the credential status
 type CredentialStatus struct {
-	ID              string `json:"id"`
-	Type            string `json:"type"`
-	StatusListIndex string `json:"statusListIndex,omitempty"`
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose,omitempty"`
+	StatusListIndex      string `json:"statusListIndex,omitempty"`
+	StatusListCredential string `json:"statusListCredential,omitempty"`
+}
+
+// CredentialStatuses holds the credentialStatus entries of a VC.
+// The VC data model allows either a single object or an array of objects.
+type CredentialStatuses []CredentialStatus
+
+// UnmarshalJSON accepts a single credentialStatus object or an array of them
+func (c *CredentialStatuses) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var status CredentialStatus
+		if err := json.Unmarshal(trimmed, &status); err != nil {
+			return err
+		}
+		*c = CredentialStatuses{status}
+		return nil
+	}
+
+	var statuses []CredentialStatus
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return err
+	}
+	*c = statuses
+	return nil
 }
 
 // JWTValidator handles JWT validation
@@ -61,7 +93,12 @@ type JWTValidator struct {
 
 // KeyResolver interface for resolving public keys
 type KeyResolver interface {
+	// ResolveKey resolves a DID, or the DID URL of a verification method, to its public key
 	ResolveKey(did string) (interface{}, error)
+	// ResolveVerificationMethod resolves the key of the DID's verification
+	// method named by a JWT kid, which must be authorized for relationship
+	// (AssertionMethod or Authentication); a mismatch wraps ErrNoMatchedPublicKey
+	ResolveVerificationMethod(did, kid, relationship string) (interface{}, error)
 }
 
 // NewJWTValidator creates a new JWT validator
@@ -93,22 +130,14 @@ func (v *JWTValidator) ValidateVC(vcJWT string) (*VCClaims, error) {
 		return nil, fmt.Errorf("issuer not found in VC")
 	}
 
-	// Resolve public key
-	publicKey, err := v.KeyResolver.ResolveKey(issuerDID)
+	// Resolve the public key named by kid, authorized to issue credentials
+	publicKey, err := v.KeyResolver.ResolveVerificationMethod(issuerDID, headerKeyID(token), AssertionMethod)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve issuer key: %w", err)
 	}
 
 	// Parse and validate JWT with public key
-	validatedToken, err := jwt.ParseWithClaims(vcJWT, &VCClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		switch token.Method.(type) {
-		case *jwt.SigningMethodECDSA, *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
-			return publicKey, nil
-		default:
-			return nil, fmt.Errorf("unsupported signing method: %v", token.Method.Alg())
-		}
-	})
+	validatedToken, err := jwt.ParseWithClaims(vcJWT, &VCClaims{}, verificationKey(publicKey))
 
 	if err != nil {
 		return nil, fmt.Errorf("JWT validation failed: %w", err)
@@ -162,22 +191,14 @@ func (v *JWTValidator) ValidateVP(vpJWT string, expectedNonce string, expectedAu
 		return nil, fmt.Errorf("holder not found in VP")
 	}
 
-	// Resolve public key
-	publicKey, err := v.KeyResolver.ResolveKey(holderDID)
+	// Resolve the public key named by kid, authorized to authenticate the holder
+	publicKey, err := v.KeyResolver.ResolveVerificationMethod(holderDID, headerKeyID(token), Authentication)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve holder key: %w", err)
 	}
 
 	// Parse and validate JWT with public key
-	validatedToken, err := jwt.ParseWithClaims(vpJWT, &VPClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		switch token.Method.(type) {
-		case *jwt.SigningMethodECDSA, *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
-			return publicKey, nil
-		default:
-			return nil, fmt.Errorf("unsupported signing method: %v", token.Method.Alg())
-		}
-	})
+	validatedToken, err := jwt.ParseWithClaims(vpJWT, &VPClaims{}, verificationKey(publicKey))
 
 	if err != nil {
 		return nil, fmt.Errorf("JWT validation failed: %w", err)
@@ -221,19 +242,75 @@ func (v *JWTValidator) ValidateVP(vpJWT string, expectedNonce string, expectedAu
 	return validatedClaims, nil
 }
 
-// SignVC creates a signed Verifiable Credential JWT
-func SignVC(claims *VCClaims, privateKey interface{}, kid string) (string, error) {
-	// Determine signing method based on key type
-	var method jwt.SigningMethod
-	switch privateKey.(type) {
+// headerKeyID returns the kid header of a JWT ("" if absent)
+func headerKeyID(token *jwt.Token) string {
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+// verificationKey returns a jwt.Keyfunc verifying with publicKey. An ECDSA
+// signature must use the algorithm of the key's curve, so a secp256k1 key
+// cannot verify an ES256 signature or the reverse.
+func verificationKey(publicKey interface{}) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		// Validate signing method
+		switch token.Method.(type) {
+		case *jwt.SigningMethodECDSA:
+			ecKey, ok := publicKey.(*ecdsa.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("signing method %s requires an ECDSA key", token.Method.Alg())
+			}
+			if alg := ecdsaAlgorithm(ecKey.Curve); alg != token.Method.Alg() {
+				return nil, fmt.Errorf("signing method %s does not match the %s key", token.Method.Alg(), ecKey.Curve.Params().Name)
+			}
+			return publicKey, nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			return publicKey, nil
+		default:
+			return nil, fmt.Errorf("unsupported signing method: %v", token.Method.Alg())
+		}
+	}
+}
+
+// ecdsaAlgorithm returns the JWS algorithm of an ECDSA curve (empty if unsupported)
+func ecdsaAlgorithm(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256.Alg()
+	case elliptic.P384():
+		return jwt.SigningMethodES384.Alg()
+	case elliptic.P521():
+		return jwt.SigningMethodES512.Alg()
+	case Secp256k1():
+		return SigningMethodES256K.Alg()
+	default:
+		return ""
+	}
+}
+
+// signingMethod determines the signing method of a private key
+func signingMethod(privateKey interface{}) (jwt.SigningMethod, error) {
+	switch key := privateKey.(type) {
 	case *ecdsa.PrivateKey:
-		method = jwt.SigningMethodES256
+		if method := jwt.GetSigningMethod(ecdsaAlgorithm(key.Curve)); method != nil {
+			return method, nil
+		}
+		return nil, fmt.Errorf("unsupported ECDSA curve: %s", key.Curve.Params().Name)
 	case *rsa.PrivateKey:
-		method = jwt.SigningMethodRS256
+		return jwt.SigningMethodRS256, nil
 	case ed25519.PrivateKey:
-		method = jwt.SigningMethodEdDSA
+		return jwt.SigningMethodEdDSA, nil
 	default:
-		return "", fmt.Errorf("unsupported private key type")
+		return nil, fmt.Errorf("unsupported private key type")
+	}
+}
+
+// SignVC creates a signed Verifiable Credential JWT
+func SignVC(claims *VCClaims, privateKey interface{}, kid string) (string, error) {
+	// Determine signing method based on key type
+	method, err := signingMethod(privateKey)
+	if err != nil {
+		return "", err
 	}
 
 	// Create token
@@ -256,16 +333,9 @@ func SignVC(claims *VCClaims, privateKey interface{}, kid string) (string, error
 // SignVP creates a signed Verifiable Presentation JWT
 func SignVP(claims *VPClaims, privateKey interface{}, kid string) (string, error) {
 	// Determine signing method based on key type
-	var method jwt.SigningMethod
-	switch privateKey.(type) {
-	case *ecdsa.PrivateKey:
-		method = jwt.SigningMethodES256
-	case *rsa.PrivateKey:
-		method = jwt.SigningMethodRS256
-	case ed25519.PrivateKey:
-		method = jwt.SigningMethodEdDSA
-	default:
-		return "", fmt.Errorf("unsupported private key type")
+	method, err := signingMethod(privateKey)
+	if err != nil {
+		return "", err
 	}
 
 	// Create token
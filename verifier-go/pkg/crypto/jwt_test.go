@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -386,3 +387,32 @@ func TestExtractDIDFromJWT(t *testing.T) {
 		t.Errorf("Subject DID mismatch: got %s, want %s", subjectDID, "did:example:holder456")
 	}
 }
+
+func TestCredentialStatuses_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		indexes []string
+	}{
+		{"Single object", `{"id":"https://issuer/status/r0#3","type":"StatusList2021Entry","statusListIndex":"3"}`, []string{"3"}},
+		{"Array", `[{"id":"a","type":"BitstringStatusListEntry","statusPurpose":"revocation","statusListIndex":"1"},{"id":"b","type":"BitstringStatusListEntry","statusPurpose":"suspension","statusListIndex":"1"}]`, []string{"1", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vc CredentialSubject
+			if err := json.Unmarshal([]byte(`{"credentialStatus":`+tt.data+`}`), &vc); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+
+			if len(vc.CredentialStatus) != len(tt.indexes) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.indexes), len(vc.CredentialStatus))
+			}
+			for i, index := range tt.indexes {
+				if vc.CredentialStatus[i].StatusListIndex != index {
+					t.Errorf("Entry %d: expected index %s, got %s", i, index, vc.CredentialStatus[i].StatusListIndex)
+				}
+			}
+		})
+	}
+}
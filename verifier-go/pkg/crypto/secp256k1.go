@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningMethodES256K is ECDSA over secp256k1 with SHA-256 (RFC 8812),
+// used by wallets with secp256k1 did:key and JWK holder keys
+var SigningMethodES256K = &jwt.SigningMethodECDSA{Name: "ES256K", Hash: stdcrypto.SHA256, KeySize: 32, CurveBits: 256}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodES256K.Alg(), func() jwt.SigningMethod {
+		return SigningMethodES256K
+	})
+}
+
+// Secp256k1 returns the secp256k1 curve (SEC 2). The standard library has no
+// implementation; this is the one of github.com/decred/dcrd/dcrec/secp256k1.
+func Secp256k1() elliptic.Curve {
+	return secp256k1.S256()
+}
+
+// parseSecp256k1Point decodes a SEC 1 compressed (0x02 or 0x03 || x) or
+// uncompressed (0x04 || x || y) secp256k1 point
+func parseSecp256k1Point(data []byte) (*ecdsa.PublicKey, error) {
+	if len(data) > 0 && (data[0] == secp256k1.PubKeyFormatHybridEven || data[0] == secp256k1.PubKeyFormatHybridOdd) {
+		return nil, fmt.Errorf("invalid secp256k1 point: unsupported hybrid format")
+	}
+
+	key, err := secp256k1.ParsePubKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 point: %w", err)
+	}
+
+	return key.ToECDSA(), nil
+}
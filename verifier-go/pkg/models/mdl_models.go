@@ -12,6 +12,7 @@ const (
 	FormatUnknown CredentialFormat = iota
 	FormatW3CJWT                    // W3C JWT-VC
 	FormatISOMDL                    // ISO 18013-5 mDL CBOR
+	FormatSDJWT                     // SD-JWT VC with Key Binding JWT
 )
 
 // String returns the string representation of the credential format
@@ -21,6 +22,8 @@ func (f CredentialFormat) String() string {
 		return "w3c_jwt"
 	case FormatISOMDL:
 		return "iso_mdl"
+	case FormatSDJWT:
+		return "sd_jwt"
 	default:
 		return "unknown"
 	}
@@ -17,8 +17,11 @@ type PresentationValidationResponse struct {
 	HolderDID          string                   `json:"holder_did,omitempty"`
 	VerifiableCredentials []VerifiableCredentialData `json:"vcs,omitempty"`
 
+	// PairwiseSub is the holder's pairwise pseudonymous identifier for this verifier
+	PairwiseSub string `json:"pairwise_sub,omitempty"`
+
 	// NEW: Format indicator for multi-format support
-	Format       string            `json:"format,omitempty"` // "w3c_jwt" or "iso_mdl"
+	Format       string            `json:"format,omitempty"` // "w3c_jwt", "sd_jwt" or "iso_mdl"
 	MDLDocuments []MDLDocumentData `json:"mdl_documents,omitempty"`
 }
 
@@ -36,6 +39,9 @@ type VerifiableCredentialData struct {
 	CredentialSubject map[string]interface{} `json:"credential_subject,omitempty"`
 	IssuanceDate      string                 `json:"issuance_date,omitempty"`
 	ExpirationDate    string                 `json:"expiration_date,omitempty"`
+	// OpaqueIDSeedEpoch changes when the issuer rotates the holder's seed: a new
+	// pairwise_sub with a higher epoch is a rotation, not a new holder
+	OpaqueIDSeedEpoch int `json:"opaque_id_seed_epoch,omitempty"`
 }
 
 // VerifyResult represents the result of OID4VP verification
@@ -73,7 +79,7 @@ func (r *OIDVPAuthorizationResponse) IsSuccess() bool {
 	return r.Error == ""
 }
 
-// DetectPresentationFormat detects the format of a presentation (W3C JWT or ISO mDL)
+// DetectPresentationFormat detects the format of a presentation (W3C JWT, SD-JWT or ISO mDL)
 func DetectPresentationFormat(presentation string) (CredentialFormat, error) {
 	// Try to decode as base64 first
 	decoded, err := base64.StdEncoding.DecodeString(presentation)
@@ -83,7 +89,11 @@ func DetectPresentationFormat(presentation string) (CredentialFormat, error) {
 	}
 
 	// Check for JWT pattern: starts with "eyJ" (base64 of "{")
+	// An SD-JWT appends its disclosures and Key Binding JWT: <jwt>~<disclosure>~...~<kb-jwt>
 	if strings.HasPrefix(presentation, "eyJ") {
+		if strings.Contains(presentation, "~") {
+			return FormatSDJWT, nil
+		}
 		return FormatW3CJWT, nil
 	}
 
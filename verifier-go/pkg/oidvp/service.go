@@ -2,7 +2,9 @@ package oidvp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
@@ -13,6 +15,14 @@ import (
 type VerifierService struct {
 	// Dependencies would go here
 	vpVerifyURI string
+	// Validator of the VP token, bound to the request's nonce and client_id
+	presentationValidator PresentationValidator
+}
+
+// PresentationValidator validates presentations bound to the verifier's nonce
+// and client_id, returning the JSON validation response (see vp.Service)
+type PresentationValidator interface {
+	ValidateWithBinding(ctx context.Context, presentations []string, nonce, clientID string) (string, int, error)
 }
 
 // NewVerifierService creates a new OID4VP verifier service
@@ -22,6 +32,11 @@ func NewVerifierService(vpVerifyURI string) *VerifierService {
 	}
 }
 
+// SetPresentationValidator sets the validator of VP tokens
+func (s *VerifierService) SetPresentationValidator(validator PresentationValidator) {
+	s.presentationValidator = validator
+}
+
 // Verify verifies an OID4VP authorization response
 // Equivalent to Java's VerifierService.verify()
 func (s *VerifierService) Verify(ctx context.Context, authzResponse *models.OIDVPAuthorizationResponse, nonce, clientID, presentationDefinition string) (*models.VerifyResult, error) {
@@ -56,21 +71,82 @@ func (s *VerifierService) verifyPresentation(ctx context.Context, vpToken, prese
 		}, nil
 	}
 
-	// In a full implementation, this would:
+	if s.presentationValidator == nil {
+		return &models.VerifyResult{
+			VerifyResult: false,
+			Error: &models.ErrorInfo{
+				Code:    errors.Unknown,
+				Message: "presentation validator is not configured",
+			},
+		}, nil
+	}
+
+	// Validate the VP token against the request's nonce and client_id
+	presentations, err := vpTokenPresentations(vpToken)
+	if err != nil {
+		return &models.VerifyResult{
+			VerifyResult: false,
+			Error: &models.ErrorInfo{
+				Code:    errors.ErrPresInvalidPresentationValidationRequest,
+				Message: "invalid vp_token",
+			},
+		}, nil
+	}
+	result, _, err := s.presentationValidator.ValidateWithBinding(ctx, presentations, nonce, clientID)
+	if err != nil {
+		errorInfo := &models.ErrorInfo{Code: errors.ErrPresValidateVPError, Message: "presentation validation failed"}
+		if vpErr, ok := err.(*errors.VPError); ok {
+			errorInfo = &models.ErrorInfo{Code: vpErr.Code, Message: vpErr.Message}
+		}
+		return &models.VerifyResult{VerifyResult: false, Error: errorInfo}, nil
+	}
+
+	var responses []models.PresentationValidationResponse
+	if err := json.Unmarshal([]byte(result), &responses); err != nil {
+		return nil, fmt.Errorf("invalid presentation validation response: %w", err)
+	}
+
+	// In a full implementation, this would also:
 	// 1. Validate presentation submission schema
 	// 2. Parse presentation definition
-	// 3. Validate VP token (call VP validation service)
-	// 4. Extract holder DID
-	// 5. Validate client_id and nonce
-	// 6. Evaluate presentation against presentation definition
-	// 7. Validate custom data if present
+	// 3. Evaluate presentation against presentation definition
+	// 4. Validate custom data if present
 
-	// For now, return success placeholder
-	return &models.VerifyResult{
+	verifyResult := &models.VerifyResult{
 		VerifyResult: true,
-		HolderDID:    "did:example:holder",
 		VCClaims:     []models.VCResponseObject{},
-	}, nil
+	}
+	for _, response := range responses {
+		if verifyResult.HolderDID == "" {
+			verifyResult.HolderDID = response.HolderDID
+		}
+		for _, vc := range response.VerifiableCredentials {
+			credentialType := ""
+			if len(vc.CredentialTypes) > 0 {
+				credentialType = vc.CredentialTypes[len(vc.CredentialTypes)-1]
+			}
+			verifyResult.VCClaims = append(verifyResult.VCClaims, models.VCResponseObject{
+				CredentialType: credentialType,
+				Claims:         vc.CredentialSubject,
+			})
+		}
+	}
+	return verifyResult, nil
+}
+
+// vpTokenPresentations splits a vp_token, a single presentation or a JSON
+// array of presentations, into its presentations
+func vpTokenPresentations(vpToken string) ([]string, error) {
+	vpToken = strings.TrimSpace(vpToken)
+	if !strings.HasPrefix(vpToken, "[") {
+		return []string{vpToken}, nil
+	}
+
+	var presentations []string
+	if err := json.Unmarshal([]byte(vpToken), &presentations); err != nil {
+		return nil, err
+	}
+	return presentations, nil
 }
 
 // GetVerifyResult retrieves a previously stored verification result
@@ -2,6 +2,7 @@ package oidvp
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
@@ -50,10 +51,28 @@ func TestVerify_WalletError(t *testing.T) {
 	}
 }
 
+// fakePresentationValidator records the binding it validates against
+type fakePresentationValidator struct {
+	presentations []string
+	nonce         string
+	clientID      string
+	err           error
+}
+
+func (v *fakePresentationValidator) ValidateWithBinding(ctx context.Context, presentations []string, nonce, clientID string) (string, int, error) {
+	v.presentations, v.nonce, v.clientID = presentations, nonce, clientID
+	if v.err != nil {
+		return "", http.StatusBadRequest, v.err
+	}
+	return `[{"holder_did":"did:example:holder","vcs":[{"credential_types":["VerifiableCredential","NationalIDCredential"],"credential_subject":{"given_name":"Mei"}}]}]`, http.StatusOK, nil
+}
+
 // TestVerify_Success tests successful verification
 func TestVerify_Success(t *testing.T) {
 	// Given
 	service := NewVerifierService("http://localhost:8080/verify")
+	validator := &fakePresentationValidator{}
+	service.SetPresentationValidator(validator)
 	ctx := context.Background()
 	authzResponse := &models.OIDVPAuthorizationResponse{
 		VPToken:               "eyJhbGciOiJFUzI1NiJ9.test.signature",
@@ -72,8 +91,65 @@ func TestVerify_Success(t *testing.T) {
 		t.Error("Expected VerifyResult to be true")
 	}
 
-	if result.HolderDID == "" {
-		t.Error("Expected holder DID to be set")
+	if result.HolderDID != "did:example:holder" {
+		t.Errorf("Expected holder DID from the presentation, got %s", result.HolderDID)
+	}
+
+	if len(result.VCClaims) != 1 || result.VCClaims[0].CredentialType != "NationalIDCredential" || result.VCClaims[0].Claims["given_name"] != "Mei" {
+		t.Errorf("Expected the disclosed claims, got %+v", result.VCClaims)
+	}
+
+	if validator.nonce != "test-nonce" || validator.clientID != "test-client-id" {
+		t.Errorf("Expected the VP token to be bound to the request's nonce and client_id, got %s / %s", validator.nonce, validator.clientID)
+	}
+}
+
+// TestVerify_InvalidPresentation tests that a presentation rejected by the validator fails verification
+func TestVerify_InvalidPresentation(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator PresentationValidator
+		vpToken   string
+		expected  int
+	}{
+		{"Rejected", &fakePresentationValidator{err: errors.NewVPError(errors.ErrPresValidateVPContentError, "nonce mismatch")}, "header.payload.signature", errors.ErrPresValidateVPContentError},
+		{"No validator", nil, "header.payload.signature", errors.Unknown},
+		{"Invalid vp_token array", &fakePresentationValidator{}, "[not-json", errors.ErrPresInvalidPresentationValidationRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			service := NewVerifierService("http://localhost:8080/verify")
+			if tt.validator != nil {
+				service.SetPresentationValidator(tt.validator)
+			}
+			authzResponse := &models.OIDVPAuthorizationResponse{VPToken: tt.vpToken}
+
+			// When
+			result, err := service.Verify(context.Background(), authzResponse, "test-nonce", "test-client-id", "{}")
+
+			// Then
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.VerifyResult || result.Error == nil || result.Error.Code != tt.expected {
+				t.Errorf("Expected verification to fail with code %d, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestVPTokenPresentations tests splitting a vp_token into presentations
+func TestVPTokenPresentations(t *testing.T) {
+	single, err := vpTokenPresentations("a.b.c")
+	if err != nil || len(single) != 1 || single[0] != "a.b.c" {
+		t.Errorf("Expected a single presentation, got %v (%v)", single, err)
+	}
+
+	array, err := vpTokenPresentations(`["a.b.c", "d.e.f~"]`)
+	if err != nil || len(array) != 2 || array[1] != "d.e.f~" {
+		t.Errorf("Expected two presentations, got %v (%v)", array, err)
 	}
 }
 
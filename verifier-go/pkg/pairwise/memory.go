@@ -0,0 +1,80 @@
+package pairwise
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRegistry is an in-memory PairwiseRegistry; accounts are lost on restart
+type MemoryRegistry struct {
+	accounts map[string]Account
+	mu       sync.Mutex
+
+	now func() time.Time
+}
+
+// NewMemoryRegistry creates an empty in-memory registry
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		accounts: make(map[string]Account),
+		now:      time.Now,
+	}
+}
+
+// Register creates the account of a pairwise_sub
+func (r *MemoryRegistry) Register(ctx context.Context, pairwiseSub string) (*Account, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[sub]; ok {
+		return nil, ErrSybilDetected
+	}
+
+	now := r.now().UTC()
+	account := Account{PairwiseSub: sub, RegisteredAt: now, LastSeenAt: now}
+	r.accounts[sub] = account
+	return &account, nil
+}
+
+// Authenticate returns the account of a pairwise_sub and updates its last seen time
+func (r *MemoryRegistry) Authenticate(ctx context.Context, pairwiseSub string) (*Account, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[sub]
+	if !ok {
+		return nil, ErrNotRegistered
+	}
+
+	account.LastSeenAt = r.now().UTC()
+	r.accounts[sub] = account
+	return &account, nil
+}
+
+// LastSeen returns the last registration or login time of a pairwise_sub
+func (r *MemoryRegistry) LastSeen(ctx context.Context, pairwiseSub string) (time.Time, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[sub]
+	if !ok {
+		return time.Time{}, ErrNotRegistered
+	}
+	return account.LastSeenAt, nil
+}
@@ -0,0 +1,46 @@
+package pairwise
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+)
+
+// SubClaim is the VP (or Key Binding JWT) claim carrying the holder's
+// pairwise pseudonymous identifier for this verifier
+const SubClaim = "pairwise_sub"
+
+// SeedClaim is the credential subject claim carrying the issuer-signed
+// opaque_id_seed a pairwise_sub is derived from
+const SeedClaim = "opaque_id_seed"
+
+// subSize is the size of a pairwise_sub: an HMAC-SHA256 output
+const subSize = 32
+
+var (
+	// ErrInvalidSub is returned when a pairwise_sub is not a base64url encoded 256-bit value
+	ErrInvalidSub = errors.New("pairwise: invalid pairwise_sub")
+
+	// ErrMissingSub is returned when a validated presentation carries no pairwise_sub
+	ErrMissingSub = errors.New("pairwise: presentation has no pairwise_sub")
+)
+
+// ParseSub validates a pairwise_sub (base64url, with or without padding, of
+// 32 bytes) and returns it in its canonical unpadded form
+func ParseSub(sub string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub, "="))
+	if err != nil || len(decoded) != subSize {
+		return "", ErrInvalidSub
+	}
+	return base64.RawURLEncoding.EncodeToString(decoded), nil
+}
+
+// Sub returns the pairwise_sub of a validated presentation
+func Sub(response *models.PresentationValidationResponse) (string, error) {
+	if response == nil || response.PairwiseSub == "" {
+		return "", ErrMissingSub
+	}
+	return ParseSub(response.PairwiseSub)
+}
@@ -0,0 +1,57 @@
+package pairwise
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+)
+
+func TestParseSub(t *testing.T) {
+	raw := make([]byte, 32)
+	for i := range raw {
+		raw[i] = byte(i * 7)
+	}
+	canonical := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name     string
+		sub      string
+		expected string
+		err      error
+	}{
+		{"Unpadded", canonical, canonical, nil},
+		{"Padded", base64.URLEncoding.EncodeToString(raw), canonical, nil},
+		{"Empty", "", "", ErrInvalidSub},
+		{"Standard base64", base64.StdEncoding.EncodeToString([]byte{0xfb, 0xff}), "", ErrInvalidSub},
+		{"Hex", "a3d7f9c8b2e1a4f6d8c9b7e2a5f8d3c1b4e7a9f2d6c8b3e5a7f9d2c4b6e8a1f3", "", ErrInvalidSub},
+		{"Too short", base64.RawURLEncoding.EncodeToString(raw[:31]), "", ErrInvalidSub},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := ParseSub(tt.sub)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if sub != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, sub)
+			}
+		})
+	}
+}
+
+func TestSub(t *testing.T) {
+	sub := base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+
+	if got, err := Sub(&models.PresentationValidationResponse{PairwiseSub: sub}); err != nil || got != sub {
+		t.Errorf("Expected %s, got %s (%v)", sub, got, err)
+	}
+	if _, err := Sub(&models.PresentationValidationResponse{}); !errors.Is(err, ErrMissingSub) {
+		t.Errorf("Expected ErrMissingSub, got %v", err)
+	}
+	if _, err := Sub(nil); !errors.Is(err, ErrMissingSub) {
+		t.Errorf("Expected ErrMissingSub, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package pairwise
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrSybilDetected is returned when a pairwise_sub is registered twice,
+	// i.e. the same person tries to open a second account
+	ErrSybilDetected = errors.New("pairwise: sybil detected: pairwise_sub is already registered")
+
+	// ErrNotRegistered is returned when a pairwise_sub has no account
+	ErrNotRegistered = errors.New("pairwise: pairwise_sub is not registered")
+)
+
+// Account is the registration of a pairwise_sub at a relying party
+type Account struct {
+	PairwiseSub  string
+	RegisteredAt time.Time
+	LastSeenAt   time.Time
+}
+
+// PairwiseRegistry enforces one account per person at a relying party,
+// keyed on the holder's pairwise_sub (see PAIRWISE-PSEUDONYM-DESIGN.md Part 3)
+type PairwiseRegistry interface {
+	// Register creates the account of a pairwise_sub
+	// (ErrSybilDetected if it is already registered, ErrInvalidSub if malformed)
+	Register(ctx context.Context, pairwiseSub string) (*Account, error)
+
+	// Authenticate returns the account of a pairwise_sub and records the
+	// login as its last seen time (ErrNotRegistered if absent)
+	Authenticate(ctx context.Context, pairwiseSub string) (*Account, error)
+
+	// LastSeen returns the last registration or login time of a pairwise_sub
+	// (ErrNotRegistered if absent)
+	LastSeen(ctx context.Context, pairwiseSub string) (time.Time, error)
+}
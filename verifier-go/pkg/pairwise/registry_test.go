@@ -0,0 +1,153 @@
+package pairwise
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSub returns a distinct valid pairwise_sub for each seed byte
+func testSub(seed byte) string {
+	raw := make([]byte, 32)
+	raw[0] = seed
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// testClock is a settable clock for registries under test
+type testClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// testRegistry exercises the PairwiseRegistry contract
+func testRegistry(t *testing.T, registry PairwiseRegistry, clock *testClock) {
+	ctx := context.Background()
+
+	t.Run("RegisterThenAuthenticate", func(t *testing.T) {
+		// Given
+		sub := testSub(1)
+		registeredAt := clock.Now()
+
+		account, err := registry.Register(ctx, sub)
+		if err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		if account.PairwiseSub != sub || !account.RegisteredAt.Equal(registeredAt) || !account.LastSeenAt.Equal(registeredAt) {
+			t.Errorf("Unexpected account: %+v", account)
+		}
+
+		// When
+		clock.Advance(time.Hour)
+		account, err = registry.Authenticate(ctx, sub)
+
+		// Then
+		if err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+		if !account.RegisteredAt.Equal(registeredAt) || !account.LastSeenAt.Equal(registeredAt.Add(time.Hour)) {
+			t.Errorf("Unexpected account: %+v", account)
+		}
+
+		lastSeen, err := registry.LastSeen(ctx, sub)
+		if err != nil || !lastSeen.Equal(registeredAt.Add(time.Hour)) {
+			t.Errorf("Expected last seen %v, got %v (%v)", registeredAt.Add(time.Hour), lastSeen, err)
+		}
+	})
+
+	t.Run("DuplicateIsSybil", func(t *testing.T) {
+		sub := testSub(2)
+		if _, err := registry.Register(ctx, sub); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		if _, err := registry.Register(ctx, sub); !errors.Is(err, ErrSybilDetected) {
+			t.Errorf("Expected ErrSybilDetected, got %v", err)
+		}
+	})
+
+	t.Run("PaddedDuplicateIsSybil", func(t *testing.T) {
+		raw := make([]byte, 32)
+		raw[0] = 3
+		if _, err := registry.Register(ctx, base64.RawURLEncoding.EncodeToString(raw)); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		if _, err := registry.Register(ctx, base64.URLEncoding.EncodeToString(raw)); !errors.Is(err, ErrSybilDetected) {
+			t.Errorf("Expected ErrSybilDetected, got %v", err)
+		}
+	})
+
+	t.Run("NotRegistered", func(t *testing.T) {
+		if _, err := registry.Authenticate(ctx, testSub(4)); !errors.Is(err, ErrNotRegistered) {
+			t.Errorf("Expected ErrNotRegistered, got %v", err)
+		}
+		if _, err := registry.LastSeen(ctx, testSub(4)); !errors.Is(err, ErrNotRegistered) {
+			t.Errorf("Expected ErrNotRegistered, got %v", err)
+		}
+	})
+
+	t.Run("InvalidSub", func(t *testing.T) {
+		if _, err := registry.Register(ctx, "not-a-pairwise-sub"); !errors.Is(err, ErrInvalidSub) {
+			t.Errorf("Expected ErrInvalidSub, got %v", err)
+		}
+		if _, err := registry.Authenticate(ctx, ""); !errors.Is(err, ErrInvalidSub) {
+			t.Errorf("Expected ErrInvalidSub, got %v", err)
+		}
+	})
+
+	t.Run("ConcurrentRegistrations", func(t *testing.T) {
+		// Given - many simultaneous registrations of the same person
+		sub := testSub(5)
+		const attempts = 20
+
+		var wg sync.WaitGroup
+		results := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := registry.Register(ctx, sub)
+				results <- err
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		// Then - exactly one account is created
+		registered := 0
+		for err := range results {
+			switch {
+			case err == nil:
+				registered++
+			case !errors.Is(err, ErrSybilDetected):
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		if registered != 1 {
+			t.Errorf("Expected 1 registration, got %d", registered)
+		}
+	})
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	clock := &testClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	registry := NewMemoryRegistry()
+	registry.now = clock.Now
+
+	testRegistry(t, registry, clock)
+}
@@ -0,0 +1,115 @@
+package pairwise
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeLayout is the storage format for timestamps (fixed-width UTC, so text order is chronological)
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// SQLRegistry is a PairwiseRegistry backed by database/sql. The SQL targets
+// SQLite through github.com/mattn/go-sqlite3 (requires cgo). The primary key
+// on pairwise_sub enforces one account per person across processes.
+type SQLRegistry struct {
+	db *sql.DB
+
+	now func() time.Time
+}
+
+// NewSQLRegistry creates a SQL registry and its table if it does not exist
+func NewSQLRegistry(ctx context.Context, db *sql.DB) (*SQLRegistry, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS pairwise_account (
+		pairwise_sub  TEXT PRIMARY KEY,
+		registered_at TEXT NOT NULL,
+		last_seen_at  TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pairwise_account table: %w", err)
+	}
+
+	return &SQLRegistry{db: db, now: time.Now}, nil
+}
+
+// Register creates the account of a pairwise_sub
+func (r *SQLRegistry) Register(ctx context.Context, pairwiseSub string) (*Account, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now().UTC()
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO pairwise_account (pairwise_sub, registered_at, last_seen_at) VALUES (?, ?, ?)`,
+		sub, now.Format(timeLayout), now.Format(timeLayout))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrSybilDetected
+		}
+		return nil, fmt.Errorf("failed to register pairwise_sub: %w", err)
+	}
+
+	return &Account{PairwiseSub: sub, RegisteredAt: now, LastSeenAt: now}, nil
+}
+
+// Authenticate returns the account of a pairwise_sub and updates its last seen time
+func (r *SQLRegistry) Authenticate(ctx context.Context, pairwiseSub string) (*Account, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now().UTC()
+	var registeredAt string
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE pairwise_account SET last_seen_at = ? WHERE pairwise_sub = ? RETURNING registered_at`,
+		now.Format(timeLayout), sub).Scan(&registeredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotRegistered
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate pairwise_sub: %w", err)
+	}
+
+	registered, err := time.ParseInLocation(timeLayout, registeredAt, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registered_at: %w", err)
+	}
+
+	return &Account{PairwiseSub: sub, RegisteredAt: registered, LastSeenAt: now}, nil
+}
+
+// LastSeen returns the last registration or login time of a pairwise_sub
+func (r *SQLRegistry) LastSeen(ctx context.Context, pairwiseSub string) (time.Time, error) {
+	sub, err := ParseSub(pairwiseSub)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var lastSeenAt string
+	err = r.db.QueryRowContext(ctx,
+		`SELECT last_seen_at FROM pairwise_account WHERE pairwise_sub = ?`, sub).Scan(&lastSeenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, ErrNotRegistered
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query pairwise_sub: %w", err)
+	}
+
+	lastSeen, err := time.ParseInLocation(timeLayout, lastSeenAt, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid last_seen_at: %w", err)
+	}
+	return lastSeen, nil
+}
+
+// isUniqueViolation reports whether err is a SQLite primary key / unique constraint violation
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") ||
+		strings.Contains(msg, "PRIMARY KEY constraint failed")
+}
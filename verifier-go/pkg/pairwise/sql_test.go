@@ -0,0 +1,70 @@
+package pairwise
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Failed to open database (github.com/mattn/go-sqlite3 requires CGO_ENABLED=1): %v", err)
+	}
+
+	return db
+}
+
+func TestSQLRegistry(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "verifier.db"))
+
+	registry, err := NewSQLRegistry(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	clock := &testClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	registry.now = clock.Now
+
+	testRegistry(t, registry, clock)
+}
+
+func TestSQLRegistry_PersistsAcrossReopen(t *testing.T) {
+	// Given - an account registered before a restart
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "verifier.db")
+
+	db := openTestDB(t, path)
+	registry, err := NewSQLRegistry(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	if _, err := registry.Register(ctx, testSub(1)); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	db.Close()
+
+	// When
+	reopened, err := NewSQLRegistry(ctx, openTestDB(t, path))
+	if err != nil {
+		t.Fatalf("Failed to reopen registry: %v", err)
+	}
+
+	// Then - the same person still cannot register twice
+	if _, err := reopened.Register(ctx, testSub(1)); !errors.Is(err, ErrSybilDetected) {
+		t.Errorf("Expected ErrSybilDetected, got %v", err)
+	}
+	if _, err := reopened.Authenticate(ctx, testSub(1)); err != nil {
+		t.Errorf("Authenticate failed: %v", err)
+	}
+}
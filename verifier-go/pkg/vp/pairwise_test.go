@@ -0,0 +1,228 @@
+package vp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/pairwise"
+)
+
+// pairwiseTestClientID is the verifier the pairwise_sub of test presentations is derived for
+const pairwiseTestClientID = "https://forum.example.com/callback"
+
+// randomPairwiseSub is a well-formed pairwise_sub derived from no seed
+var randomPairwiseSub = base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+
+// testSeed returns the opaque_id_seed of an issued VC-JWT, or of the
+// disclosures of an SD-JWT
+func testSeed(t *testing.T, credential string) string {
+	t.Helper()
+
+	parts := strings.Split(credential, "~")
+	for _, encoded := range parts[1:] {
+		data, _ := base64.RawURLEncoding.DecodeString(encoded)
+		var content []interface{}
+		if json.Unmarshal(data, &content) == nil && len(content) == 3 && content[1] == pairwise.SeedClaim {
+			return content[2].(string)
+		}
+	}
+
+	claims := &crypto.VCClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(parts[0], claims); err != nil {
+		t.Fatalf("Failed to parse VC: %v", err)
+	}
+	seed, ok := claims.VC.CredentialSubject[pairwise.SeedClaim].(string)
+	if !ok {
+		t.Fatal("Expected an opaque_id_seed in the credential")
+	}
+	return seed
+}
+
+// derivePairwiseSub derives the holder's pairwise_sub for clientID as a wallet does
+func derivePairwiseSub(t *testing.T, credential, clientID string) string {
+	t.Helper()
+
+	sub, err := pairwiseid.Derive(testSeed(t, credential), clientID)
+	if err != nil {
+		t.Fatalf("Failed to derive pairwise_sub: %v", err)
+	}
+	return sub
+}
+
+// resignVP re-signs a VP for pairwiseTestClientID with a pairwise_sub
+func (f *statusTestFixture) resignVP(t *testing.T, vpJWT, pairwiseSub string) string {
+	t.Helper()
+
+	claims := &crypto.VPClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(vpJWT, claims); err != nil {
+		t.Fatalf("Failed to parse VP: %v", err)
+	}
+	claims.Audience = jwt.ClaimStrings{pairwiseTestClientID}
+	claims.VP.PairwiseSub = pairwiseSub
+
+	signed, err := crypto.SignVP(claims, f.holderKey, statusTestHolderDID+"#key-1")
+	if err != nil {
+		t.Fatalf("Failed to sign VP: %v", err)
+	}
+	return signed
+}
+
+// vpCredential returns the first VC of a VP
+func vpCredential(t *testing.T, vpJWT string) string {
+	t.Helper()
+
+	claims := &crypto.VPClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(vpJWT, claims); err != nil {
+		t.Fatalf("Failed to parse VP: %v", err)
+	}
+	return claims.VP.VerifiableCredential[0]
+}
+
+// validateOne validates a single presentation and returns its response
+func validateOne(t *testing.T, service *Service, presentation string) models.PresentationValidationResponse {
+	t.Helper()
+	return validateOneWithBinding(t, service, presentation, "", "")
+}
+
+// validateOneWithBinding validates a single presentation bound to nonce and
+// clientID and returns its response
+func validateOneWithBinding(t *testing.T, service *Service, presentation, nonce, clientID string) models.PresentationValidationResponse {
+	t.Helper()
+
+	result, status, err := service.ValidateWithBinding(context.Background(), []string{presentation}, nonce, clientID)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (%v): %s", status, err, result)
+	}
+
+	var responses []models.PresentationValidationResponse
+	if err := json.Unmarshal([]byte(result), &responses); err != nil || len(responses) != 1 {
+		t.Fatalf("Failed to parse response: %v (%s)", err, result)
+	}
+	return responses[0]
+}
+
+func TestValidate_PairwiseSub(t *testing.T) {
+	f := newStatusTestFixture(t)
+	_, vpJWT := f.issue(t)
+	derived := derivePairwiseSub(t, vpCredential(t, vpJWT), pairwiseTestClientID)
+
+	t.Run("Derived from the seed", func(t *testing.T) {
+		// Given - padded, as a Dart wallet encodes it
+		response := validateOne(t, f.service, f.resignVP(t, vpJWT, derived+"="))
+
+		if response.PairwiseSub != derived {
+			t.Errorf("Expected pairwise_sub %s, got %s", derived, response.PairwiseSub)
+		}
+	})
+
+	t.Run("Absent", func(t *testing.T) {
+		response := validateOne(t, f.service, vpJWT)
+
+		if response.PairwiseSub != "" {
+			t.Errorf("Expected no pairwise_sub, got %s", response.PairwiseSub)
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		// A holder making up a fresh pseudonym to register again
+		expectVPError(t, f.service, f.resignVP(t, vpJWT, randomPairwiseSub), errors.ErrPresValidateVPContentError)
+	})
+
+	t.Run("Derived for another verifier", func(t *testing.T) {
+		other := derivePairwiseSub(t, vpCredential(t, vpJWT), "https://social.example.org")
+		expectVPError(t, f.service, f.resignVP(t, vpJWT, other), errors.ErrPresValidateVPContentError)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, sub := range []string{"a3d7f9c8", "not base64url!", base64.RawURLEncoding.EncodeToString(make([]byte, 16))} {
+			expectVPError(t, f.service, f.resignVP(t, vpJWT, sub), errors.ErrPresValidateVPContentError)
+		}
+	})
+}
+
+// presentWithPairwiseSub appends a KB-JWT for pairwiseTestClientID carrying a pairwise_sub
+func (f *sdJWTTestFixture) presentWithPairwiseSub(t *testing.T, sdJWT, pairwiseSub string) string {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(sdJWT))
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":          time.Now().Unix(),
+		"nonce":        sdJWTTestNonce,
+		"aud":          pairwiseTestClientID,
+		"sd_hash":      base64.RawURLEncoding.EncodeToString(sum[:]),
+		"pairwise_sub": pairwiseSub,
+	})
+	token.Header["typ"] = "kb+jwt"
+	kbJWT, err := token.SignedString(f.holderKey)
+	if err != nil {
+		t.Fatalf("Failed to sign KB-JWT: %v", err)
+	}
+	return sdJWT + kbJWT
+}
+
+func TestValidate_SDJWTPairwiseSub(t *testing.T) {
+	f := newSDJWTTestFixture(t)
+	issued := f.issue(t)
+	derived := derivePairwiseSub(t, issued, pairwiseTestClientID)
+
+	t.Run("Derived from the disclosed seed", func(t *testing.T) {
+		// When
+		response := validateOneWithBinding(t, f.service, f.presentWithPairwiseSub(t, discloseClaims(t, issued, "given_name", pairwise.SeedClaim), derived), sdJWTTestNonce, pairwiseTestClientID)
+
+		// Then
+		if response.PairwiseSub != derived {
+			t.Errorf("Expected pairwise_sub %s, got %s", derived, response.PairwiseSub)
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		presentation := f.presentWithPairwiseSub(t, discloseClaims(t, issued, "given_name", pairwise.SeedClaim), randomPairwiseSub)
+		expectVPErrorWithBinding(t, f.service, presentation, sdJWTTestNonce, pairwiseTestClientID, errors.ErrPresValidateVPContentError)
+	})
+
+	t.Run("Seed not disclosed", func(t *testing.T) {
+		presentation := f.presentWithPairwiseSub(t, discloseClaims(t, issued, "given_name"), derived)
+		expectVPErrorWithBinding(t, f.service, presentation, sdJWTTestNonce, pairwiseTestClientID, errors.ErrPresValidateVPContentError)
+	})
+}
+
+func TestValidate_OpaqueIDSeedEpoch(t *testing.T) {
+	t.Run("VC-JWT", func(t *testing.T) {
+		f := newStatusTestFixture(t)
+		_, vpJWT := f.issue(t)
+
+		response := validateOne(t, f.service, vpJWT)
+
+		if epoch := response.VerifiableCredentials[0].OpaqueIDSeedEpoch; epoch != 1 {
+			t.Errorf("Expected opaque_id_seed_epoch 1, got %d", epoch)
+		}
+	})
+
+	t.Run("SD-JWT after a seed rotation", func(t *testing.T) {
+		// Given - the issuer rotated the holder's seed
+		f := newSDJWTTestFixture(t)
+		f.issue(t)
+		if _, status, err := f.issuer.RotateSeed(context.Background(), statusTestHolderDID, "NationalIDCredential", nil); err != nil || status != http.StatusOK {
+			t.Fatalf("Failed to rotate seed: %d %v", status, err)
+		}
+
+		// When
+		response := validateOneWithBinding(t, f.service, f.present(t, discloseClaims(t, f.issue(t), "given_name")), sdJWTTestNonce, sdJWTTestClientID)
+
+		// Then
+		if epoch := response.VerifiableCredentials[0].OpaqueIDSeedEpoch; epoch != 2 {
+			t.Errorf("Expected opaque_id_seed_epoch 2, got %d", epoch)
+		}
+	})
+}
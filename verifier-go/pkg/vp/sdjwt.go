@@ -0,0 +1,437 @@
+package vp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+)
+
+// SD-JWT constants (RFC 9901)
+const (
+	sdJWTSeparator      = "~"
+	sdHashAlgorithm     = "sha-256" // the only supported _sd_alg
+	sdDigestsClaim      = "_sd"
+	sdAlgorithmClaim    = "_sd_alg"
+	sdArrayElementClaim = "..."
+	kbJWTType           = "kb+jwt"
+)
+
+// kbJWTClaims represents the claims of a Key Binding JWT
+type kbJWTClaims struct {
+	jwt.RegisteredClaims
+	Nonce       string `json:"nonce"`
+	SDHash      string `json:"sd_hash"`
+	PairwiseSub string `json:"pairwise_sub,omitempty"`
+}
+
+// sdDisclosure is a decoded disclosure of an SD-JWT
+type sdDisclosure struct {
+	name  string // empty for an array element
+	value interface{}
+}
+
+// validateSDJWTPresentations validates SD-JWT VC presentations
+func (s *Service) validateSDJWTPresentations(ctx context.Context, presentations []string, binding presentationBinding) ([]models.PresentationValidationResponse, error) {
+	// An unbound SD-JWT presentation could be replayed to any verifier
+	if binding.nonce == "" || binding.audience == "" {
+		return nil, errors.NewVPError(
+			errors.ErrPresInvalidPresentationValidationRequest,
+			"SD-JWT presentations must be validated against the verifier's nonce and client_id",
+		)
+	}
+
+	var results []models.PresentationValidationResponse
+	isArray := len(presentations) > 1
+
+	for vpIndex, presentation := range presentations {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return nil, errors.NewVPError(
+				errors.Unknown,
+				"operation cancelled",
+			)
+		default:
+			// Continue processing
+		}
+
+		// Skip blank presentations
+		presentation = strings.TrimSpace(presentation)
+		if presentation == "" {
+			continue
+		}
+
+		result, err := s.validateSDJWT(ctx, presentation, binding)
+		if err != nil {
+			if vpErr, ok := err.(*errors.VPError); ok {
+				return nil, errors.NewVPError(
+					vpErr.Code,
+					fmt.Sprintf("(vp_path=%s) -> %s", getVPPath(vpIndex, isArray), vpErr.Message),
+				)
+			}
+			return nil, err
+		}
+
+		result.VerifiableCredentials[0].VPPath = getVPPath(vpIndex, isArray)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// validateSDJWT validates a single SD-JWT presentation,
+// <issuer-jwt>~<disclosure>~...~<kb-jwt>:
+//  1. verifies the issuer-signed JWT and the credential status
+//  2. checks every disclosure is referenced by exactly one digest and rebuilds the disclosed claims
+//  3. verifies the Key Binding JWT with the cnf key, its sd_hash, nonce, aud and
+//     iat freshness, and checks the holder's pairwise_sub in it against the disclosed opaque_id_seed
+func (s *Service) validateSDJWT(ctx context.Context, presentation string, binding presentationBinding) (models.PresentationValidationResponse, error) {
+	parts := strings.Split(presentation, sdJWTSeparator)
+	issuerJWT, encodedDisclosures, kbJWT := parts[0], parts[1:len(parts)-1], parts[len(parts)-1]
+	if kbJWT == "" {
+		return models.PresentationValidationResponse{}, errors.NewVPError(errors.ErrPresValidateVPContentError, "missing key binding JWT")
+	}
+
+	// 1. Validate the issuer-signed JWT
+	vcClaims, err := s.jwtValidator.ValidateVC(issuerJWT)
+	if err != nil {
+		return models.PresentationValidationResponse{}, errors.NewVPError(
+			credentialProofErrorCode(err),
+			fmt.Sprintf("VC validation failed: %v", err),
+		)
+	}
+
+	payload := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(issuerJWT, payload); err != nil {
+		return models.PresentationValidationResponse{}, errors.NewVPError(errors.ErrCredValidateVCContentError, "invalid vc content")
+	}
+
+	issuerDID := vcClaims.Issuer
+	if issuerDID == "" && vcClaims.VC.Issuer != "" {
+		issuerDID = vcClaims.VC.Issuer
+	}
+
+	if err := s.checkCredentialStatus(ctx, issuerDID, vcClaims.VC.CredentialStatus); err != nil {
+		return models.PresentationValidationResponse{}, err
+	}
+
+	// 2. Rebuild the disclosed claims
+	claims, err := disclosedClaims(payload, encodedDisclosures)
+	if err != nil {
+		return models.PresentationValidationResponse{}, err
+	}
+
+	// 3. Validate the Key Binding JWT
+	holderJWK, _ := nestedObject(claims, "cnf", "jwk")
+	if holderJWK == nil {
+		return models.PresentationValidationResponse{}, errors.NewVPError(errors.ErrPresLackOfHolderPublicKey, "lack of holder's public key (cnf)")
+	}
+	holderKey, err := crypto.ParseJWK(holderJWK)
+	if err != nil {
+		return models.PresentationValidationResponse{}, errors.NewVPError(
+			errors.ErrPresLackOfHolderPublicKey,
+			fmt.Sprintf("invalid holder's public key (cnf): %v", err),
+		)
+	}
+
+	kbClaims, err := validateKeyBindingJWT(kbJWT, holderKey, presentation[:len(presentation)-len(kbJWT)], binding, s.keyBindingMaxAge)
+	if err != nil {
+		return models.PresentationValidationResponse{}, err
+	}
+
+	// 4. Return the disclosed credential data
+	credentialSubject, _ := nestedObject(claims, "vc", "credentialSubject")
+	if credentialSubject == nil {
+		credentialSubject = make(map[string]interface{})
+	}
+	credentialTypes := vcClaims.VC.Type
+	if credentialTypes == nil {
+		credentialTypes = []string{}
+	}
+
+	pairwiseSub, err := s.verifyPairwiseSub(kbClaims.PairwiseSub, kbClaims.Audience[0], credentialSubject)
+	if err != nil {
+		return models.PresentationValidationResponse{}, err
+	}
+
+	return models.PresentationValidationResponse{
+		Format:      models.FormatSDJWT.String(),
+		ClientID:    kbClaims.Audience[0],
+		Nonce:       kbClaims.Nonce,
+		HolderDID:   vcClaims.Subject,
+		PairwiseSub: pairwiseSub,
+		VerifiableCredentials: []models.VerifiableCredentialData{{
+			VCPath:                   "$",
+			HolderPublicKey:          holderJWK,
+			Sub:                      vcClaims.Subject,
+			LimitDisclosureSupported: true,
+			IssuerDID:                issuerDID,
+			CredentialTypes:          credentialTypes,
+			CredentialSubject:        credentialSubject,
+			IssuanceDate:             vcClaims.VC.IssuanceDate,
+			ExpirationDate:           vcClaims.VC.ExpirationDate,
+			OpaqueIDSeedEpoch:        vcClaims.OpaqueIDSeedEpoch,
+		}},
+	}, nil
+}
+
+// validateKeyBindingJWT verifies a Key Binding JWT signed by the holder key.
+// sdJWT is the presentation without the KB-JWT (<issuer-jwt>~<disclosure>~...~);
+// its digest must match sd_hash. The nonce and aud must match the binding and
+// iat must be no older than maxAge.
+func validateKeyBindingJWT(kbJWT string, holderKey interface{}, sdJWT string, binding presentationBinding, maxAge time.Duration) (*kbJWTClaims, error) {
+	claims := &kbJWTClaims{}
+	_, err := jwt.ParseWithClaims(kbJWT, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != kbJWTType {
+			return nil, fmt.Errorf("invalid typ: %q", typ)
+		}
+		switch token.Method.(type) {
+		case *jwt.SigningMethodECDSA, *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			return holderKey, nil
+		default:
+			return nil, fmt.Errorf("unsupported signing method: %v", token.Method.Alg())
+		}
+	}, jwt.WithIssuedAt())
+	if err != nil {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPProofError, fmt.Sprintf("key binding JWT validation failed: %v", err))
+	}
+
+	if claims.IssuedAt == nil {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, "key binding JWT lacks iat")
+	}
+	if age := time.Since(claims.IssuedAt.Time); age > maxAge {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, fmt.Sprintf("key binding JWT is too old: issued %s ago, maximum %s", age.Round(time.Second), maxAge))
+	}
+	if claims.SDHash != sdDigest(sdJWT) {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, "key binding JWT sd_hash does not match the presentation")
+	}
+	if claims.Nonce == "" {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, "key binding JWT lacks nonce")
+	}
+	if claims.Nonce != binding.nonce {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, fmt.Sprintf("nonce mismatch: expected %s, got %s", binding.nonce, claims.Nonce))
+	}
+	if len(claims.Audience) == 0 || claims.Audience[0] == "" {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, "key binding JWT lacks aud")
+	}
+	if claims.Audience[0] != binding.audience {
+		return nil, errors.NewVPError(errors.ErrPresValidateVPContentError, fmt.Sprintf("audience mismatch: expected %s, got %v", binding.audience, claims.Audience))
+	}
+
+	return claims, nil
+}
+
+// disclosedClaims replaces the digests of an issuer-signed JWT payload with
+// the claims of the presented disclosures. Digests without a disclosure
+// (undisclosed claims or decoys) are dropped; a disclosure presented twice,
+// referenced more than once, or not referenced at all is rejected.
+// Equivalent to the Authlete SD-JWT library's SDObjectDecoder.decode()
+func disclosedClaims(payload map[string]interface{}, encodedDisclosures []string) (map[string]interface{}, error) {
+	// _sd_alg is a top-level claim; credentials issued by the Java issuer carry it in credentialSubject
+	algorithm, _ := payload[sdAlgorithmClaim].(string)
+	if algorithm == "" {
+		credentialSubject, _ := nestedObject(payload, "vc", "credentialSubject")
+		algorithm, _ = credentialSubject[sdAlgorithmClaim].(string)
+	}
+	if algorithm != "" && algorithm != sdHashAlgorithm {
+		return nil, errors.NewVPError(errors.ErrCredValidateVCContentError, fmt.Sprintf("unsupported _sd_alg: %s", algorithm))
+	}
+
+	d := &sdDecoder{
+		disclosures: make(map[string]*sdDisclosure, len(encodedDisclosures)),
+		digests:     make(map[string]bool),
+	}
+	for _, encoded := range encodedDisclosures {
+		disclosure, err := parseDisclosure(encoded)
+		if err != nil {
+			return nil, errors.NewVPError(errors.ErrCredValidateVCContentError, fmt.Sprintf("invalid disclosure: %v", err))
+		}
+		digest := sdDigest(encoded)
+		if _, ok := d.disclosures[digest]; ok {
+			return nil, errors.NewVPError(errors.ErrCredValidateVCContentError, "duplicate disclosure")
+		}
+		d.disclosures[digest] = disclosure
+	}
+
+	claims, err := d.decodeObject(payload)
+	if err != nil {
+		return nil, errors.NewVPError(errors.ErrCredValidateVCContentError, fmt.Sprintf("invalid selective disclosure: %v", err))
+	}
+	if d.used != len(d.disclosures) {
+		return nil, errors.NewVPError(errors.ErrCredValidateVCContentError, "disclosure is not referenced by the credential")
+	}
+
+	return claims, nil
+}
+
+// sdDecoder tracks the digests seen while rebuilding a payload
+type sdDecoder struct {
+	disclosures map[string]*sdDisclosure // presented disclosures by digest
+	digests     map[string]bool          // every digest seen, disclosed or not
+	used        int                      // number of disclosures referenced
+}
+
+// disclosure returns the disclosure of a digest (nil if not presented);
+// a digest may only appear once in the payload
+func (d *sdDecoder) disclosure(digest interface{}) (*sdDisclosure, error) {
+	value, ok := digest.(string)
+	if !ok {
+		return nil, fmt.Errorf("digest is not a string")
+	}
+	if d.digests[value] {
+		return nil, fmt.Errorf("digest %s appears more than once", value)
+	}
+	d.digests[value] = true
+
+	disclosure := d.disclosures[value]
+	if disclosure != nil {
+		d.used++
+	}
+	return disclosure, nil
+}
+
+func (d *sdDecoder) decodeObject(obj map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(obj))
+	for name, value := range obj {
+		if name == sdDigestsClaim || name == sdAlgorithmClaim {
+			continue
+		}
+		decoded, err := d.decodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = decoded
+	}
+
+	digests, ok := obj[sdDigestsClaim]
+	if !ok {
+		return result, nil
+	}
+	digestList, ok := digests.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", sdDigestsClaim)
+	}
+
+	for _, digest := range digestList {
+		disclosure, err := d.disclosure(digest)
+		if err != nil {
+			return nil, err
+		}
+		if disclosure == nil {
+			continue
+		}
+		if disclosure.name == "" {
+			return nil, fmt.Errorf("array element disclosure referenced by %s", sdDigestsClaim)
+		}
+		if _, exists := result[disclosure.name]; exists {
+			return nil, fmt.Errorf("disclosed claim %q already exists", disclosure.name)
+		}
+
+		decoded, err := d.decodeValue(disclosure.value)
+		if err != nil {
+			return nil, err
+		}
+		result[disclosure.name] = decoded
+	}
+
+	return result, nil
+}
+
+func (d *sdDecoder) decodeArray(arr []interface{}) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(arr))
+	for _, element := range arr {
+		if obj, ok := element.(map[string]interface{}); ok && len(obj) == 1 {
+			if digest, ok := obj[sdArrayElementClaim]; ok {
+				disclosure, err := d.disclosure(digest)
+				if err != nil {
+					return nil, err
+				}
+				if disclosure == nil {
+					continue
+				}
+				if disclosure.name != "" {
+					return nil, fmt.Errorf("object property disclosure referenced by an array element")
+				}
+				element = disclosure.value
+			}
+		}
+
+		decoded, err := d.decodeValue(element)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, decoded)
+	}
+	return result, nil
+}
+
+func (d *sdDecoder) decodeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return d.decodeObject(v)
+	case []interface{}:
+		return d.decodeArray(v)
+	default:
+		return v, nil
+	}
+}
+
+// parseDisclosure decodes a disclosure: base64url of [salt, name, value] or, for an array element, [salt, value]
+func parseDisclosure(encoded string) (*sdDisclosure, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url encoding")
+	}
+
+	var content []interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("not a JSON array")
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty disclosure")
+	}
+	if _, ok := content[0].(string); !ok {
+		return nil, fmt.Errorf("invalid salt")
+	}
+
+	switch len(content) {
+	case 2:
+		return &sdDisclosure{value: content[1]}, nil
+	case 3:
+		name, ok := content[1].(string)
+		if !ok || name == sdDigestsClaim || name == sdArrayElementClaim {
+			return nil, fmt.Errorf("invalid claim name")
+		}
+		return &sdDisclosure{name: name, value: content[2]}, nil
+	default:
+		return nil, fmt.Errorf("expected 2 or 3 elements, got %d", len(content))
+	}
+}
+
+// sdDigest returns the base64url encoded SHA-256 digest of a disclosure or SD-JWT
+func sdDigest(encoded string) string {
+	sum := sha256.Sum256([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// nestedObject returns obj[keys[0]][keys[1]]... if every value is an object
+func nestedObject(obj map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, key := range keys {
+		value, ok := obj[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		obj = value
+	}
+	return obj, true
+}
@@ -0,0 +1,452 @@
+package vp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/credential"
+	issuerModels "github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+)
+
+const (
+	sdJWTTestNonce    = "nonce-sd-jwt"
+	sdJWTTestClientID = "https://verifier.example.com"
+)
+
+// sdJWTTestFixture holds an SD-JWT issuer, a holder and a verifier wired together
+type sdJWTTestFixture struct {
+	issuer    *credential.Service
+	holderKey *ecdsa.PrivateKey
+	service   *Service
+}
+
+func newSDJWTTestFixture(t *testing.T) *sdJWTTestFixture {
+	t.Helper()
+
+	issuerKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	der, err := x509.MarshalPKCS8PrivateKey(issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer key: %v", err)
+	}
+	issuer := credential.NewService(statusTestIssuerDID, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		credential.WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(issuerModels.CredentialPolicyEntity{
+			CredentialType:      "NationalIDCredential",
+			ExpirationDuration:  1,
+			ExpirationTimeUnit:  issuerModels.TimeUnitYear,
+			SelectiveDisclosure: `{"selective_disclosure": {"family_name": {"always_disclosed": true}}}`,
+			PairwiseEnabled:     true,
+		})))
+
+	resolver := crypto.NewDIDResolver()
+	resolver.RegisterLocalKey(statusTestIssuerDID, issuer.PublicKey())
+
+	service := NewServiceWithResolver(resolver)
+	service.SetStatusListFetcher(issuerStatusListFetcher(issuer))
+
+	return &sdJWTTestFixture{issuer: issuer, holderKey: holderKey, service: service}
+}
+
+// holderJWK returns the holder's public key as a JWK
+func (f *sdJWTTestFixture) holderJWK() map[string]interface{} {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	f.holderKey.PublicKey.X.FillBytes(x)
+	f.holderKey.PublicKey.Y.FillBytes(y)
+	return map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(x),
+		"y":   base64.RawURLEncoding.EncodeToString(y),
+	}
+}
+
+// issue issues an SD-JWT bound to the holder key and returns <issuer-jwt>~<disclosure>~...~
+func (f *sdJWTTestFixture) issue(t *testing.T) string {
+	t.Helper()
+
+	result, status, err := f.issuer.Generate(context.Background(), &issuerModels.CredentialRequestDTO{
+		IssuerDID:           statusTestIssuerDID,
+		CredentialType:      "NationalIDCredential",
+		CredentialSubjectID: statusTestHolderDID,
+		CredentialSubject: map[string]interface{}{
+			"family_name": "Chen",
+			"given_name":  "Mei",
+			"birth_date":  "1990-01-01",
+			"nationality": []string{"TW", "JP"},
+		},
+		HolderPublicKey: f.holderJWK(),
+	})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to issue credential: %v (status %d)", err, status)
+	}
+
+	var issued issuerModels.CredentialResponseDTO
+	if err := json.Unmarshal([]byte(result), &issued); err != nil {
+		t.Fatalf("Failed to parse issuer response: %v", err)
+	}
+	return issued.Credential
+}
+
+// disclose keeps the disclosures of an issued SD-JWT accepted by keep, called
+// with the decoded disclosure ([salt, name, value] or [salt, value])
+func disclose(t *testing.T, sdJWT string, keep func(content []interface{}) bool) string {
+	t.Helper()
+
+	parts := strings.Split(sdJWT, "~")
+	kept := []string{parts[0]}
+	for _, encoded := range parts[1 : len(parts)-1] {
+		data, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("Failed to decode disclosure: %v", err)
+		}
+		var content []interface{}
+		if err := json.Unmarshal(data, &content); err != nil {
+			t.Fatalf("Failed to parse disclosure: %v", err)
+		}
+		if keep(content) {
+			kept = append(kept, encoded)
+		}
+	}
+	return strings.Join(kept, "~") + "~"
+}
+
+// discloseClaims keeps the disclosures of the named claims; the nationality
+// elements are kept along with nationality
+func discloseClaims(t *testing.T, sdJWT string, names ...string) string {
+	return disclose(t, sdJWT, func(content []interface{}) bool {
+		for _, name := range names {
+			if (len(content) == 3 && content[1] == name) || (len(content) == 2 && name == "nationality") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// keyBindingJWT signs a KB-JWT over sdJWT (<issuer-jwt>~<disclosure>~...~)
+func keyBindingJWT(t *testing.T, sdJWT string, key *ecdsa.PrivateKey, typ, nonce, audience string) string {
+	t.Helper()
+	return keyBindingJWTAt(t, sdJWT, key, typ, nonce, audience, time.Now())
+}
+
+// keyBindingJWTAt signs a KB-JWT over sdJWT issued at iat
+func keyBindingJWTAt(t *testing.T, sdJWT string, key *ecdsa.PrivateKey, typ, nonce, audience string, iat time.Time) string {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(sdJWT))
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":     iat.Unix(),
+		"nonce":   nonce,
+		"aud":     audience,
+		"sd_hash": base64.RawURLEncoding.EncodeToString(sum[:]),
+	})
+	token.Header["typ"] = typ
+
+	kbJWT, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign KB-JWT: %v", err)
+	}
+	return kbJWT
+}
+
+// present appends a KB-JWT signed by the holder to an SD-JWT
+func (f *sdJWTTestFixture) present(t *testing.T, sdJWT string) string {
+	return sdJWT + keyBindingJWT(t, sdJWT, f.holderKey, "kb+jwt", sdJWTTestNonce, sdJWTTestClientID)
+}
+
+// validate validates presentations bound to sdJWTTestNonce and sdJWTTestClientID
+func (f *sdJWTTestFixture) validate(presentations ...string) (string, int, error) {
+	return f.service.ValidateWithBinding(context.Background(), presentations, sdJWTTestNonce, sdJWTTestClientID)
+}
+
+func TestValidate_SDJWT(t *testing.T) {
+	// Given - the holder discloses given_name and nationality, but not birth_date nor opaque_id_seed
+	f := newSDJWTTestFixture(t)
+	presentation := f.present(t, discloseClaims(t, f.issue(t), "given_name", "nationality"))
+
+	// When
+	result, status, err := f.validate(presentation)
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (%v): %s", status, err, result)
+	}
+
+	var responses []models.PresentationValidationResponse
+	if err := json.Unmarshal([]byte(result), &responses); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(responses) != 1 || len(responses[0].VerifiableCredentials) != 1 {
+		t.Fatalf("Expected 1 presentation with 1 VC, got %+v", responses)
+	}
+
+	response := responses[0]
+	if response.Format != "sd_jwt" {
+		t.Errorf("Expected format sd_jwt, got %s", response.Format)
+	}
+	if response.Nonce != sdJWTTestNonce || response.ClientID != sdJWTTestClientID {
+		t.Errorf("Expected nonce and client_id from the KB-JWT, got %s / %s", response.Nonce, response.ClientID)
+	}
+
+	vc := response.VerifiableCredentials[0]
+	if vc.IssuerDID != statusTestIssuerDID {
+		t.Errorf("Unexpected issuer: %s", vc.IssuerDID)
+	}
+	if vc.Sub != "" || response.HolderDID != "" {
+		t.Errorf("Expected an SD-JWT VC without a subject ID, got %s / %s", vc.Sub, response.HolderDID)
+	}
+	if vc.HolderPublicKey["x"] != f.holderJWK()["x"] {
+		t.Errorf("Expected the cnf key as holder public key, got %v", vc.HolderPublicKey)
+	}
+
+	subject := vc.CredentialSubject
+	if subject["family_name"] != "Chen" || subject["given_name"] != "Mei" {
+		t.Errorf("Expected disclosed claims, got %v", subject)
+	}
+	nationality, _ := subject["nationality"].([]interface{})
+	if len(nationality) != 2 || nationality[0] != "TW" || nationality[1] != "JP" {
+		t.Errorf("Expected nationality [TW JP], got %v", subject["nationality"])
+	}
+	for _, name := range []string{"id", "birth_date", "opaque_id_seed", "_sd", "_sd_alg"} {
+		if _, ok := subject[name]; ok {
+			t.Errorf("Expected %s not to be in the credential subject", name)
+		}
+	}
+}
+
+func TestValidate_SDJWTArrayElements(t *testing.T) {
+	// Given - the holder discloses nationality but only its first element
+	f := newSDJWTTestFixture(t)
+	sdJWT := disclose(t, f.issue(t), func(content []interface{}) bool {
+		return (len(content) == 3 && content[1] == "nationality") || (len(content) == 2 && content[1] == "TW")
+	})
+
+	// When
+	result, status, err := f.validate(f.present(t, sdJWT))
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (%v): %s", status, err, result)
+	}
+	var responses []models.PresentationValidationResponse
+	json.Unmarshal([]byte(result), &responses)
+
+	nationality, _ := responses[0].VerifiableCredentials[0].CredentialSubject["nationality"].([]interface{})
+	if len(nationality) != 1 || nationality[0] != "TW" {
+		t.Errorf("Expected nationality [TW], got %v", nationality)
+	}
+}
+
+func TestValidate_SDJWTErrors(t *testing.T) {
+	f := newSDJWTTestFixture(t)
+	sdJWT := discloseClaims(t, f.issue(t), "given_name")
+	otherDisclosure := strings.Split(discloseClaims(t, f.issue(t), "given_name"), "~")[1]
+	givenName := strings.Split(sdJWT, "~")[1]
+	otherHolderKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	withKB := func(sdJWT string) string { return f.present(t, sdJWT) }
+	parts := strings.Split(sdJWT, ".")
+	tamperedIssuerJWT := parts[0] + "." + parts[1] + "." + strings.Repeat("A", 86) + "~"
+
+	tests := []struct {
+		name         string
+		presentation string
+		expectedCode int
+	}{
+		{"Missing KB-JWT", sdJWT, errors.ErrPresValidateVPContentError},
+		{"Invalid issuer signature", withKB(tamperedIssuerJWT), errors.ErrCredValidateVCProofError},
+		{"Unreferenced disclosure", withKB(sdJWT + otherDisclosure + "~"), errors.ErrCredValidateVCContentError},
+		{"Duplicate disclosure", withKB(sdJWT + givenName + "~"), errors.ErrCredValidateVCContentError},
+		{"Invalid disclosure", withKB(sdJWT + "not-a-disclosure~"), errors.ErrCredValidateVCContentError},
+		{"sd_hash mismatch", sdJWT + keyBindingJWT(t, strings.TrimSuffix(sdJWT, givenName+"~"), f.holderKey, "kb+jwt", sdJWTTestNonce, sdJWTTestClientID), errors.ErrPresValidateVPContentError},
+		{"KB-JWT signed by another key", sdJWT + keyBindingJWT(t, sdJWT, otherHolderKey, "kb+jwt", sdJWTTestNonce, sdJWTTestClientID), errors.ErrPresValidateVPProofError},
+		{"KB-JWT with wrong typ", sdJWT + keyBindingJWT(t, sdJWT, f.holderKey, "JWT", sdJWTTestNonce, sdJWTTestClientID), errors.ErrPresValidateVPProofError},
+		{"KB-JWT without nonce", sdJWT + keyBindingJWT(t, sdJWT, f.holderKey, "kb+jwt", "", sdJWTTestClientID), errors.ErrPresValidateVPContentError},
+		{"KB-JWT without aud", sdJWT + keyBindingJWT(t, sdJWT, f.holderKey, "kb+jwt", sdJWTTestNonce, ""), errors.ErrPresValidateVPContentError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			result, _, err := f.validate(tt.presentation)
+
+			// Then
+			vpErr, ok := err.(*errors.VPError)
+			if !ok || vpErr.Code != tt.expectedCode {
+				t.Errorf("Expected error code %d, got %v: %s", tt.expectedCode, err, result)
+			}
+		})
+	}
+}
+
+func TestValidateWithBinding_SDJWT(t *testing.T) {
+	f := newSDJWTTestFixture(t)
+	presentation := f.present(t, discloseClaims(t, f.issue(t), "given_name"))
+
+	tests := []struct {
+		name         string
+		nonce        string
+		clientID     string
+		expectedCode int
+	}{
+		{"Matching nonce and client_id", sdJWTTestNonce, sdJWTTestClientID, 0},
+		{"Nonce mismatch", "other-nonce", sdJWTTestClientID, errors.ErrPresValidateVPContentError},
+		{"Audience mismatch", sdJWTTestNonce, "https://other.example.com", errors.ErrPresValidateVPContentError},
+		{"Unbound", "", "", errors.ErrPresInvalidPresentationValidationRequest},
+		{"Without nonce", "", sdJWTTestClientID, errors.ErrPresInvalidPresentationValidationRequest},
+		{"Without client_id", sdJWTTestNonce, "", errors.ErrPresInvalidPresentationValidationRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			result, status, err := f.service.ValidateWithBinding(context.Background(), []string{presentation}, tt.nonce, tt.clientID)
+
+			// Then
+			if tt.expectedCode == 0 {
+				if err != nil || status != http.StatusOK {
+					t.Errorf("Expected status 200, got %d (%v): %s", status, err, result)
+				}
+				return
+			}
+			vpErr, ok := err.(*errors.VPError)
+			if !ok || vpErr.Code != tt.expectedCode {
+				t.Errorf("Expected error code %d, got %v: %s", tt.expectedCode, err, result)
+			}
+		})
+	}
+}
+
+func TestValidate_SDJWTUnbound(t *testing.T) {
+	// Given
+	f := newSDJWTTestFixture(t)
+	presentation := f.present(t, discloseClaims(t, f.issue(t), "given_name"))
+
+	// When
+	result, status, err := f.service.Validate(context.Background(), []string{presentation})
+
+	// Then
+	vpErr, ok := err.(*errors.VPError)
+	if !ok || vpErr.Code != errors.ErrPresInvalidPresentationValidationRequest || status != http.StatusBadRequest {
+		t.Errorf("Expected an unbound SD-JWT presentation to be rejected, got %d %v: %s", status, err, result)
+	}
+}
+
+func TestValidateWithBinding_SDJWTKeyBindingAge(t *testing.T) {
+	f := newSDJWTTestFixture(t)
+	f.service.SetKeyBindingMaxAge(time.Minute)
+	sdJWT := discloseClaims(t, f.issue(t), "given_name")
+
+	tests := []struct {
+		name         string
+		iat          time.Time
+		expectedCode int
+	}{
+		{"Fresh", time.Now().Add(-30 * time.Second), 0},
+		{"Stale", time.Now().Add(-2 * time.Minute), errors.ErrPresValidateVPContentError},
+		{"Issued in the future", time.Now().Add(time.Hour), errors.ErrPresValidateVPProofError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			presentation := sdJWT + keyBindingJWTAt(t, sdJWT, f.holderKey, "kb+jwt", sdJWTTestNonce, sdJWTTestClientID, tt.iat)
+			result, status, err := f.validate(presentation)
+
+			// Then
+			if tt.expectedCode == 0 {
+				if err != nil || status != http.StatusOK {
+					t.Errorf("Expected status 200, got %d (%v): %s", status, err, result)
+				}
+				return
+			}
+			vpErr, ok := err.(*errors.VPError)
+			if !ok || vpErr.Code != tt.expectedCode {
+				t.Errorf("Expected error code %d, got %v: %s", tt.expectedCode, err, result)
+			}
+		})
+	}
+}
+
+func TestDisclosedClaims(t *testing.T) {
+	encode := func(content ...interface{}) string {
+		data, _ := json.Marshal(content)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	name := encode("salt1", "name", "Mei")
+	element := encode("salt2", "TW")
+	decoy := sdDigest(encode("salt3", "decoy", true))
+
+	tests := []struct {
+		name        string
+		payload     map[string]interface{}
+		disclosures []string
+		expected    map[string]interface{}
+	}{
+		{"Decoy digests are dropped", map[string]interface{}{
+			"_sd": []interface{}{sdDigest(name), decoy}, "_sd_alg": "sha-256",
+		}, []string{name}, map[string]interface{}{"name": "Mei"}},
+		{"Undisclosed array elements are dropped", map[string]interface{}{
+			"nationality": []interface{}{map[string]interface{}{"...": sdDigest(element)}, map[string]interface{}{"...": decoy}, "JP"},
+		}, []string{element}, map[string]interface{}{"nationality": []interface{}{"TW", "JP"}}},
+		{"Digest referenced twice", map[string]interface{}{
+			"_sd": []interface{}{sdDigest(name)}, "nested": map[string]interface{}{"_sd": []interface{}{sdDigest(name)}},
+		}, []string{name}, nil},
+		{"Disclosed claim already exists", map[string]interface{}{
+			"name": "Alice", "_sd": []interface{}{sdDigest(name)},
+		}, []string{name}, nil},
+		{"Array element disclosure in _sd", map[string]interface{}{
+			"_sd": []interface{}{sdDigest(element)},
+		}, []string{element}, nil},
+		{"Object property disclosure in an array", map[string]interface{}{
+			"nationality": []interface{}{map[string]interface{}{"...": sdDigest(name)}},
+		}, []string{name}, nil},
+		{"Unsupported _sd_alg", map[string]interface{}{
+			"_sd": []interface{}{sdDigest(name)}, "_sd_alg": "sha-512",
+		}, []string{name}, nil},
+		{"Reserved claim name", map[string]interface{}{
+			"_sd": []interface{}{sdDigest(encode("salt4", "_sd", "x"))},
+		}, []string{encode("salt4", "_sd", "x")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			claims, err := disclosedClaims(tt.payload, tt.disclosures)
+
+			// Then
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("Expected error, got %v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, _ := json.Marshal(claims)
+			expected, _ := json.Marshal(tt.expected)
+			if string(got) != string(expected) {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
@@ -2,14 +2,19 @@ package vp
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/pairwiseid"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/pairwise"
 )
 
 // Validation limits to prevent DoS attacks
@@ -19,34 +24,84 @@ const (
 	MaxTotalPayloadSize    = 10485760  // 10MB - Maximum total size of all presentations
 )
 
+// DefaultKeyBindingMaxAge is how old a Key Binding JWT (its iat) may be by default
+const DefaultKeyBindingMaxAge = 5 * time.Minute
+
 // Service handles VP (Verifiable Presentation) validation
 type Service struct {
 	// JWT validator for cryptographic validation
 	jwtValidator *crypto.JWTValidator
 	// DID resolver for resolving public keys
 	didResolver *crypto.DIDResolver
+	// Status list fetcher for credential status checking
+	statusListFetcher StatusListFetcher
+	// Verifier groups sharing a pairwise_sub (nil if none)
+	verifierGroups *pairwiseid.GroupRegistry
+	// Maximum age of a Key Binding JWT
+	keyBindingMaxAge time.Duration
 }
 
 // NewService creates a new VP validation service
 func NewService() *Service {
 	resolver := crypto.NewDIDResolver()
 	return &Service{
-		jwtValidator: crypto.NewJWTValidator(resolver),
-		didResolver:  resolver,
+		jwtValidator:      crypto.NewJWTValidator(resolver),
+		didResolver:       resolver,
+		statusListFetcher: NewHTTPStatusListFetcher(),
+		keyBindingMaxAge:  DefaultKeyBindingMaxAge,
 	}
 }
 
 // NewServiceWithResolver creates a new VP validation service with custom resolver
 func NewServiceWithResolver(resolver *crypto.DIDResolver) *Service {
 	return &Service{
-		jwtValidator: crypto.NewJWTValidator(resolver),
-		didResolver:  resolver,
+		jwtValidator:      crypto.NewJWTValidator(resolver),
+		didResolver:       resolver,
+		statusListFetcher: NewHTTPStatusListFetcher(),
+		keyBindingMaxAge:  DefaultKeyBindingMaxAge,
 	}
 }
 
+// SetStatusListFetcher replaces the fetcher used to load status list credentials
+func (s *Service) SetStatusListFetcher(fetcher StatusListFetcher) {
+	s.statusListFetcher = fetcher
+}
+
+// SetVerifierGroups sets the verifier groups whose members share a
+// pairwise_sub; a pairwise_sub presented to a group member is derived from the
+// group ID instead of the verifier's domain
+func (s *Service) SetVerifierGroups(groups *pairwiseid.GroupRegistry) {
+	s.verifierGroups = groups
+}
+
+// SetKeyBindingMaxAge sets how old the iat of a Key Binding JWT may be
+func (s *Service) SetKeyBindingMaxAge(maxAge time.Duration) {
+	s.keyBindingMaxAge = maxAge
+}
+
+// presentationBinding is the nonce and audience (client_id) a presentation
+// must be bound to; empty values are not checked for W3C VPs, and SD-JWT
+// presentations are rejected without both
+type presentationBinding struct {
+	nonce    string
+	audience string
+}
+
 // Validate validates a list of verifiable presentations
 // This is the Go equivalent of PresentationServiceAsync.validate()
+// SD-JWT presentations are rejected: they must be validated with ValidateWithBinding
 func (s *Service) Validate(ctx context.Context, presentations []string) (string, int, error) {
+	return s.validate(ctx, presentations, presentationBinding{})
+}
+
+// ValidateWithBinding validates a list of verifiable presentations that must
+// be bound to the verifier's nonce and client_id (the VP or Key Binding JWT
+// nonce and aud)
+func (s *Service) ValidateWithBinding(ctx context.Context, presentations []string, nonce, clientID string) (string, int, error) {
+	return s.validate(ctx, presentations, presentationBinding{nonce: nonce, audience: clientID})
+}
+
+func (s *Service) validate(ctx context.Context, presentations []string, binding presentationBinding) (string, int, error) {
 	// Check for nil or empty presentation list
 	if presentations == nil || len(presentations) == 0 {
 		vpErr := errors.NewVPError(
@@ -128,7 +183,9 @@ func (s *Service) Validate(ctx context.Context, presentations []string) (string,
 		var results []models.PresentationValidationResponse
 		switch format {
 		case models.FormatW3CJWT:
-			results, err = s.validateW3CVPs(ctx, presentations)
+			results, err = s.validateW3CVPs(ctx, presentations, binding)
+		case models.FormatSDJWT:
+			results, err = s.validateSDJWTPresentations(ctx, presentations, binding)
 		case models.FormatISOMDL:
 			results, err = s.validateMDLPresentations(ctx, presentations)
 		default:
@@ -160,7 +217,7 @@ func (s *Service) Validate(ctx context.Context, presentations []string) (string,
 	}
 
 	// Legacy path for empty list (should not reach here due to earlier check)
-	results, err := s.validateW3CVPs(ctx, presentations)
+	results, err := s.validateW3CVPs(ctx, presentations, binding)
 	if err != nil {
 		if vpErr, ok := err.(*errors.VPError); ok {
 			response, _ := json.Marshal(vpErr.Response())
@@ -183,7 +240,7 @@ func (s *Service) Validate(ctx context.Context, presentations []string) (string,
 }
 
 // validateW3CVPs validates multiple W3C JWT-VC presentations
-func (s *Service) validateW3CVPs(ctx context.Context, presentations []string) ([]models.PresentationValidationResponse, error) {
+func (s *Service) validateW3CVPs(ctx context.Context, presentations []string, binding presentationBinding) ([]models.PresentationValidationResponse, error) {
 	var results []models.PresentationValidationResponse
 	isArray := len(presentations) > 1
 
@@ -211,7 +268,7 @@ func (s *Service) validateW3CVPs(ctx context.Context, presentations []string) ([
 		}
 
 		// Validate individual VP
-		result, err := s.validateVP(ctx, presentation, vpIndex, isArray)
+		result, err := s.validateVP(ctx, presentation, vpIndex, isArray, binding)
 		if err != nil {
 			return nil, err
 		}
@@ -223,12 +280,12 @@ func (s *Service) validateW3CVPs(ctx context.Context, presentations []string) ([
 }
 
 // validateVP validates a single VP
-func (s *Service) validateVP(ctx context.Context, presentation string, vpIndex int, isArray bool) (models.PresentationValidationResponse, error) {
-	// 1. Parse and validate VP JWT signature
-	vpClaims, err := s.jwtValidator.ValidateVP(presentation, "", "") // Nonce and audience validation optional for now
+func (s *Service) validateVP(ctx context.Context, presentation string, vpIndex int, isArray bool, binding presentationBinding) (models.PresentationValidationResponse, error) {
+	// 1. Parse and validate VP JWT signature (nonce and audience only if bound)
+	vpClaims, err := s.jwtValidator.ValidateVP(presentation, binding.nonce, binding.audience)
 	if err != nil {
 		return models.PresentationValidationResponse{}, errors.NewVPError(
-			errors.ErrPresValidateVPError,
+			proofErrorCode(err, errors.ErrPresValidateVPError),
 			fmt.Sprintf("VP validation failed: %v", err),
 		)
 	}
@@ -253,30 +310,123 @@ func (s *Service) validateVP(ctx context.Context, presentation string, vpIndex i
 	for vcIndex, vcJWT := range vpClaims.VP.VerifiableCredential {
 		vcResult, err := s.validateVC(ctx, vcJWT, vcIndex, holderDID)
 		if err != nil {
-			// For now, continue processing other VCs but log the error
-			// In production, you might want to fail fast or collect all errors
-			continue
+			// A VC failing validation (ex: revoked) rejects the whole presentation
+			// Equivalent to Java's PresentationServiceAsync.getVcDataFromFutureTask()
+			if vpErr, ok := err.(*errors.VPError); ok {
+				return models.PresentationValidationResponse{}, errors.NewVPError(
+					vpErr.Code,
+					fmt.Sprintf("(vp_path=%s,vc_path=%s) -> %s", getVPPath(vpIndex, isArray), getVCPath(vcIndex), vpErr.Message),
+				)
+			}
+			return models.PresentationValidationResponse{}, err
 		}
 		vcResults = append(vcResults, vcResult)
 	}
 
-	// 5. Return validation response
+	// 5. Check the holder's pairwise pseudonymous identifier, if presented,
+	// against the opaque_id_seed of the credentials
+	credentialSubjects := make([]map[string]interface{}, 0, len(vcResults))
+	for _, vcResult := range vcResults {
+		credentialSubjects = append(credentialSubjects, vcResult.CredentialSubject)
+	}
+	pairwiseSub, err := s.verifyPairwiseSub(vpClaims.VP.PairwiseSub, clientID, credentialSubjects...)
+	if err != nil {
+		return models.PresentationValidationResponse{}, err
+	}
+
+	// 6. Return validation response
 	return models.PresentationValidationResponse{
 		Format:                models.FormatW3CJWT.String(),
 		ClientID:              clientID,
 		Nonce:                 nonce,
 		HolderDID:             holderDID,
 		VerifiableCredentials: vcResults,
+		PairwiseSub:           pairwiseSub,
 	}, nil
 }
 
+// proofErrorCode returns the error code of a failed JWT validation: code, or
+// ErrConnNoMatchedIssuerPublicKey if the kid names no key authorized to sign it
+func proofErrorCode(err error, code int) int {
+	if stderrors.Is(err, crypto.ErrNoMatchedPublicKey) {
+		return errors.ErrConnNoMatchedIssuerPublicKey
+	}
+	return code
+}
+
+// credentialProofErrorCode returns the error code of a failed VC validation:
+// deactivated and malformed issuer DIDs are told apart from bad proofs
+func credentialProofErrorCode(err error) int {
+	switch {
+	case stderrors.Is(err, crypto.ErrDIDDeactivated):
+		return errors.ErrCredInvalidIssuerDIDStatus
+	case stderrors.Is(err, crypto.ErrInvalidDID):
+		return errors.ErrCredInvalidIssuerDIDFormat
+	default:
+		return proofErrorCode(err, errors.ErrCredValidateVCProofError)
+	}
+}
+
+// parsePairwiseSub validates the pairwise_sub of a presentation ("" if absent)
+func parsePairwiseSub(sub string) (string, error) {
+	if sub == "" {
+		return "", nil
+	}
+	parsed, err := pairwise.ParseSub(sub)
+	if err != nil {
+		return "", errors.NewVPError(errors.ErrPresValidateVPContentError, "invalid pairwise_sub: expected a base64url encoded 256-bit value")
+	}
+	return parsed, nil
+}
+
+// verifyPairwiseSub checks a presented pairwise_sub ("" if absent) is the
+// HMAC-SHA256 of the opaque_id_seed of one of the credential subjects and the
+// canonical domain (or verifier group) of clientID, so a holder cannot present
+// a pseudonym of their choosing. It returns the pairwise_sub in canonical form.
+func (s *Service) verifyPairwiseSub(sub, clientID string, credentialSubjects ...map[string]interface{}) (string, error) {
+	parsed, err := parsePairwiseSub(sub)
+	if err != nil || parsed == "" {
+		return parsed, err
+	}
+
+	seeds := 0
+	for _, credentialSubject := range credentialSubjects {
+		seed, ok := credentialSubject[pairwise.SeedClaim].(string)
+		if !ok {
+			continue
+		}
+		seeds++
+
+		expected, err := s.derivePairwiseSub(seed, clientID)
+		if err != nil {
+			return "", errors.NewVPError(errors.ErrPresValidateVPContentError, fmt.Sprintf("cannot derive pairwise_sub for client_id %q: %v", clientID, err))
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(parsed)) == 1 {
+			return parsed, nil
+		}
+	}
+
+	if seeds == 0 {
+		return "", errors.NewVPError(errors.ErrPresValidateVPContentError, "pairwise_sub requires a disclosed opaque_id_seed")
+	}
+	return "", errors.NewVPError(errors.ErrPresValidateVPContentError, "pairwise_sub does not match the opaque_id_seed and client_id")
+}
+
+// derivePairwiseSub derives the pairwise_sub of a seed for a verifier
+func (s *Service) derivePairwiseSub(seed, clientID string) (string, error) {
+	if s.verifierGroups != nil {
+		return s.verifierGroups.Derive(seed, clientID)
+	}
+	return pairwiseid.Derive(seed, clientID)
+}
+
 // validateVC validates a single VC and extracts its data
 func (s *Service) validateVC(ctx context.Context, vcJWT string, vcIndex int, expectedHolderDID string) (models.VerifiableCredentialData, error) {
 	// 1. Parse and validate VC JWT signature
 	vcClaims, err := s.jwtValidator.ValidateVC(vcJWT)
 	if err != nil {
 		return models.VerifiableCredentialData{}, errors.NewVPError(
-			errors.ErrCredValidateVCProofError,
+			credentialProofErrorCode(err),
 			fmt.Sprintf("VC validation failed: %v", err),
 		)
 	}
@@ -295,25 +445,31 @@ func (s *Service) validateVC(ctx context.Context, vcJWT string, vcIndex int, exp
 		issuerDID = vcClaims.VC.Issuer
 	}
 
-	// 4. Extract credential types
+	// 4. Check credential status against the issuer's status lists
+	if err := s.checkCredentialStatus(ctx, issuerDID, vcClaims.VC.CredentialStatus); err != nil {
+		return models.VerifiableCredentialData{}, err
+	}
+
+	// 5. Extract credential types
 	credentialTypes := vcClaims.VC.Type
 	if credentialTypes == nil {
 		credentialTypes = []string{}
 	}
 
-	// 5. Extract credential subject data
+	// 6. Extract credential subject data
 	credentialSubject := vcClaims.VC.CredentialSubject
 	if credentialSubject == nil {
 		credentialSubject = make(map[string]interface{})
 	}
 
-	// 6. Return VC data
+	// 7. Return VC data
 	return models.VerifiableCredentialData{
 		IssuerDID:         issuerDID,
 		CredentialTypes:   credentialTypes,
 		CredentialSubject: credentialSubject,
 		IssuanceDate:      vcClaims.VC.IssuanceDate,
 		ExpirationDate:    vcClaims.VC.ExpirationDate,
+		OpaqueIDSeedEpoch: vcClaims.OpaqueIDSeedEpoch,
 	}, nil
 }
 
@@ -5,13 +5,18 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/credential"
+	issuerModels "github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
 	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
 )
 
 // TestValidate_WithRealJWT tests the full VP validation flow with real JWT signatures
@@ -273,3 +278,187 @@ func TestValidate_WithInvalidSignature(t *testing.T) {
 		t.Error("Expected non-OK status due to invalid signature")
 	}
 }
+
+// TestValidate_IssuerRoundTrip tests that a credential issued by credential.Service
+// validates through vp.Service
+func TestValidate_IssuerRoundTrip(t *testing.T) {
+	// Setup: Generate keys for issuer and holder
+	issuerPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	der, err := x509.MarshalPKCS8PrivateKey(issuerPrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer key: %v", err)
+	}
+	issuerKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	issuerDID := "did:example:issuer123"
+	holderDID := "did:example:holder456"
+
+	// Issue a credential
+	issuer := credential.NewService(issuerDID, issuerKeyPEM, withIssuerPolicies())
+	result, status, err := issuer.Generate(context.Background(), &issuerModels.CredentialRequestDTO{
+		IssuerDID:           issuerDID,
+		CredentialType:      "NationalIDCredential",
+		CredentialSubjectID: holderDID,
+		CredentialSubject: map[string]interface{}{
+			"name": "Test User",
+		},
+	})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to issue credential: %v (status %d)", err, status)
+	}
+
+	var issued issuerModels.CredentialResponseDTO
+	if err := json.Unmarshal([]byte(result), &issued); err != nil {
+		t.Fatalf("Failed to parse issuer response: %v", err)
+	}
+
+	// Create DID resolver and register keys
+	resolver := crypto.NewDIDResolver()
+	resolver.RegisterLocalKey(issuerDID, issuer.PublicKey())
+	resolver.RegisterLocalKey(holderDID, &holderPrivateKey.PublicKey)
+	service := NewServiceWithResolver(resolver)
+	service.SetStatusListFetcher(issuerStatusListFetcher(issuer))
+
+	// Wrap the credential in a VP signed by the holder
+	vpClaims := &crypto.VPClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "nonce-roundtrip",
+			Subject:   holderDID,
+			Audience:  jwt.ClaimStrings{"did:example:verifier789"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		VP: crypto.PresentationSubject{
+			Context:              []string{"https://www.w3.org/2018/credentials/v1"},
+			Type:                 []string{"VerifiablePresentation"},
+			VerifiableCredential: []string{issued.Credential},
+			Holder:               holderDID,
+		},
+	}
+
+	vpJWT, err := crypto.SignVP(vpClaims, holderPrivateKey, holderDID+"#key-1")
+	if err != nil {
+		t.Fatalf("Failed to sign VP: %v", err)
+	}
+
+	// Test: Validate the VP
+	validation, status, err := service.Validate(context.Background(), []string{vpJWT})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Validation failed: %v (status %d)", err, status)
+	}
+
+	var response []struct {
+		VCs []struct {
+			IssuerDID         string                 `json:"issuer_did"`
+			CredentialSubject map[string]interface{} `json:"credential_subject"`
+		} `json:"vcs"`
+	}
+	if err := json.Unmarshal([]byte(validation), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if len(response) != 1 || len(response[0].VCs) != 1 {
+		t.Fatalf("Expected 1 validated VC, got %s", validation)
+	}
+
+	vc := response[0].VCs[0]
+	if vc.IssuerDID != issuerDID {
+		t.Errorf("Expected issuer %s, got %s", issuerDID, vc.IssuerDID)
+	}
+	if vc.CredentialSubject["name"] != "Test User" {
+		t.Errorf("Expected name claim, got %v", vc.CredentialSubject["name"])
+	}
+}
+
+// TestValidate_KidNotMatchingIssuer tests that a VC whose kid names another
+// DID's key is rejected with ErrConnNoMatchedIssuerPublicKey
+func TestValidate_KidNotMatchingIssuer(t *testing.T) {
+	// Given
+	issuerPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	issuerDID := "did:example:issuer123"
+	holderDID := "did:example:holder456"
+
+	resolver := crypto.NewDIDResolver()
+	resolver.RegisterLocalKey(issuerDID, &issuerPrivateKey.PublicKey)
+	resolver.RegisterLocalKey(holderDID, &holderPrivateKey.PublicKey)
+	service := NewServiceWithResolver(resolver)
+
+	vcJWT, _ := crypto.SignVC(&crypto.VCClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuerDID,
+			Subject:   holderDID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		},
+		VC: crypto.CredentialSubject{Type: []string{"VerifiableCredential"}},
+	}, issuerPrivateKey, "did:example:other#key-1")
+	vpJWT, _ := crypto.SignVP(&crypto.VPClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "nonce-kid",
+			Subject:   holderDID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+		},
+		VP: crypto.PresentationSubject{
+			Type:                 []string{"VerifiablePresentation"},
+			VerifiableCredential: []string{vcJWT},
+		},
+	}, holderPrivateKey, holderDID+"#key-1")
+
+	// When / Then
+	expectVPError(t, service, vpJWT, errors.ErrConnNoMatchedIssuerPublicKey)
+}
+
+// TestValidate_IssuerDIDStatus tests that VCs from deactivated or malformed
+// issuer DIDs are rejected with the issuer DID error codes
+func TestValidate_IssuerDIDStatus(t *testing.T) {
+	issuerPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderDID := "did:example:holder456"
+
+	tests := []struct {
+		name      string
+		issuerDID string
+		expected  int
+	}{
+		{"deactivated issuer", "did:twdiw:deactivated", errors.ErrCredInvalidIssuerDIDStatus},
+		{"malformed issuer", "issuer123", errors.ErrCredInvalidIssuerDIDFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given - a DID registry reporting every did:twdiw as deactivated
+			resolver := crypto.NewDIDResolver()
+			resolver.RegisterLocalKey(holderDID, &holderPrivateKey.PublicKey)
+			resolver.RegisterMethod("twdiw", crypto.DIDMethodResolverFunc(func(did string) (*crypto.DIDResolutionResult, error) {
+				return &crypto.DIDResolutionResult{DIDDocumentMetadata: crypto.DIDDocumentMetadata{Deactivated: true}}, nil
+			}))
+			service := NewServiceWithResolver(resolver)
+
+			vcJWT, _ := crypto.SignVC(&crypto.VCClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    tt.issuerDID,
+					Subject:   holderDID,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+				},
+				VC: crypto.CredentialSubject{Type: []string{"VerifiableCredential"}},
+			}, issuerPrivateKey, tt.issuerDID+"#key-1")
+			vpJWT, _ := crypto.SignVP(&crypto.VPClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "nonce-issuer",
+					Subject:   holderDID,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+				},
+				VP: crypto.PresentationSubject{
+					Type:                 []string{"VerifiablePresentation"},
+					VerifiableCredential: []string{vcJWT},
+				},
+			}, holderPrivateKey, holderDID+"#key-1")
+
+			// When / Then
+			expectVPError(t, service, vpJWT, tt.expected)
+		})
+	}
+}
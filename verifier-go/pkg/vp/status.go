@@ -0,0 +1,271 @@
+package vp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
+)
+
+// Status list limits
+const (
+	MaxStatusListSize             = 1048576  // 1MB - Maximum size of a fetched status list response
+	maxDecompressedStatusListSize = 16777216 // 16MB - Maximum size of a decompressed bitstring
+)
+
+// statusListCredentialTypes lists the accepted types of a status list credential
+var statusListCredentialTypes = []string{"BitstringStatusListCredential", "StatusList2021Credential"}
+
+// StatusListFetcher loads a signed status list credential (a JWT) from its URL
+type StatusListFetcher interface {
+	FetchStatusList(ctx context.Context, statusListURL string) (string, error)
+}
+
+// StatusListFetcherFunc adapts a function to a StatusListFetcher
+type StatusListFetcherFunc func(ctx context.Context, statusListURL string) (string, error)
+
+// FetchStatusList calls f(ctx, statusListURL)
+func (f StatusListFetcherFunc) FetchStatusList(ctx context.Context, statusListURL string) (string, error) {
+	return f(ctx, statusListURL)
+}
+
+// HTTPStatusListFetcher loads status lists from the issuer over HTTP(S)
+// Equivalent to Java's ResourceLoadService.loadStatusList()
+type HTTPStatusListFetcher struct {
+	httpClient *http.Client
+}
+
+// NewHTTPStatusListFetcher creates a status list fetcher with a 10 second timeout
+func NewHTTPStatusListFetcher() *HTTPStatusListFetcher {
+	return &HTTPStatusListFetcher{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// FetchStatusList fetches a status list credential. The issuer may answer
+// with the raw JWT or with a JSON body carrying it in "status_list".
+func (f *HTTPStatusListFetcher) FetchStatusList(ctx context.Context, statusListURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusListURL, nil)
+	if err != nil {
+		return "", errors.NewVPError(errors.ErrConnLoadIssuerStatusListError, fmt.Sprintf("invalid status list URL: %s", statusListURL))
+	}
+	req.Header.Set("Accept", "application/jwt, application/json")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return "", errors.NewVPError(errors.ErrConnLoadIssuerStatusListError, fmt.Sprintf("fail to load issuer's status list: %v", err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxStatusListSize+1))
+	if err != nil {
+		return "", errors.NewVPError(errors.ErrConnLoadIssuerStatusListError, fmt.Sprintf("fail to load issuer's status list: %v", err))
+	}
+	if len(body) > MaxStatusListSize {
+		return "", errors.NewVPError(errors.ErrConnInvalidIssuerStatusList, fmt.Sprintf("issuer's status list exceeds maximum size of %d bytes", MaxStatusListSize))
+	}
+
+	content := strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || content == "" {
+		return "", errors.NewVPError(
+			errors.ErrConnLoadIssuerStatusListError,
+			fmt.Sprintf("fail to load issuer's status list. (http status code) = (%d)", resp.StatusCode),
+		)
+	}
+
+	if !strings.HasPrefix(content, "{") {
+		return content, nil
+	}
+
+	var response struct {
+		StatusList string `json:"status_list"`
+	}
+	if err := json.Unmarshal([]byte(content), &response); err != nil || response.StatusList == "" {
+		return "", errors.NewVPError(errors.ErrConnInvalidIssuerStatusList, "invalid issuer's status list")
+	}
+	return response.StatusList, nil
+}
+
+// statusListClaims represents the claims of a status list credential JWT
+type statusListClaims struct {
+	jwt.RegisteredClaims
+	VC struct {
+		Type              []string `json:"type"`
+		Issuer            string   `json:"issuer"`
+		CredentialSubject struct {
+			ID            string `json:"id"`
+			Type          string `json:"type"`
+			StatusPurpose string `json:"statusPurpose"`
+			EncodedList   string `json:"encodedList"`
+		} `json:"credentialSubject"`
+	} `json:"vc"`
+}
+
+// checkCredentialStatus checks every credentialStatus entry of a VC against
+// its status list and rejects the VC if any of them is set
+// Equivalent to Java's PresentationServiceAsync.validateVC() step 3
+func (s *Service) checkCredentialStatus(ctx context.Context, issuerDID string, statuses crypto.CredentialStatuses) error {
+	for _, status := range statuses {
+		statusListURL := status.StatusListCredential
+		if statusListURL == "" {
+			// StatusList2021-style entries only carry "{statusListCredential}#{index}" in id
+			statusListURL, _, _ = strings.Cut(status.ID, "#")
+		}
+		index, err := strconv.Atoi(status.StatusListIndex)
+		if statusListURL == "" || err != nil || index < 0 {
+			return errors.NewVPError(errors.ErrCredValidateVCContentError, "invalid vc credentialStatus")
+		}
+
+		statusList, err := s.statusListFetcher.FetchStatusList(ctx, statusListURL)
+		if err != nil {
+			if vpErr, ok := err.(*errors.VPError); ok {
+				return vpErr
+			}
+			return errors.NewVPError(errors.ErrConnLoadIssuerStatusListError, fmt.Sprintf("fail to load issuer's status list: %v", err))
+		}
+
+		set, statusPurpose, err := s.checkStatusList(statusList, issuerDID, index)
+		if err != nil {
+			return err
+		}
+		if status.StatusPurpose != "" && statusPurpose != "" && status.StatusPurpose != statusPurpose {
+			return errors.NewVPError(
+				errors.ErrSLValidateStatusListContentError,
+				fmt.Sprintf("status list purpose (%s) does not match credentialStatus purpose (%s)", statusPurpose, status.StatusPurpose),
+			)
+		}
+		if set {
+			if statusPurpose == "" {
+				statusPurpose = "revocation"
+			}
+			return errors.NewVPError(errors.ErrCredValidateVCStatusError, fmt.Sprintf("vc status is invalid (statusPurpose=%s)", statusPurpose))
+		}
+	}
+
+	return nil
+}
+
+// checkStatusList validates a status list credential issued by issuerDID and
+// returns whether the bit at index is set, along with the list's status purpose
+// Equivalent to Java's StatusListCheckTask.validate()
+func (s *Service) checkStatusList(statusList, issuerDID string, index int) (bool, string, error) {
+	// step 1: validate status list content
+	claims := &statusListClaims{}
+	token, _, err := new(jwt.Parser).ParseUnverified(statusList, claims)
+	if err != nil {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListContentError, "fail to validate status list content")
+	}
+
+	listIssuer := claims.Issuer
+	if listIssuer == "" {
+		listIssuer = claims.VC.Issuer
+	}
+	if listIssuer != issuerDID {
+		return false, "", errors.NewVPError(
+			errors.ErrSLValidateStatusListContentError,
+			fmt.Sprintf("status list issuer (%s) does not match vc issuer (%s)", listIssuer, issuerDID),
+		)
+	}
+	if !hasStatusListCredentialType(claims.VC.Type) || claims.VC.CredentialSubject.EncodedList == "" {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListContentError, "fail to validate status list content")
+	}
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListContentError, "status list has expired")
+	}
+
+	// step 2: validate status list proof
+	kid, _ := token.Header["kid"].(string)
+	publicKey, err := s.didResolver.ResolveVerificationMethod(issuerDID, kid, crypto.AssertionMethod)
+	if stderrors.Is(err, crypto.ErrNoMatchedPublicKey) {
+		return false, "", errors.NewVPError(errors.ErrConnNoMatchedIssuerPublicKey, "no issuer's public key matches the status list kid")
+	}
+	if err != nil {
+		return false, "", errors.NewVPError(errors.ErrSLLackOfIssuerPublicKey, "lack of issuer's public key")
+	}
+
+	_, err = jwt.Parse(statusList, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodECDSA, *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			return publicKey, nil
+		default:
+			return nil, fmt.Errorf("unsupported signing method: %v", token.Method.Alg())
+		}
+	}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListProofError, "fail to validate status list proof")
+	}
+
+	// step 3: check the bit at index
+	bitstring, err := decodeStatusList(claims.VC.CredentialSubject.EncodedList)
+	if err != nil {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListError, fmt.Sprintf("fail to decode status list: %v", err))
+	}
+	if index >= len(bitstring)*8 {
+		return false, "", errors.NewVPError(errors.ErrSLValidateStatusListError, fmt.Sprintf("status list index %d out of range", index))
+	}
+
+	set := bitstring[index/8]&(0x80>>(index%8)) != 0
+	return set, claims.VC.CredentialSubject.StatusPurpose, nil
+}
+
+// hasStatusListCredentialType reports whether types contains a status list credential type
+func hasStatusListCredentialType(types []string) bool {
+	for _, t := range types {
+		for _, expected := range statusListCredentialTypes {
+			if t == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeStatusList decodes a GZIP-compressed, base64 encoded bitstring.
+// Accepts the multibase base64url form ("u" prefix) as well as plain base64.
+// Equivalent to Java's ZipUtils.gzipUncompress()
+func decodeStatusList(encodedList string) ([]byte, error) {
+	var compressed []byte
+	var err error
+	if strings.HasPrefix(encodedList, "u") {
+		compressed, err = base64.RawURLEncoding.DecodeString(encodedList[1:])
+	} else {
+		compressed, err = base64.StdEncoding.DecodeString(encodedList)
+		if err != nil {
+			compressed, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(encodedList, "="))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 encoding: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %w", err)
+	}
+	defer reader.Close()
+
+	bitstring, err := io.ReadAll(io.LimitReader(reader, maxDecompressedStatusListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %w", err)
+	}
+	if len(bitstring) > maxDecompressedStatusListSize {
+		return nil, fmt.Errorf("decompressed status list exceeds %d bytes", maxDecompressedStatusListSize)
+	}
+
+	return bitstring, nil
+}
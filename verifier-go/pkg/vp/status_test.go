@@ -0,0 +1,353 @@
+package vp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/credential"
+	issuerModels "github.com/moda-gov-tw/twdiw-issuer-go/pkg/models"
+	"github.com/moda-gov-tw/twdiw-issuer-go/pkg/repository"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/crypto"
+	"github.com/moda-gov-tw/twdiw-verifier-go/pkg/errors"
+)
+
+// withIssuerPolicies registers a one-year policy for the credential type issued in tests
+func withIssuerPolicies() credential.Option {
+	return credential.WithPolicyRepository(repository.NewMemoryCredentialPolicyRepository(issuerModels.CredentialPolicyEntity{
+		CredentialType:     "NationalIDCredential",
+		ExpirationDuration: 1,
+		ExpirationTimeUnit: issuerModels.TimeUnitYear,
+		PairwiseEnabled:    true,
+	}))
+}
+
+// issuerStatusListFetcher serves status lists straight from an issuer service,
+// standing in for the issuer's /api/status-list endpoint
+func issuerStatusListFetcher(issuer *credential.Service) StatusListFetcher {
+	return StatusListFetcherFunc(func(ctx context.Context, statusListURL string) (string, error) {
+		u, err := url.Parse(statusListURL)
+		if err != nil {
+			return "", err
+		}
+		parts := strings.Split(strings.TrimPrefix(u.Path, "/api/status-list/"), "/")
+		if len(parts) != 2 {
+			return "", fmt.Errorf("unexpected status list URL: %s", statusListURL)
+		}
+
+		result, _, err := issuer.GetStatusList(ctx, parts[0], parts[1])
+		if err != nil {
+			return "", err
+		}
+
+		var response issuerModels.StatusListResponse
+		if err := json.Unmarshal([]byte(result), &response); err != nil {
+			return "", err
+		}
+		return response.StatusList, nil
+	})
+}
+
+// statusTestFixture holds an issuer, a holder and a verifier wired together
+type statusTestFixture struct {
+	issuer    *credential.Service
+	holderKey *ecdsa.PrivateKey
+	service   *Service
+}
+
+const (
+	statusTestIssuerDID = "did:example:issuer123"
+	statusTestHolderDID = "did:example:holder456"
+)
+
+func newStatusTestFixture(t *testing.T) *statusTestFixture {
+	t.Helper()
+
+	issuerKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	holderKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	der, err := x509.MarshalPKCS8PrivateKey(issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer key: %v", err)
+	}
+	issuer := credential.NewService(statusTestIssuerDID, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), withIssuerPolicies())
+
+	resolver := crypto.NewDIDResolver()
+	resolver.RegisterLocalKey(statusTestIssuerDID, issuer.PublicKey())
+	resolver.RegisterLocalKey(statusTestHolderDID, &holderKey.PublicKey)
+
+	service := NewServiceWithResolver(resolver)
+	service.SetStatusListFetcher(issuerStatusListFetcher(issuer))
+
+	return &statusTestFixture{issuer: issuer, holderKey: holderKey, service: service}
+}
+
+// issue issues a credential to the holder and returns its CID and a VP wrapping it
+func (f *statusTestFixture) issue(t *testing.T) (string, string) {
+	t.Helper()
+
+	result, status, err := f.issuer.Generate(context.Background(), &issuerModels.CredentialRequestDTO{
+		IssuerDID:           statusTestIssuerDID,
+		CredentialType:      "NationalIDCredential",
+		CredentialSubjectID: statusTestHolderDID,
+		CredentialSubject:   map[string]interface{}{"name": "Test User"},
+	})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to issue credential: %v (status %d)", err, status)
+	}
+
+	var issued issuerModels.CredentialResponseDTO
+	if err := json.Unmarshal([]byte(result), &issued); err != nil {
+		t.Fatalf("Failed to parse issuer response: %v", err)
+	}
+
+	vpJWT, err := crypto.SignVP(&crypto.VPClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "nonce-status",
+			Subject:   statusTestHolderDID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		VP: crypto.PresentationSubject{
+			Context:              []string{"https://www.w3.org/2018/credentials/v1"},
+			Type:                 []string{"VerifiablePresentation"},
+			VerifiableCredential: []string{issued.Credential},
+			Holder:               statusTestHolderDID,
+		},
+	}, f.holderKey, statusTestHolderDID+"#key-1")
+	if err != nil {
+		t.Fatalf("Failed to sign VP: %v", err)
+	}
+
+	return issued.CID, vpJWT
+}
+
+// expectVPError validates a VP and checks it is rejected with the given error code
+func expectVPError(t *testing.T, service *Service, vpJWT string, expected int) {
+	t.Helper()
+	expectVPErrorWithBinding(t, service, vpJWT, "", "", expected)
+}
+
+// expectVPErrorWithBinding validates a presentation bound to nonce and clientID and checks the error code
+func expectVPErrorWithBinding(t *testing.T, service *Service, presentation, nonce, clientID string, expected int) {
+	t.Helper()
+
+	_, status, err := service.ValidateWithBinding(context.Background(), []string{presentation}, nonce, clientID)
+
+	vpErr, ok := err.(*errors.VPError)
+	if !ok {
+		t.Fatalf("Expected VPError, got %T (%v)", err, err)
+	}
+	if vpErr.Code != expected {
+		t.Errorf("Expected error code %d, got %d (%s)", expected, vpErr.Code, vpErr.Message)
+	}
+	if status == http.StatusOK {
+		t.Error("Expected non-OK status")
+	}
+}
+
+// TestValidate_RevokedCredential tests that a revoked credential rejects the presentation
+func TestValidate_RevokedCredential(t *testing.T) {
+	// Given
+	f := newStatusTestFixture(t)
+	cid, vpJWT := f.issue(t)
+	if _, _, err := f.issuer.Revoke(context.Background(), cid, nil); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	// When / Then
+	expectVPError(t, f.service, vpJWT, errors.ErrCredValidateVCStatusError)
+}
+
+// TestValidate_SuspendedCredential tests that a suspended credential is rejected until recovered
+func TestValidate_SuspendedCredential(t *testing.T) {
+	// Given
+	f := newStatusTestFixture(t)
+	ctx := context.Background()
+	cid, vpJWT := f.issue(t)
+	if _, _, err := f.issuer.Suspend(ctx, cid, nil); err != nil {
+		t.Fatalf("Suspend failed: %v", err)
+	}
+
+	// When / Then
+	expectVPError(t, f.service, vpJWT, errors.ErrCredValidateVCStatusError)
+
+	// When - recovered
+	if _, _, err := f.issuer.Recover(ctx, cid, nil); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	_, status, err := f.service.Validate(ctx, []string{vpJWT})
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Errorf("Expected recovered credential to validate, got %v (status %d)", err, status)
+	}
+}
+
+// TestValidate_OtherCredentialRevoked tests that revoking one credential leaves others valid
+func TestValidate_OtherCredentialRevoked(t *testing.T) {
+	// Given
+	f := newStatusTestFixture(t)
+	revokedCID, _ := f.issue(t)
+	_, vpJWT := f.issue(t)
+	if _, _, err := f.issuer.Revoke(context.Background(), revokedCID, nil); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	// When
+	_, status, err := f.service.Validate(context.Background(), []string{vpJWT})
+
+	// Then
+	if err != nil || status != http.StatusOK {
+		t.Errorf("Expected validation to succeed, got %v (status %d)", err, status)
+	}
+}
+
+// TestValidate_StatusListErrors tests rejection of unusable status lists
+func TestValidate_StatusListErrors(t *testing.T) {
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// signStatusList signs a status list credential with an unset bitstring
+	signStatusList := func(issuer string, key *ecdsa.PrivateKey) string {
+		claims := &statusListClaims{}
+		claims.Issuer = issuer
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+		claims.VC.Type = []string{"VerifiableCredential", "BitstringStatusListCredential"}
+		// 16 zero bytes, gzip + base64url
+		claims.VC.CredentialSubject.EncodedList = "uH4sIAAAAAAAA_wAQAO__AAAAAAAAAAAAAAAAAAAAAAMAVUu77BAAAAA"
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		fetcher  StatusListFetcherFunc
+		expected int
+	}{
+		{
+			name: "Fetch failure",
+			fetcher: func(ctx context.Context, statusListURL string) (string, error) {
+				return "", fmt.Errorf("connection refused")
+			},
+			expected: errors.ErrConnLoadIssuerStatusListError,
+		},
+		{
+			name: "Not a JWT",
+			fetcher: func(ctx context.Context, statusListURL string) (string, error) {
+				return "not-a-jwt", nil
+			},
+			expected: errors.ErrSLValidateStatusListContentError,
+		},
+		{
+			name: "Issued by another DID",
+			fetcher: func(ctx context.Context, statusListURL string) (string, error) {
+				return signStatusList("did:example:other", otherKey), nil
+			},
+			expected: errors.ErrSLValidateStatusListContentError,
+		},
+		{
+			name: "Forged signature",
+			fetcher: func(ctx context.Context, statusListURL string) (string, error) {
+				return signStatusList(statusTestIssuerDID, otherKey), nil
+			},
+			expected: errors.ErrSLValidateStatusListProofError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newStatusTestFixture(t)
+			_, vpJWT := f.issue(t)
+			f.service.SetStatusListFetcher(tt.fetcher)
+
+			expectVPError(t, f.service, vpJWT, tt.expected)
+		})
+	}
+}
+
+// TestHTTPStatusListFetcher tests loading status lists as a raw JWT or a JSON response
+func TestHTTPStatusListFetcher(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/jwt")
+		w.Write([]byte("header.payload.signature\n"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"group_name":"r0","status_list":"header.payload.signature","content_type":"application/jwt"}`))
+	})
+	mux.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":64003}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPStatusListFetcher()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"Raw JWT", "/jwt", 0},
+		{"JSON response", "/json", 0},
+		{"Not found", "/missing", errors.ErrConnLoadIssuerStatusListError},
+		{"Invalid JSON response", "/invalid", errors.ErrConnInvalidIssuerStatusList},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusList, err := fetcher.FetchStatusList(context.Background(), server.URL+tt.path)
+
+			if tt.expected == 0 {
+				if err != nil || statusList != "header.payload.signature" {
+					t.Errorf("Expected status list JWT, got %q (%v)", statusList, err)
+				}
+				return
+			}
+
+			vpErr, ok := err.(*errors.VPError)
+			if !ok || vpErr.Code != tt.expected {
+				t.Errorf("Expected error code %d, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestDecodeStatusList tests decoding of the multibase and plain base64 encodings
+func TestDecodeStatusList(t *testing.T) {
+	tests := []struct {
+		name        string
+		encodedList string
+		wantErr     bool
+	}{
+		{"Multibase base64url", "uH4sIAAAAAAAA_wAQAO__AAAAAAAAAAAAAAAAAAAAAAMAVUu77BAAAAA", false},
+		{"Standard base64", "H4sIAAAAAAAA/wAQAO//AAAAAAAAAAAAAAAAAAAAAAMAVUu77BAAAAA=", false},
+		{"Invalid base64", "u!!!", true},
+		{"Not gzip", "dGVzdA==", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bitstring, err := decodeStatusList(tt.encodedList)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && len(bitstring) != 16 {
+				t.Errorf("Expected 16 bytes, got %d", len(bitstring))
+			}
+		})
+	}
+}